@@ -0,0 +1,298 @@
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/monteCarlo"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/portfolio"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/report"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/stats"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/tradeLog"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/utils"
+	"github.com/rs/zerolog/log"
+)
+
+// analysis is a log of trades and everything needed to calculate and write its statistics,
+// shared by the backtest and the report command so both produce the same outputs.
+type analysis struct {
+	// title is the title of the HTML report.
+	title string
+
+	// trades is the log of trades to analyse.
+	trades *tradeLog.Log
+
+	// configuration is the user configuration, for the starting balance and the optional analytics.
+	configuration *utils.Configuration
+
+	// dimensions is every dimension the breakdown report groups by.
+	dimensions []stats.Dimension
+
+	// baseCurrency is the currency every statistic is in.
+	baseCurrency string
+
+	// runTime is the time of the run, used to name every output file.
+	runTime time.Time
+
+	// sessions is the session windows the trades were taken in, the random entry baseline is skipped without them.
+	sessions []*portfolio.Session
+
+	// marginUtilisation is the margin held on every candle, the marked to market equity curve is skipped without it.
+	marginUtilisation []*portfolio.MarginPoint
+}
+
+// analysisDimensions validates the optional analytics of the configuration and resolves every dimension the
+// breakdown report groups by, so an invalid configuration fails before any trades are loaded or backtested.
+func analysisDimensions(cfg *utils.Configuration) ([]stats.Dimension, error) {
+	if cfg.MonteCarlo != nil {
+		if err := monteCarlo.Validate(cfg.MonteCarlo); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Significance != nil {
+		if err := stats.ValidateSignificance(cfg.Significance); err != nil {
+			return nil, err
+		}
+		if cfg.Significance.ConfidenceLevel <= 1 {
+			log.Warn().Msgf(
+				"Significance ConfidenceLevel is a percentage, %v gives a %v%% interval not a %v%% one",
+				cfg.Significance.ConfidenceLevel,
+				cfg.Significance.ConfidenceLevel,
+				cfg.Significance.ConfidenceLevel*100,
+			)
+		}
+	}
+
+	dimensions := stats.StandardDimensions
+	for _, field := range cfg.Breakdowns {
+		dimension, err := stats.FieldDimension(field)
+		if err != nil {
+			return nil, err
+		}
+		dimensions = append(dimensions, dimension)
+	}
+
+	return dimensions, nil
+}
+
+// run calculates every statistic of the trades, logs the headline figures and writes the results, summary,
+// breakdown, equity curves, HTML report, Monte Carlo, significance and excursion files. Errors writing a file
+// are logged so the rest are still written.
+func (a *analysis) run() {
+	startingBalance := a.configuration.StartingBalance
+
+	// Calculate the performance statistics and print them to the console
+	summary := stats.Calculate(a.trades, startingBalance)
+	log.Info().Msgf("Performance summary in %s:", a.baseCurrency)
+	if err := summary.Print(os.Stdout); err != nil {
+		log.Error().Msg(err.Error())
+	}
+
+	// Build the equity curves, the marked to market curve captures the drawdown while trades are open
+	equityCurves := map[string]*stats.EquityCurve{
+		"equity-trade": stats.TradeEquityCurve(a.trades, startingBalance),
+		"equity-daily": stats.DailyEquityCurve(a.trades, startingBalance),
+	}
+	if len(a.marginUtilisation) > 0 {
+		equityCurves["equity-bar"] = stats.MarkedToMarketCurve(a.marginUtilisation, startingBalance)
+		if deepest := equityCurves["equity-bar"].MaxDrawdown(); deepest != nil {
+			log.Info().Msgf(
+				"Max marked to market drawdown: %.2f (%.2f%%) at %v",
+				deepest.Drawdown,
+				deepest.DrawdownPercent,
+				deepest.Time,
+			)
+		}
+	}
+
+	// Resample the trades into alternative sequences to see how much worse the results could have been
+	var monteCarloResult *monteCarlo.Result
+	if a.configuration.MonteCarlo != nil && len(*a.trades) > 0 {
+		var err error
+		monteCarloResult, err = monteCarlo.Run(a.trades, startingBalance, a.configuration.MonteCarlo)
+		if err != nil {
+			log.Error().Msg(err.Error())
+		} else {
+			logMonteCarlo(monteCarloResult, a.configuration.MonteCarlo)
+		}
+	}
+
+	// Test whether the edge of the trades is real rather than noise
+	var significance *stats.Significance
+	if cfg := a.configuration.Significance; cfg != nil && len(*a.trades) > 0 {
+		significance = stats.CalculateSignificance(a.trades, cfg.Resamples, cfg.ConfidenceLevel, cfg.Seed)
+		if !cfg.SkipBaseline && len(a.sessions) > 0 {
+			significance.Baseline = stats.RandomEntryBaseline(
+				a.trades,
+				a.sessions,
+				a.configuration.Instruments,
+				utils.ContractSpecs,
+				cfg.BaselineSimulations,
+				cfg.Seed,
+			)
+		}
+		logSignificance(significance)
+	}
+
+	// Summarise how far trades moved against and in favour of the entry so stops and targets can be tuned
+	excursions := a.trades.SummariseExcursions()
+	log.Info().Msgf(
+		"Average MAE winners %.2fR losers %.2fR, average MFE winners %.2fR losers %.2fR, winners kept %.2f%% of MFE",
+		excursions.AverageWinnerMAER,
+		excursions.AverageLoserMAER,
+		excursions.AverageWinnerMFER,
+		excursions.AverageLoserMFER,
+		excursions.MFECapturePercent,
+	)
+
+	// Write log to disk
+	resultsPath, err := tradeLog.ResultsFilePath("results", a.runTime, "csv")
+	if err == nil {
+		err = tradeLog.Write(a.trades, resultsPath)
+	}
+	if err != nil {
+		log.Error().Msg(err.Error())
+	}
+
+	// Write the performance statistics so they can be read by other tools
+	summaryPath, err := tradeLog.ResultsFilePath("summary", a.runTime, "json")
+	if err == nil {
+		err = summary.WriteJSON(summaryPath)
+	}
+	if err != nil {
+		log.Error().Msg(err.Error())
+	}
+
+	// Write the statistics of the trades grouped by every dimension
+	breakdown := stats.BreakdownBy(a.trades, a.dimensions...)
+	if len(*a.trades) > 0 {
+		breakdownPath, err := tradeLog.ResultsFilePath("breakdown", a.runTime, "csv")
+		if err == nil {
+			err = breakdown.WriteCSV(breakdownPath)
+		}
+		if err != nil {
+			log.Error().Msg(err.Error())
+		}
+	}
+
+	// Write every equity curve alongside the results
+	for prefix, curve := range equityCurves {
+		if len(*curve) == 0 {
+			continue
+		}
+		curvePath, err := tradeLog.ResultsFilePath(prefix, a.runTime, "csv")
+		if err == nil {
+			err = curve.WriteCSV(curvePath)
+		}
+		if err != nil {
+			log.Error().Msg(err.Error())
+		}
+	}
+
+	// Write every statistic, chart and trade to a single HTML file that can be opened offline
+	htmlReport := &report.Report{
+		Title:        a.title,
+		RunTime:      a.runTime,
+		BaseCurrency: a.baseCurrency,
+		Summary:      summary,
+		Equity:       equityCurves["equity-trade"],
+		Breakdown:    breakdown,
+		Trades:       a.trades,
+	}
+	reportPath, err := tradeLog.ResultsFilePath("report", a.runTime, "html")
+	if err == nil {
+		err = htmlReport.WriteHTML(reportPath)
+	}
+	if err != nil {
+		log.Error().Msg(err.Error())
+	}
+
+	// Write the percentiles of the Monte Carlo simulation
+	if monteCarloResult != nil {
+		monteCarloPath, err := tradeLog.ResultsFilePath("montecarlo", a.runTime, "csv")
+		if err == nil {
+			err = monteCarloResult.WriteCSV(monteCarloPath)
+		}
+		if err != nil {
+			log.Error().Msg(err.Error())
+		}
+	}
+
+	// Write the significance tests
+	if significance != nil {
+		significancePath, err := tradeLog.ResultsFilePath("significance", a.runTime, "json")
+		if err == nil {
+			err = significance.WriteJSON(significancePath)
+		}
+		if err != nil {
+			log.Error().Msg(err.Error())
+		}
+	}
+
+	// Write the MAE/MFE analysis in quarter R steps up to 3R
+	if len(*a.trades) > 0 {
+		excursionPath, err := tradeLog.ResultsFilePath("excursion", a.runTime, "csv")
+		if err == nil {
+			err = tradeLog.WriteExcursions(a.trades.ExcursionReport(0.25, 3), excursionPath)
+		}
+		if err != nil {
+			log.Error().Msg(err.Error())
+		}
+	}
+}
+
+// logMonteCarlo logs every percentile of a Monte Carlo simulation and its risk of ruin.
+func logMonteCarlo(result *monteCarlo.Result, cfg *utils.MonteCarloConfiguration) {
+	for _, row := range result.Percentiles {
+		log.Info().Msgf(
+			"Monte Carlo %gth percentile: final equity %.2f, max drawdown %.2f%%, longest losing streak %.0f",
+			row.Percentile,
+			row.FinalEquity,
+			row.MaxDrawdownPercent,
+			row.LongestLosingStreak,
+		)
+	}
+	log.Info().Msgf(
+		"Monte Carlo risk of ruin at a %.2f%% drawdown: %.2f%% of %d %s simulations",
+		cfg.RuinDrawdownPercent,
+		result.RiskOfRuin,
+		cfg.Simulations,
+		cfg.Mode,
+	)
+}
+
+// logSignificance logs the confidence intervals, the t-test and the random entry baseline of the trades.
+func logSignificance(significance *stats.Significance) {
+	for _, interval := range []struct {
+		name     string
+		interval stats.ConfidenceInterval
+	}{
+		{"Expectancy (R)", significance.ExpectancyR},
+		{"Win rate (%)", significance.WinRate},
+		{"Profit factor (R)", significance.ProfitFactor},
+	} {
+		log.Info().Msgf(
+			"%s: %.2f, %g%% confidence interval %.2f to %.2f",
+			interval.name,
+			interval.interval.Estimate,
+			significance.ConfidenceLevel,
+			interval.interval.Lower,
+			interval.interval.Upper,
+		)
+	}
+	log.Info().Msgf(
+		"t-test of the average R against 0 over %d trades: t %.2f, p-value %.4f",
+		significance.Trades,
+		significance.TStatistic,
+		significance.PValue,
+	)
+	if baseline := significance.Baseline; baseline != nil {
+		log.Info().Msgf(
+			"Random entry baseline over %d simulations: %.2fR against %.2fR, p-value %.4f",
+			baseline.Simulations,
+			baseline.RandomExpectancyR,
+			baseline.ExpectancyR,
+			baseline.PValue,
+		)
+	}
+}
@@ -0,0 +1,603 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/backtestData"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/manifest"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/optimize"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/report"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/stats"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/tradeLog"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/utils"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// UnknownCommand is an error for when the first argument is not a subcommand.
+var UnknownCommand = errors.New("unknown command")
+
+// runCommand runs the subcommand with its arguments, the run time is used to name every output file.
+func runCommand(command string, args []string, runTime time.Time) error {
+	switch command {
+	case "report":
+		return reportCommand(args, runTime)
+	case "compare":
+		return compareCommand(args, runTime)
+	case "rerun":
+		return rerunCommand(args, runTime)
+	case "optimize":
+		return optimizeCommand(args, runTime)
+	case "walkforward":
+		return walkForwardCommand(args, runTime)
+	default:
+		return fmt.Errorf("%q, the commands are report, compare, rerun, optimize and walkforward: %w", command, UnknownCommand)
+	}
+}
+
+// listFlag splits a comma separated flag value into its values, an empty value is an empty list.
+func listFlag(value string) []string {
+	var values []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+
+	return values
+}
+
+// dateFlag parses a flag value in the 2006-01-02 format, an empty value is the zero time.
+func dateFlag(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	date, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("-%s must be a date like 2006-01-02: %w", name, err)
+	}
+
+	return date, nil
+}
+
+// addFilterFlags adds the flags of a tradeLog.Filter to a flag set, and returns a function that builds the filter
+// once the flags are parsed.
+func addFilterFlags(flags *flag.FlagSet) func() (tradeLog.Filter, error) {
+	instruments := flags.String("instruments", "", "comma separated instruments to keep, all if empty")
+	directions := flags.String("directions", "", "comma separated directions to keep, LONG or SHORT, all if empty")
+	regions := flags.String("regions", "", "comma separated regions to keep, all if empty")
+	from := flags.String("from", "", "keep trades taken on or after this date, 2006-01-02")
+	to := flags.String("to", "", "keep trades taken before this date, 2006-01-02")
+
+	return func() (tradeLog.Filter, error) {
+		filter := tradeLog.Filter{
+			Instruments: listFlag(*instruments),
+			Directions:  listFlag(*directions),
+			Regions:     listFlag(*regions),
+		}
+
+		var err error
+		if filter.From, err = dateFlag("from", *from); err != nil {
+			return filter, err
+		}
+		filter.To, err = dateFlag("to", *to)
+
+		return filter, err
+	}
+}
+
+// readLogs reads and merges the trade logs matching every pattern, so a pattern like
+// backtesting_results/results-*.csv works without a shell to expand it.
+func readLogs(patterns []string) (*tradeLog.Log, error) {
+	var logs []*tradeLog.Log
+	for _, pattern := range patterns {
+		filePaths, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if len(filePaths) == 0 {
+			return nil, fmt.Errorf("no results files match %s", pattern)
+		}
+
+		for _, filePath := range filePaths {
+			l, err := tradeLog.Read(filePath)
+			if err != nil {
+				return nil, err
+			}
+			log.Info().Msgf("Read %d trades from %s", len(*l), filePath)
+			if l.Unsized() {
+				log.Warn().Msgf(
+					"%s has no contract sizes, it was likely written before trades were sized. Only the statistics in R "+
+						"are valid, the net profit, CAGR, drawdown, Sharpe and every other currency statistic read as 0",
+					filePath,
+				)
+			}
+			logs = append(logs, l)
+		}
+	}
+
+	return tradeLog.Merge(logs...), nil
+}
+
+// reportCommand reads one or more results CSVs, filters and merges their trades and reruns every statistic and
+// report on them without backtesting again.
+func reportCommand(args []string, runTime time.Time) error {
+	flags := flag.NewFlagSet("report", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: report [flags] results.csv [results.csv ...]")
+		flags.PrintDefaults()
+	}
+	configPath := flags.String("config", "config.json", "the configuration of the analytics and starting balance")
+	buildFilter := addFilterFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("report needs at least one results CSV")
+	}
+
+	cfg, err := utils.LoadConfiguration(*configPath)
+	if err != nil {
+		return err
+	}
+	dimensions, err := analysisDimensions(cfg)
+	if err != nil {
+		return err
+	}
+
+	filter, err := buildFilter()
+	if err != nil {
+		return err
+	}
+
+	merged, err := readLogs(flags.Args())
+	if err != nil {
+		return err
+	}
+	trades := merged.Filter(filter)
+	log.Info().Msgf("Reporting on %d of %d trades", len(*trades), len(*merged))
+
+	(&analysis{
+		title:         "Strongbow report",
+		trades:        trades,
+		configuration: cfg,
+		dimensions:    dimensions,
+		baseCurrency:  cfg.BaseCurrency,
+		runTime:       runTime,
+	}).run()
+	log.Info().Msg("Computering finito.")
+
+	return nil
+}
+
+// compareCommand matches the trades of a base and a candidate results CSV, classifies every trade as added, removed,
+// changed or unchanged and summarises the effect on the statistics, so the trades a parameter change affected can
+// be seen.
+func compareCommand(args []string, runTime time.Time) error {
+	flags := flag.NewFlagSet("compare", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: compare [flags] base.csv candidate.csv")
+		flags.PrintDefaults()
+	}
+	configPath := flags.String("config", "config.json", "the configuration of the starting balance")
+	buildFilter := addFilterFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return errors.New("compare needs a base and a candidate results CSV")
+	}
+
+	cfg, err := utils.LoadConfiguration(*configPath)
+	if err != nil {
+		return err
+	}
+	filter, err := buildFilter()
+	if err != nil {
+		return err
+	}
+
+	base, err := readLogs(flags.Args()[:1])
+	if err != nil {
+		return err
+	}
+	candidate, err := readLogs(flags.Args()[1:])
+	if err != nil {
+		return err
+	}
+	base, candidate = base.Filter(filter), candidate.Filter(filter)
+
+	// Classify every trade and sum the effect of each kind of change on the profit
+	comparison := tradeLog.Compare(base, candidate)
+	summaries := comparison.Summarise()
+	for _, change := range []string{
+		tradeLog.Change.ADDED,
+		tradeLog.Change.REMOVED,
+		tradeLog.Change.CHANGED,
+		tradeLog.Change.UNCHANGED,
+	} {
+		log.Info().Str("change", change).Msgf(
+			"%d trades, net effect %.2fR (%.2f %s)",
+			summaries[change].Trades,
+			summaries[change].ChangeR,
+			summaries[change].ChangeProfitValue,
+			cfg.BaseCurrency,
+		)
+	}
+
+	// Print the change in every statistic as an aligned table
+	statistics := stats.CompareSummaries(
+		stats.Calculate(base, cfg.StartingBalance),
+		stats.Calculate(candidate, cfg.StartingBalance),
+	)
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(table, "Statistic\tBase\tCandidate\tChange\t")
+	for _, statistic := range *statistics {
+		fmt.Fprintf(table, "%s\t%.2f\t%.2f\t%+.2f\t\n", statistic.Statistic, statistic.Base, statistic.Candidate, statistic.Change)
+	}
+	if err := table.Flush(); err != nil {
+		log.Error().Msg(err.Error())
+	}
+
+	comparisonPath, err := tradeLog.ResultsFilePath("compare", runTime, "csv")
+	if err == nil {
+		err = tradeLog.WriteComparison(comparison, comparisonPath)
+	}
+	if err != nil {
+		log.Error().Msg(err.Error())
+	}
+
+	statisticsPath, err := tradeLog.ResultsFilePath("compare-summary", runTime, "csv")
+	if err == nil {
+		err = statistics.WriteCSV(statisticsPath)
+	}
+	if err != nil {
+		log.Error().Msg(err.Error())
+	}
+	log.Info().Msg("Computering finito.")
+
+	return nil
+}
+
+// rerunCommand backtests again with the effective configuration and contract specifications of a run manifest.
+// It returns an error if a data file has changed since the run, unless forced.
+func rerunCommand(args []string, runTime time.Time) error {
+	flags := flag.NewFlagSet("rerun", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: rerun [flags] manifest.json")
+		flags.PrintDefaults()
+	}
+	force := flags.Bool("force", false, "rerun even if a data file has changed since the run")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("rerun needs the manifest of a run")
+	}
+
+	runManifest, err := manifest.Read(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if err := runManifest.Verify(); err != nil {
+		if !*force {
+			return fmt.Errorf("%w, use -force to rerun anyway", err)
+		}
+		log.Warn().Msgf("The rerun will not match the run exactly: %s", err.Error())
+	}
+	if runManifest.Build.Commit != "" && runManifest.Build.Commit != manifest.ReadBuild().Commit {
+		log.Warn().Msgf("The run was made by commit %s, results may differ from this binary", runManifest.Build.Commit)
+	}
+
+	// Run from the working directory of the run, so the data, contract specification and rate files it read are
+	// read again wherever the rerun is started from, and the outputs sit next to those of the run
+	if runManifest.WorkingDirectory != "" {
+		if err := os.Chdir(runManifest.WorkingDirectory); err != nil {
+			return fmt.Errorf("error changing to the working directory of the run: %w", err)
+		}
+	}
+
+	log.Info().Msgf("Rerunning the run of %v in %s", runManifest.RunTime, runManifest.WorkingDirectory)
+	utils.ContractSpecs = runManifest.ContractSpecs
+	return runBacktest(runManifest.Configuration, runTime)
+}
+
+// addOptimizationFlags adds the flags of the optimiser to a flag set, and returns a function that loads the
+// configuration, contract specifications and candles, and builds an optimize.Evaluator once the flags are parsed.
+func addOptimizationFlags(flags *flag.FlagSet) func() (*utils.Configuration, *optimize.Evaluator, error) {
+	configPath := flags.String("config", "config.json", "the configuration with the parameters to optimise")
+	objective := flags.String("objective", "", "the objective to rank by instead of the configured one")
+	workers := flags.Int("workers", 0, "the amount of combinations to backtest at once instead of the configured amount")
+
+	return func() (*utils.Configuration, *optimize.Evaluator, error) {
+		cfg, err := utils.LoadConfiguration(*configPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		if cfg.Optimization != nil {
+			if *objective != "" {
+				cfg.Optimization.Objective = *objective
+			}
+			if *workers != 0 {
+				cfg.Optimization.Workers = *workers
+			}
+		}
+		if err := optimize.Validate(cfg); err != nil {
+			return nil, nil, err
+		}
+		if err := loadContractSpecs(cfg); err != nil {
+			return nil, nil, err
+		}
+		converter, err := newConverter(cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Every combination logs every trade at debug, which would flood the log file
+		log.Logger = log.Logger.Level(zerolog.InfoLevel)
+
+		data := make(map[string]backtestData.Data)
+		for _, instrument := range cfg.Keys() {
+			if data[instrument], err = backtestData.Load(instrumentDataPath(instrument)); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		return cfg, optimize.NewEvaluator(cfg, utils.ContractSpecs, converter, data), nil
+	}
+}
+
+// optimizeCommand searches the combinations of the parameters in the Optimization section of the configuration in
+// parallel with the configured method, journaling every evaluation so the search can be resumed, and writes the
+// combinations ranked by the objective or their robustness, and a sensitivity heatmap of each pair of parameters.
+func optimizeCommand(args []string, runTime time.Time) error {
+	flags := flag.NewFlagSet("optimize", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: optimize [flags]")
+		flags.PrintDefaults()
+	}
+	buildEvaluator := addOptimizationFlags(flags)
+	resume := flags.String("resume", "", "the evaluations journal of a search to resume and append to")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, evaluator, err := buildEvaluator()
+	if err != nil {
+		return err
+	}
+	optimization := cfg.Optimization
+	space, err := optimize.NewSpace(optimization.Parameters)
+	if err != nil {
+		return err
+	}
+
+	// Resume from the journal of an earlier search made on the same configuration and data, keeping only the
+	// evaluations of the same parameters
+	journalPath := *resume
+	if journalPath == "" {
+		if journalPath, err = tradeLog.ResultsFilePath("evaluations", runTime, "jsonl"); err != nil {
+			return err
+		}
+	}
+	var data []manifest.DataFile
+	for _, filePath := range dataFiles(cfg) {
+		dataFile, err := manifest.HashFile(filePath)
+		if err != nil {
+			return err
+		}
+		data = append(data, dataFile)
+	}
+	fingerprint, err := optimize.NewFingerprint(cfg, space.Names(), data)
+	if err != nil {
+		return err
+	}
+	journal, journaled, err := optimize.OpenJournal(journalPath, fingerprint)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := journal.Close(); err != nil {
+			log.Error().Msg(err.Error())
+		}
+	}()
+	var previous []*optimize.Evaluation
+	for _, evaluation := range journaled {
+		if slices.Equal(evaluation.Parameters.Names(), space.Names()) {
+			previous = append(previous, evaluation)
+		}
+	}
+	if len(journaled) > 0 {
+		log.Info().Msgf("Resuming %d evaluations from %s, skipped %d with other parameters", len(previous), journalPath, len(journaled)-len(previous))
+	}
+
+	budget := optimize.Budget{Evaluations: optimization.MaxEvaluations}
+	if optimization.MaxMinutes > 0 {
+		budget.Deadline = time.Now().Add(time.Duration(optimization.MaxMinutes * float64(time.Minute)))
+	}
+	optimizer := optimize.NewOptimizer(
+		evaluator,
+		cfg.BacktestStartDate.Time,
+		cfg.BacktestEndDate.Time,
+		optimization.Workers,
+		optimization.MinimumTrades,
+		budget,
+		journal,
+		previous,
+	)
+
+	log.Info().Msgf(
+		"Optimising by %s with a %s search and %d workers, journaling to %s",
+		optimization.Objective,
+		optimization.Method,
+		optimization.Workers,
+		journalPath,
+	)
+	random := rand.New(rand.NewSource(optimization.Seed))
+	switch optimization.Method {
+	case utils.SearchMethod.RANDOM:
+		optimize.RandomSearch(optimizer, space, random)
+	case utils.SearchMethod.GENETIC:
+		optimize.GeneticSearch(optimizer, space, random, optimization.Genetic)
+	default:
+		optimize.GridSearch(optimizer, optimize.Grid(optimization.Parameters))
+	}
+	evaluations := optimizer.Evaluations()
+
+	// Score each combination by its neighbours too, so a plateau can be picked over a lone peak
+	optimize.ScoreRobustness(evaluations, optimization.Parameters)
+	if optimization.RankByRobustness {
+		optimize.RankRobust(evaluations, optimization.MinimumTrades)
+	}
+
+	for rank, evaluation := range evaluations[:min(len(evaluations), 5)] {
+		log.Info().Msgf(
+			"#%d %s: score %.2f, robust score %.2f over %d neighbours, %d trades, net %.2fR, profit factor %.2f",
+			rank+1,
+			evaluation.Parameters.Key(),
+			evaluation.Score,
+			evaluation.Robustness.Score,
+			evaluation.Robustness.Neighbours,
+			evaluation.Summary.Trades,
+			evaluation.Summary.NetProfitR,
+			evaluation.Summary.ProfitFactor,
+		)
+	}
+
+	optimizePath, err := tradeLog.ResultsFilePath("optimize", runTime, "csv")
+	if err == nil {
+		err = optimize.WriteCSV(evaluations, optimizePath)
+	}
+	if err != nil {
+		log.Error().Msg(err.Error())
+	}
+
+	if pairs := optimize.HeatmapPairs(optimization); len(pairs) > 0 {
+		heatmapsDirectory, err := tradeLog.ResultsSubdirectory("heatmaps", runTime)
+		if err != nil {
+			log.Error().Msg(err.Error())
+		} else {
+			written := writeHeatmaps(evaluations, optimization.Parameters, pairs, heatmapsDirectory)
+			log.Info().Msgf("Wrote %d sensitivity heatmaps to %s", written, heatmapsDirectory)
+		}
+	}
+	log.Info().Msg("Computering finito.")
+
+	return nil
+}
+
+// writeHeatmaps writes the sensitivity heatmap of each pair of parameters to a CSV and an SVG in a directory, and
+// returns how many were written.
+func writeHeatmaps(
+	evaluations []*optimize.Evaluation,
+	ranges map[string]*utils.ParameterRange,
+	pairs [][2]string,
+	directory string,
+) int {
+	written := 0
+	for _, pair := range pairs {
+		heatmap := optimize.NewHeatmap(evaluations, ranges, pair[0], pair[1])
+		filePath := filepath.Join(directory, heatmap.FileName())
+
+		err := heatmap.WriteCSV(filePath + ".csv")
+		if err == nil {
+			err = report.NewSensitivityChart(heatmap).WriteSVG(filePath + ".svg")
+		}
+		if err != nil {
+			log.Error().Msg(err.Error())
+			continue
+		}
+		written++
+	}
+
+	return written
+}
+
+// walkForwardCommand optimises the parameters in the Optimization section of the configuration on each in-sample
+// window of a walk-forward analysis and trades the best out-of-sample, then writes every segment and analyses the
+// out-of-sample trades stitched together.
+func walkForwardCommand(args []string, runTime time.Time) error {
+	flags := flag.NewFlagSet("walkforward", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: walkforward [flags]")
+		flags.PrintDefaults()
+	}
+	buildEvaluator := addOptimizationFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, evaluator, err := buildEvaluator()
+	if err != nil {
+		return err
+	}
+	if cfg.Optimization.WalkForward == nil {
+		return fmt.Errorf("Optimization WalkForward is not set: %w", optimize.ConfigurationInvalid)
+	}
+	if cfg.Optimization.Method != utils.SearchMethod.GRID {
+		return fmt.Errorf("walkforward only searches a GRID, got %s: %w", cfg.Optimization.Method, optimize.ConfigurationInvalid)
+	}
+	dimensions, err := analysisDimensions(cfg)
+	if err != nil {
+		return err
+	}
+	windows, err := optimize.Windows(cfg.BacktestStartDate.Time, cfg.BacktestEndDate.Time, cfg.Optimization.WalkForward)
+	if err != nil {
+		return err
+	}
+
+	result := optimize.WalkForward(
+		evaluator,
+		optimize.Grid(cfg.Optimization.Parameters),
+		windows,
+		cfg.Optimization.Workers,
+		cfg.Optimization.MinimumTrades,
+	)
+	for index, segment := range result.Segments {
+		log.Info().Msgf(
+			"Segment %d %s: in-sample %.2fR over %d trades, out-of-sample %.2fR over %d trades, efficiency %.2f",
+			index+1,
+			segment.InSample.Parameters.Key(),
+			segment.InSample.Summary.NetProfitR,
+			segment.InSample.Summary.Trades,
+			segment.OutOfSample.Summary.NetProfitR,
+			segment.OutOfSample.Summary.Trades,
+			segment.Efficiency,
+		)
+	}
+	log.Info().Msgf("Walk-forward efficiency: %.2f", result.Efficiency)
+
+	walkForwardPath, err := tradeLog.ResultsFilePath("walkforward", runTime, "csv")
+	if err == nil {
+		err = result.WriteCSV(walkForwardPath)
+	}
+	if err != nil {
+		log.Error().Msg(err.Error())
+	}
+
+	// Analyse the out-of-sample trades as one run, which writes their equity curves alongside the segments
+	(&analysis{
+		title:         "Strongbow walk-forward",
+		trades:        result.Trades,
+		configuration: cfg,
+		dimensions:    dimensions,
+		baseCurrency:  cfg.BaseCurrency,
+		runTime:       runTime,
+	}).run()
+	log.Info().Msg("Computering finito.")
+
+	return nil
+}
@@ -295,13 +295,19 @@ func (r Row) TradeDirection() (string, error) {
 // In the event of a short a valid trade entry candle would wick above a previous high, but close below
 // In the event of a long a valid trade would wick below the previous low but close above.
 func (r Row) IsValidEntry(tradeDirection string) bool {
+	return r.SweptBoundary(tradeDirection) != nil
+}
+
+// SweptBoundary returns the broken boundary a candle wicked through but closed back inside of,
+// or nil if the candle is not a valid entry, see IsValidEntry.
+func (r Row) SweptBoundary(tradeDirection string) *Boundary {
 	// If the direction is SHORT
 	if tradeDirection == utils.TradeDirection.SHORT {
 		// Get the most recent broken high to check if we can enter, sort by ascending as we want the lowest high first
 		filteredBoundaries, err := r.HighBoundaries.GetSortedBrokenBoundary(true)
 		if err != nil {
 			log.Debug().Msg("No broken high boundary found")
-			return false
+			return nil
 		}
 
 		// Get the first filtered boundary
@@ -309,14 +315,14 @@ func (r Row) IsValidEntry(tradeDirection string) bool {
 
 		// if it has wicked above but closed below the most recent high.
 		if r.High > mostRecentHighBoundary.Value && r.Close < mostRecentHighBoundary.Value {
-			return true
+			return mostRecentHighBoundary
 		}
 	} else if tradeDirection == utils.TradeDirection.LONG {
 		// Get the most recent broken low to check if we can enter, sort by descending as we want the highest low first
 		filteredBoundaries, err := r.LowBoundaries.GetSortedBrokenBoundary(false)
 		if err != nil {
 			log.Debug().Msg("No broken low boundary found")
-			return false
+			return nil
 		}
 
 		// Get the first filtered boundary
@@ -324,10 +330,10 @@ func (r Row) IsValidEntry(tradeDirection string) bool {
 
 		// if it has wicked below but closed above the most recent low .
 		if r.Low < mostRecentLowBoundary.Value && r.Close > mostRecentLowBoundary.Value {
-			return true
+			return mostRecentLowBoundary
 		}
 	}
-	return false
+	return nil
 }
 
 // Data is a slice of Row memory pointers
@@ -416,6 +422,18 @@ func Load(filePath string) (Data, error) {
 	return data, nil
 }
 
+// Clone returns a copy of every row, so indicators can be calculated on the copy without changing d.
+// The boundaries of each row are shared with d as they are replaced, not changed, by CalculateUnbrokenHighsLows.
+func (d *Data) Clone() Data {
+	clone := make(Data, len(*d))
+	for index, row := range *d {
+		copied := *row
+		clone[index] = &copied
+	}
+
+	return clone
+}
+
 // CalculateSMA calculates the Simple Moving Averages for each Row in Data.
 func (d *Data) CalculateSMA(config *utils.InstrumentConfiguration, tickSize float64) error {
 	// Check if the lookback amounts are positive and non-zero and check if the data is not empty
@@ -376,6 +376,25 @@ func TestIsValidEntry(t *testing.T) {
 	}
 }
 
+// TestSweptBoundary tests that the broken boundary swept by a valid entry is returned
+func TestSweptBoundary(t *testing.T) {
+	boundaryTime := time.Date(2023, 10, 20, 9, 0, 0, 0, time.UTC)
+	row := Row{
+		Low:   95.0,
+		Close: 110.0,
+		LowBoundaries: Boundaries{
+			{Time: boundaryTime.Add(-time.Hour), Value: 90.0, Broken: true},
+			{Time: boundaryTime, Value: 100.0, Broken: true},
+		},
+	}
+
+	swept := row.SweptBoundary(utils.TradeDirection.LONG)
+	assert.NotNil(t, swept)
+	assert.Equal(t, 100.0, swept.Value)
+	assert.Equal(t, boundaryTime, swept.Time)
+	assert.Nil(t, row.SweptBoundary(utils.TradeDirection.SHORT))
+}
+
 // TestRowString tests printing the string of a row
 func TestRowString(t *testing.T) {
 	sampleRow := Row{
@@ -1199,3 +1218,15 @@ func TestGetMinLow(t *testing.T) {
 		})
 	}
 }
+
+// TestClone tests indicators calculated on a clone do not change the original rows
+func TestClone(t *testing.T) {
+	data := Data{{Close: 1}, {Close: 3}}
+	clone := data.Clone()
+
+	err := clone.CalculateSMA(&utils.InstrumentConfiguration{LargeSMALookbackAmount: 2, SmallSMALookbackAmount: 1}, 0.25)
+	assert.NoError(t, err)
+	assert.Equal(t, 2.0, clone[1].LargeSMA)
+	assert.Zero(t, data[1].LargeSMA)
+	assert.Equal(t, data[1].Close, clone[1].Close)
+}
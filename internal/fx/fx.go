@@ -0,0 +1,139 @@
+package fx
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/gocarina/gocsv"
+)
+
+var (
+	// RateNotFound is an error for when there is no rate to convert a currency into the base currency.
+	RateNotFound = errors.New("fx rate not found")
+	// RateInvalid is an error for when a rate is less than or equal to 0.
+	RateInvalid = errors.New("fx rate must be greater than 0")
+)
+
+// RateRow is a struct that represents one row of an FX rate CSV file.
+type RateRow struct {
+	// Time is the time the rate applies from.
+	Time time.Time `csv:"Time"`
+
+	// Currency is the ISO currency code the rate converts from.
+	Currency string `csv:"Currency"`
+
+	// Rate is the amount of the base currency one unit of Currency is worth.
+	Rate float64 `csv:"Rate"`
+}
+
+// Converter converts amounts into a base currency using a time series of rates,
+// falling back to fixed rates when a currency has no time series.
+type Converter struct {
+	// baseCurrency is the ISO currency code everything is converted into.
+	baseCurrency string
+
+	// fixedRates is a mapping of currency to the amount of the base currency one unit is worth.
+	fixedRates map[string]float64
+
+	// series is a mapping of currency to its rates sorted by time.
+	series map[string][]*RateRow
+}
+
+// NewConverter creates a Converter for a base currency with a mapping of fixed rates,
+// each rate being the amount of the base currency one unit of the currency is worth.
+func NewConverter(baseCurrency string, fixedRates map[string]float64) (*Converter, error) {
+	for currency, rate := range fixedRates {
+		if rate <= 0 {
+			return nil, fmt.Errorf("%s: %w", currency, RateInvalid)
+		}
+	}
+
+	return &Converter{
+		baseCurrency: baseCurrency,
+		fixedRates:   fixedRates,
+		series:       make(map[string][]*RateRow),
+	}, nil
+}
+
+// BaseCurrency returns the ISO currency code everything is converted into.
+func (c *Converter) BaseCurrency() string {
+	return c.baseCurrency
+}
+
+// LoadRatesCSV reads a CSV file of RateRow from the file path and adds it to the time series of the Converter.
+func (c *Converter) LoadRatesCSV(filePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("error opening file: %w", err)
+	}
+	defer func(file *os.File) {
+		err := file.Close()
+		if err != nil {
+			// Handle the error, but don't return it
+			fmt.Printf("Error closing fx rate file: %v\n", err)
+		}
+	}(file)
+
+	var rows []*RateRow
+	if err := gocsv.UnmarshalFile(file, &rows); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		if row.Rate <= 0 {
+			return fmt.Errorf("%s at %v in %s: %w", row.Currency, row.Time, filePath, RateInvalid)
+		}
+	}
+	c.AddRates(rows)
+
+	return nil
+}
+
+// AddRates adds rows to the time series of the Converter, keeping each currency sorted by time.
+func (c *Converter) AddRates(rows []*RateRow) {
+	for _, row := range rows {
+		c.series[row.Currency] = append(c.series[row.Currency], row)
+	}
+
+	for _, currencyRows := range c.series {
+		sort.SliceStable(currencyRows, func(i, j int) bool {
+			return currencyRows[i].Time.Before(currencyRows[j].Time)
+		})
+	}
+}
+
+// Rate returns the amount of the base currency one unit of the currency is worth at the given time.
+// The time series is used first, taking the latest rate at or before the time, then the fixed rates.
+func (c *Converter) Rate(currency string, at time.Time) (float64, error) {
+	if currency == c.baseCurrency {
+		return 1, nil
+	}
+
+	// Find the first rate after the time, the one before it is the latest rate at or before the time
+	if currencyRows, ok := c.series[currency]; ok {
+		index := sort.Search(len(currencyRows), func(i int) bool {
+			return currencyRows[i].Time.After(at)
+		})
+		if index > 0 {
+			return currencyRows[index-1].Rate, nil
+		}
+	}
+
+	if rate, ok := c.fixedRates[currency]; ok {
+		return rate, nil
+	}
+
+	return 0, fmt.Errorf("%s to %s at %v: %w", currency, c.baseCurrency, at, RateNotFound)
+}
+
+// ToBase converts an amount of a currency into the base currency at the given time.
+func (c *Converter) ToBase(amount float64, currency string, at time.Time) (float64, error) {
+	rate, err := c.Rate(currency, at)
+	if err != nil {
+		return 0, err
+	}
+	return amount * rate, nil
+}
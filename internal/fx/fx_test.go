@@ -0,0 +1,84 @@
+package fx
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewConverter tests that invalid fixed rates are rejected
+func TestNewConverter(t *testing.T) {
+	_, err := NewConverter("USD", map[string]float64{"EUR": 0})
+	require.ErrorIs(t, err, RateInvalid)
+
+	converter, err := NewConverter("USD", map[string]float64{"EUR": 1.1})
+	require.NoError(t, err)
+	require.Equal(t, "USD", converter.BaseCurrency())
+}
+
+// TestConverter_Rate tests the time series, fixed rate fallback and missing rates
+func TestConverter_Rate(t *testing.T) {
+	day := time.Date(2023, 10, 20, 0, 0, 0, 0, time.UTC)
+	converter, err := NewConverter("USD", map[string]float64{"EUR": 1.1, "JPY": 0.0067})
+	require.NoError(t, err)
+	converter.AddRates([]*RateRow{
+		{Time: day.Add(24 * time.Hour), Currency: "EUR", Rate: 1.07},
+		{Time: day, Currency: "EUR", Rate: 1.05},
+	})
+
+	tests := []struct {
+		name     string
+		currency string
+		at       time.Time
+		want     float64
+		wantErr  error
+	}{
+		{"base currency", "USD", day, 1, nil},
+		{"before the series uses the fixed rate", "EUR", day.Add(-time.Hour), 1.1, nil},
+		{"equal to a rate", "EUR", day, 1.05, nil},
+		{"between rates uses the latest", "EUR", day.Add(12 * time.Hour), 1.05, nil},
+		{"after the series uses the last rate", "EUR", day.Add(48 * time.Hour), 1.07, nil},
+		{"fixed only", "JPY", day, 0.0067, nil},
+		{"missing", "GBP", day, 0, RateNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, err := converter.Rate(tt.currency, tt.at)
+			require.ErrorIs(t, err, tt.wantErr)
+			require.Equal(t, tt.want, rate)
+		})
+	}
+
+	converted, err := converter.ToBase(100, "JPY", day)
+	require.NoError(t, err)
+	require.InDelta(t, 0.67, converted, 0.0000001)
+	_, err = converter.ToBase(100, "GBP", day)
+	require.ErrorIs(t, err, RateNotFound)
+}
+
+// TestConverter_LoadRatesCSV tests loading a valid and invalid rate file
+func TestConverter_LoadRatesCSV(t *testing.T) {
+	directory := t.TempDir()
+	converter, err := NewConverter("USD", nil)
+	require.NoError(t, err)
+
+	validPath := filepath.Join(directory, "rates.csv")
+	require.NoError(t, os.WriteFile(validPath, []byte(
+		"Time,Currency,Rate\n2023-10-20T00:00:00Z,EUR,1.05\n2023-10-21T00:00:00Z,EUR,1.07\n",
+	), 0600))
+	require.NoError(t, converter.LoadRatesCSV(validPath))
+
+	rate, err := converter.Rate("EUR", time.Date(2023, 10, 21, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, 1.07, rate)
+
+	invalidPath := filepath.Join(directory, "invalid.csv")
+	require.NoError(t, os.WriteFile(invalidPath, []byte("Time,Currency,Rate\n2023-10-20T00:00:00Z,EUR,-1\n"), 0600))
+	require.ErrorIs(t, converter.LoadRatesCSV(invalidPath), RateInvalid)
+
+	require.Error(t, converter.LoadRatesCSV(filepath.Join(directory, "missing.csv")))
+}
@@ -0,0 +1,208 @@
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/utils"
+)
+
+// DataChanged is an error for when a data file no longer has the SHA-256 it had when the run was made.
+var DataChanged = errors.New("data file has changed since the run")
+
+// DataFile is a file a run read its data from.
+type DataFile struct {
+	// Path is the path of the file relative to the working directory of the run.
+	Path string `json:"Path"`
+
+	// SHA256 is the hex encoded SHA-256 of the contents of the file.
+	SHA256 string `json:"SHA256"`
+
+	// Bytes is the size of the file.
+	Bytes int64 `json:"Bytes"`
+}
+
+// Build is the version of the binary that made a run, from the build info embedded by the Go toolchain.
+type Build struct {
+	// Version is the module version, (devel) for a binary built from a checkout.
+	Version string `json:"Version"`
+
+	// GoVersion is the version of Go the binary was built with.
+	GoVersion string `json:"GoVersion"`
+
+	// Commit is the git commit the binary was built from, empty if it was built without version control.
+	Commit string `json:"Commit,omitempty"`
+
+	// CommitTime is the time of the commit.
+	CommitTime string `json:"CommitTime,omitempty"`
+
+	// Modified is true if the checkout had uncommitted changes when the binary was built.
+	Modified bool `json:"Modified"`
+}
+
+// Manifest is everything needed to know what produced the outputs of a run and to reproduce it.
+type Manifest struct {
+	// RunTime is the time the run started, which every output file of the run is named by.
+	RunTime time.Time `json:"RunTime"`
+
+	// DurationSeconds is how long the run took.
+	DurationSeconds float64 `json:"DurationSeconds"`
+
+	// CommandLine is the arguments the binary was run with, including its path.
+	CommandLine []string `json:"CommandLine"`
+
+	// WorkingDirectory is the directory the run was made in, which the data file paths are relative to.
+	WorkingDirectory string `json:"WorkingDirectory"`
+
+	// Build is the version of the binary that made the run.
+	Build Build `json:"Build"`
+
+	// Configuration is the effective configuration of the run, with every default applied.
+	Configuration *utils.Configuration `json:"Configuration"`
+
+	// ContractSpecs is the contract specification of every instrument after any contract specification file
+	// was merged in.
+	ContractSpecs utils.ContractSpecRegistry `json:"ContractSpecs"`
+
+	// DataFiles is every file the run read its data from.
+	DataFiles []DataFile `json:"DataFiles"`
+}
+
+// New returns the manifest of a run starting at runTime, hashing every data file it will read.
+// It returns an error if a data file cannot be read.
+func New(
+	cfg *utils.Configuration,
+	specs utils.ContractSpecRegistry,
+	dataFiles []string,
+	runTime time.Time,
+) (*Manifest, error) {
+	workingDirectory, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{
+		RunTime:          runTime,
+		CommandLine:      os.Args,
+		WorkingDirectory: workingDirectory,
+		Build:            ReadBuild(),
+		Configuration:    cfg,
+		ContractSpecs:    specs,
+	}
+	for _, filePath := range dataFiles {
+		dataFile, err := HashFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		m.DataFiles = append(m.DataFiles, dataFile)
+	}
+
+	return m, nil
+}
+
+// ReadBuild returns the version of the running binary. Every field is empty if it was built without build info.
+func ReadBuild() Build {
+	var build Build
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return build
+	}
+
+	build.Version, build.GoVersion = info.Main.Version, info.GoVersion
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			build.Commit = setting.Value
+		case "vcs.time":
+			build.CommitTime = setting.Value
+		case "vcs.modified":
+			build.Modified = setting.Value == "true"
+		}
+	}
+
+	return build
+}
+
+// HashFile returns the SHA-256 and size of a file.
+func HashFile(filePath string) (DataFile, error) {
+	dataFile := DataFile{Path: filePath}
+	file, err := os.Open(filePath)
+	if err != nil {
+		return dataFile, fmt.Errorf("error hashing data file: %w", err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if dataFile.Bytes, err = io.Copy(hash, file); err != nil {
+		return dataFile, fmt.Errorf("error hashing data file %s: %w", filePath, err)
+	}
+	dataFile.SHA256 = hex.EncodeToString(hash.Sum(nil))
+
+	return dataFile, nil
+}
+
+// Finish records the time the run finished.
+func (m *Manifest) Finish(end time.Time) {
+	m.DurationSeconds = end.Sub(m.RunTime).Seconds()
+}
+
+// Resolve returns the path of a file of the run, joined to the working directory of the run if it is relative, so the
+// same file is read wherever the run is reproduced from.
+func (m *Manifest) Resolve(filePath string) string {
+	if filePath == "" || filepath.IsAbs(filePath) || m.WorkingDirectory == "" {
+		return filePath
+	}
+
+	return filepath.Join(m.WorkingDirectory, filePath)
+}
+
+// Verify hashes every data file again, relative to the working directory of the run, and returns a DataChanged error
+// for each file that is missing or has different contents, so a rerun can warn it will not reproduce the run exactly.
+func (m *Manifest) Verify() error {
+	var errs []error
+	for _, dataFile := range m.DataFiles {
+		current, err := HashFile(m.Resolve(dataFile.Path))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w: %w", dataFile.Path, DataChanged, err))
+			continue
+		}
+		if current.SHA256 != dataFile.SHA256 {
+			errs = append(errs, fmt.Errorf("%s has SHA-256 %s not %s: %w", dataFile.Path, current.SHA256, dataFile.SHA256, DataChanged))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// WriteJSON writes the manifest to a JSON file on disk, creating or overwriting it.
+func (m *Manifest) WriteJSON(filePath string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filePath, data, 0644)
+}
+
+// Read reads a manifest written by WriteJSON.
+func Read(filePath string) (*Manifest, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading manifest: %w", err)
+	}
+
+	m := new(Manifest)
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("error reading manifest %s: %w", filePath, err)
+	}
+
+	return m, nil
+}
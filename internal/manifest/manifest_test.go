@@ -0,0 +1,90 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// mockManifest returns the manifest of a run that read one data file containing "abc".
+func mockManifest(t *testing.T) (*Manifest, string) {
+	dataPath := filepath.Join(t.TempDir(), "ES.csv")
+	require.NoError(t, os.WriteFile(dataPath, []byte("abc"), 0644))
+
+	cfg := &utils.Configuration{
+		StartingBalance: 10000,
+		Instruments:     map[string]*utils.InstrumentConfiguration{"ES": {MinimumRR: 2}},
+	}
+	specs := utils.ContractSpecRegistry{"ES": {TickSize: 0.25, TickValue: 12.5}}
+	runTime := time.Date(2023, 10, 16, 9, 0, 0, 0, time.UTC)
+
+	m, err := New(cfg, specs, []string{dataPath}, runTime)
+	require.NoError(t, err)
+
+	return m, dataPath
+}
+
+// TestNew tests every data file is hashed and the duration is recorded
+func TestNew(t *testing.T) {
+	m, dataPath := mockManifest(t)
+
+	require.Equal(t, []DataFile{{
+		Path:   dataPath,
+		SHA256: "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+		Bytes:  3,
+	}}, m.DataFiles)
+	require.NotEmpty(t, m.CommandLine)
+	require.NotEmpty(t, m.Build.GoVersion)
+
+	m.Finish(m.RunTime.Add(90 * time.Second))
+	require.Equal(t, 90.0, m.DurationSeconds)
+
+	_, err := New(m.Configuration, m.ContractSpecs, []string{filepath.Join(t.TempDir(), "missing.csv")}, m.RunTime)
+	require.Error(t, err)
+}
+
+// TestVerify tests a changed or missing data file is reported
+func TestVerify(t *testing.T) {
+	m, dataPath := mockManifest(t)
+	require.NoError(t, m.Verify())
+
+	require.NoError(t, os.WriteFile(dataPath, []byte("abd"), 0644))
+	require.ErrorIs(t, m.Verify(), DataChanged)
+
+	require.NoError(t, os.Remove(dataPath))
+	require.ErrorIs(t, m.Verify(), DataChanged)
+}
+
+// TestVerifyRelative tests a relative data file is hashed in the working directory of the run
+func TestVerifyRelative(t *testing.T) {
+	m, dataPath := mockManifest(t)
+	m.WorkingDirectory = filepath.Dir(dataPath)
+	m.DataFiles[0].Path = filepath.Base(dataPath)
+	require.Equal(t, dataPath, m.Resolve(m.DataFiles[0].Path))
+	require.Equal(t, dataPath, m.Resolve(dataPath))
+	require.NoError(t, m.Verify())
+
+	m.WorkingDirectory = t.TempDir()
+	require.ErrorIs(t, m.Verify(), DataChanged)
+}
+
+// TestWriteJSON tests a manifest can be written to disk and read back
+func TestWriteJSON(t *testing.T) {
+	m, _ := mockManifest(t)
+	filePath := filepath.Join(t.TempDir(), "manifest.json")
+	require.NoError(t, m.WriteJSON(filePath))
+
+	read, err := Read(filePath)
+	require.NoError(t, err)
+	require.Equal(t, m.DataFiles, read.DataFiles)
+	require.Equal(t, m.ContractSpecs, read.ContractSpecs)
+	require.Equal(t, m.Configuration.Instruments, read.Configuration.Instruments)
+	require.True(t, m.RunTime.Equal(read.RunTime))
+
+	_, err = Read(filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(t, err)
+}
@@ -0,0 +1,234 @@
+package monteCarlo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/stats"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/tradeLog"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/utils"
+	"github.com/gocarina/gocsv"
+)
+
+// ConfigurationInvalid is an error for when the Monte Carlo configuration cannot be used to resample trades.
+var ConfigurationInvalid = errors.New("monte carlo configuration is invalid")
+
+// Validate returns a ConfigurationInvalid error if the configuration cannot be used to resample trades.
+func Validate(config *utils.MonteCarloConfiguration) error {
+	switch config.Mode {
+	case utils.ResamplingMode.SHUFFLE, utils.ResamplingMode.BOOTSTRAP, utils.ResamplingMode.BLOCK_BOOTSTRAP:
+	default:
+		return fmt.Errorf("got Mode %q: %w", config.Mode, ConfigurationInvalid)
+	}
+
+	if config.Simulations <= 0 {
+		return fmt.Errorf("Simulations must be greater than 0: %w", ConfigurationInvalid)
+	}
+	if config.Mode == utils.ResamplingMode.BLOCK_BOOTSTRAP && config.BlockSize <= 0 {
+		return fmt.Errorf("BlockSize must be greater than 0 for %s: %w", config.Mode, ConfigurationInvalid)
+	}
+	if config.RuinDrawdownPercent <= 0 || config.RuinDrawdownPercent > 100 {
+		return fmt.Errorf(
+			"RuinDrawdownPercent must be greater than 0 and at most 100, got %v: %w",
+			config.RuinDrawdownPercent,
+			ConfigurationInvalid,
+		)
+	}
+	for _, percentile := range config.Percentiles {
+		if percentile < 0 || percentile > 100 {
+			return fmt.Errorf("Percentiles must be between 0 and 100, got %v: %w", percentile, ConfigurationInvalid)
+		}
+	}
+
+	return nil
+}
+
+// Path is the outcome of trading one resampled sequence of trades.
+type Path struct {
+	// FinalEquity is the equity after the last trade, or when trading was stopped if the path was ruined.
+	FinalEquity float64
+
+	// MaxDrawdownPercent is the largest fall in equity from a peak as a percentage of the peak.
+	MaxDrawdownPercent float64
+
+	// LongestLosingStreak is the most trades in a row that did not win.
+	LongestLosingStreak int
+
+	// Ruined is true if the drawdown reached the ruin threshold and trading was stopped.
+	Ruined bool
+}
+
+// PercentileRow is a struct representing one row of the Monte Carlo output CSV,
+// the value of every distribution at one percentile.
+type PercentileRow struct {
+	// Percentile is the percentage of paths with a value at or below this row.
+	Percentile float64 `csv:"Percentile"`
+
+	// FinalEquity is the final equity at the percentile.
+	FinalEquity float64 `csv:"FinalEquity"`
+
+	// MaxDrawdownPercent is the max drawdown percentage at the percentile.
+	MaxDrawdownPercent float64 `csv:"MaxDrawdownPercent"`
+
+	// LongestLosingStreak is the longest losing streak at the percentile.
+	LongestLosingStreak float64 `csv:"LongestLosingStreak"`
+}
+
+// Result is the distribution of the outcomes of every resampled sequence of trades.
+type Result struct {
+	// Paths is the outcome of every resampled sequence, in the order they were simulated.
+	Paths []*Path
+
+	// Percentiles is the value of every distribution at each configured percentile.
+	Percentiles []*PercentileRow
+
+	// RiskOfRuin is the percentage of paths that reached the ruin threshold.
+	RiskOfRuin float64
+}
+
+// Run resamples the trades of a log into alternative sequences and trades each of them from the starting balance.
+// Each trade keeps its return on the equity it was entered with, so position sizing compounds the same way it did
+// in the backtest. It returns an error if the configuration is invalid.
+func Run(l *tradeLog.Log, startingBalance float64, config *utils.MonteCarloConfiguration) (*Result, error) {
+	if err := Validate(config); err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	if len(*l) == 0 {
+		return result, nil
+	}
+
+	returns, wins := tradeReturns(l, startingBalance)
+	random := rand.New(rand.NewSource(config.Seed))
+	ruined := 0
+	for simulation := 0; simulation < config.Simulations; simulation++ {
+		path := trade(resample(random, len(returns), config), returns, wins, startingBalance, config.RuinDrawdownPercent)
+		if path.Ruined {
+			ruined++
+		}
+		result.Paths = append(result.Paths, path)
+	}
+	result.RiskOfRuin = float64(ruined) / float64(config.Simulations) * 100
+
+	// Sort each outcome separately to read off its percentiles
+	finalEquity := make([]float64, 0, len(result.Paths))
+	maxDrawdown := make([]float64, 0, len(result.Paths))
+	losingStreak := make([]float64, 0, len(result.Paths))
+	for _, path := range result.Paths {
+		finalEquity = append(finalEquity, path.FinalEquity)
+		maxDrawdown = append(maxDrawdown, path.MaxDrawdownPercent)
+		losingStreak = append(losingStreak, float64(path.LongestLosingStreak))
+	}
+	for _, values := range [][]float64{finalEquity, maxDrawdown, losingStreak} {
+		sort.Float64s(values)
+	}
+	for _, percentile := range config.Percentiles {
+		result.Percentiles = append(result.Percentiles, &PercentileRow{
+			Percentile:          percentile,
+			FinalEquity:         stats.Percentile(finalEquity, percentile),
+			MaxDrawdownPercent:  stats.Percentile(maxDrawdown, percentile),
+			LongestLosingStreak: stats.Percentile(losingStreak, percentile),
+		})
+	}
+
+	return result, nil
+}
+
+// tradeReturns returns the profit of every trade, in the order they closed, as a fraction of the equity before it,
+// and whether each trade won.
+func tradeReturns(l *tradeLog.Log, startingBalance float64) ([]float64, []bool) {
+	rows := make([]*tradeLog.Row, len(*l))
+	copy(rows, *l)
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].ClosedAtTime.Before(rows[j].ClosedAtTime)
+	})
+
+	returns, wins := make([]float64, 0, len(rows)), make([]bool, 0, len(rows))
+	equity := startingBalance
+	for _, row := range rows {
+		tradeReturn := 0.0
+		if equity > 0 {
+			tradeReturn = row.ProfitValue / equity
+		}
+		returns, wins = append(returns, tradeReturn), append(wins, row.Win)
+		equity += row.ProfitValue
+	}
+
+	return returns, wins
+}
+
+// resample returns the indexes of the trades in one resampled sequence of the same length as the original.
+func resample(random *rand.Rand, trades int, config *utils.MonteCarloConfiguration) []int {
+	switch config.Mode {
+	case utils.ResamplingMode.BOOTSTRAP:
+		// Draw every trade independently, so a trade can be drawn more than once
+		indexes := make([]int, trades)
+		for index := range indexes {
+			indexes[index] = random.Intn(trades)
+		}
+		return indexes
+
+	case utils.ResamplingMode.BLOCK_BOOTSTRAP:
+		// Draw runs of consecutive trades, wrapping around the end, so streaks in the original are kept
+		indexes := make([]int, 0, trades)
+		for len(indexes) < trades {
+			start := random.Intn(trades)
+			for offset := 0; offset < config.BlockSize && len(indexes) < trades; offset++ {
+				indexes = append(indexes, (start+offset)%trades)
+			}
+		}
+		return indexes
+
+	default:
+		// Reorder the original trades, every trade is used exactly once
+		return random.Perm(trades)
+	}
+}
+
+// trade applies a sequence of trades to the starting balance, stopping if the drawdown reaches ruinDrawdownPercent.
+func trade(indexes []int, returns []float64, wins []bool, startingBalance, ruinDrawdownPercent float64) *Path {
+	var (
+		path         = &Path{}
+		equity       = startingBalance
+		peak         = startingBalance
+		losingStreak int
+	)
+	for _, index := range indexes {
+		equity *= 1 + returns[index]
+		peak = math.Max(peak, equity)
+		if peak > 0 {
+			path.MaxDrawdownPercent = math.Max(path.MaxDrawdownPercent, (peak-equity)/peak*100)
+		}
+
+		if wins[index] {
+			losingStreak = 0
+		} else {
+			losingStreak++
+			path.LongestLosingStreak = max(path.LongestLosingStreak, losingStreak)
+		}
+
+		if path.MaxDrawdownPercent >= ruinDrawdownPercent {
+			path.Ruined = true
+			break
+		}
+	}
+	path.FinalEquity = equity
+
+	return path
+}
+
+// WriteCSV takes a file path and writes the percentiles of the result to a CSV on disk, creating or overwriting it.
+func (r *Result) WriteCSV(filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return gocsv.MarshalFile(&r.Percentiles, file)
+}
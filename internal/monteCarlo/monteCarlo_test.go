@@ -0,0 +1,135 @@
+package monteCarlo
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/tradeLog"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// mockLog returns a log that wins 10% twice then loses 10% three times in a row, from a balance of 1000.
+func mockLog() *tradeLog.Log {
+	closedAt := time.Date(2023, 10, 16, 10, 0, 0, 0, time.UTC)
+	l := tradeLog.NewLog()
+	equity := 1000.0
+	for index, tradeReturn := range []float64{0.1, 0.1, -0.1, -0.1, -0.1} {
+		profit := equity * tradeReturn
+		*l = append(*l, &tradeLog.Row{
+			ClosedAtTime: closedAt.Add(time.Duration(index) * time.Hour),
+			ProfitValue:  profit,
+			Win:          profit > 0,
+		})
+		equity += profit
+	}
+
+	return l
+}
+
+// mockConfiguration returns a configuration with the defaults of LoadConfiguration.
+func mockConfiguration(mode string) *utils.MonteCarloConfiguration {
+	return &utils.MonteCarloConfiguration{
+		Mode:                mode,
+		Simulations:         200,
+		BlockSize:           2,
+		Seed:                1,
+		RuinDrawdownPercent: 50,
+		Percentiles:         []float64{5, 50, 95},
+	}
+}
+
+// TestValidate tests an invalid configuration is rejected
+func TestValidate(t *testing.T) {
+	require.NoError(t, Validate(mockConfiguration(utils.ResamplingMode.BLOCK_BOOTSTRAP)))
+
+	config := mockConfiguration("RANDOM")
+	require.ErrorIs(t, Validate(config), ConfigurationInvalid)
+
+	config = mockConfiguration(utils.ResamplingMode.SHUFFLE)
+	config.Simulations = 0
+	require.ErrorIs(t, Validate(config), ConfigurationInvalid)
+
+	config = mockConfiguration(utils.ResamplingMode.SHUFFLE)
+	config.Percentiles = []float64{101}
+	require.ErrorIs(t, Validate(config), ConfigurationInvalid)
+
+	for _, ruin := range []float64{-10, 0, 150} {
+		config = mockConfiguration(utils.ResamplingMode.SHUFFLE)
+		config.RuinDrawdownPercent = ruin
+		require.ErrorIs(t, Validate(config), ConfigurationInvalid)
+	}
+
+	_, err := Run(mockLog(), 1000, mockConfiguration("RANDOM"))
+	require.ErrorIs(t, err, ConfigurationInvalid)
+}
+
+// TestRunShuffle tests reordering the trades keeps the final equity but changes the drawdown and streaks
+func TestRunShuffle(t *testing.T) {
+	result, err := Run(mockLog(), 1000, mockConfiguration(utils.ResamplingMode.SHUFFLE))
+	require.NoError(t, err)
+
+	require.Len(t, result.Paths, 200)
+	for _, path := range result.Paths {
+		require.InDelta(t, 1000*1.1*1.1*0.9*0.9*0.9, path.FinalEquity, 1e-9)
+	}
+	require.Len(t, result.Percentiles, 3)
+	require.Equal(t, 3.0, result.Percentiles[2].LongestLosingStreak)
+	require.Less(t, result.Percentiles[0].LongestLosingStreak, 3.0)
+	require.InDelta(t, 27.1, result.Percentiles[2].MaxDrawdownPercent, 1e-9)
+	require.Zero(t, result.RiskOfRuin)
+
+	// The same seed resamples the same sequences
+	again, err := Run(mockLog(), 1000, mockConfiguration(utils.ResamplingMode.SHUFFLE))
+	require.NoError(t, err)
+	require.Equal(t, result.Percentiles, again.Percentiles)
+}
+
+// TestRunBootstrap tests drawing trades with replacement changes the final equity and can ruin the account
+func TestRunBootstrap(t *testing.T) {
+	config := mockConfiguration(utils.ResamplingMode.BOOTSTRAP)
+	config.RuinDrawdownPercent = 25
+
+	result, err := Run(mockLog(), 1000, config)
+	require.NoError(t, err)
+
+	require.Less(t, result.Percentiles[0].FinalEquity, result.Percentiles[2].FinalEquity)
+	require.Greater(t, result.RiskOfRuin, 0.0)
+	require.Less(t, result.RiskOfRuin, 100.0)
+	for _, path := range result.Paths {
+		if path.Ruined {
+			require.GreaterOrEqual(t, path.MaxDrawdownPercent, 25.0)
+		}
+	}
+
+	// An empty log has nothing to resample
+	empty, err := Run(tradeLog.NewLog(), 1000, config)
+	require.NoError(t, err)
+	require.Empty(t, empty.Paths)
+}
+
+// TestResampleBlockBootstrap tests trades are drawn in runs of consecutive trades that wrap around the end
+func TestResampleBlockBootstrap(t *testing.T) {
+	indexes := resample(rand.New(rand.NewSource(1)), 5, mockConfiguration(utils.ResamplingMode.BLOCK_BOOTSTRAP))
+
+	require.Len(t, indexes, 5)
+	for block := 0; block+1 < len(indexes); block += 2 {
+		require.Equal(t, (indexes[block]+1)%5, indexes[block+1])
+	}
+}
+
+// TestWriteCSV tests the percentiles can be written to disk
+func TestWriteCSV(t *testing.T) {
+	result, err := Run(mockLog(), 1000, mockConfiguration(utils.ResamplingMode.SHUFFLE))
+	require.NoError(t, err)
+
+	filePath := filepath.Join(t.TempDir(), "montecarlo.csv")
+	require.NoError(t, result.WriteCSV(filePath))
+
+	contents, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	require.Contains(t, string(contents), "Percentile,FinalEquity,MaxDrawdownPercent,LongestLosingStreak")
+}
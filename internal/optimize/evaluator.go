@@ -0,0 +1,288 @@
+package optimize
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/backtestData"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/fx"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/portfolio"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/stats"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/tradeLog"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/utils"
+)
+
+// Evaluation is the result of backtesting one combination of parameters.
+type Evaluation struct {
+	// Parameters is the combination of parameters backtested.
+	Parameters Parameters `json:"Parameters"`
+
+	// Summary is the performance statistics of the trades.
+	Summary *stats.Summary `json:"Summary"`
+
+	// Trades is the log of every trade taken, which is not journaled so is nil for a resumed evaluation.
+	Trades *tradeLog.Log `json:"-"`
+
+	// Score is the value of the objective, higher is better.
+	Score float64 `json:"Score"`
+
+	// Robustness is how the neighbours of the combination performed, nil until ScoreRobustness is called.
+	Robustness *Robustness `json:"-"`
+}
+
+// smaKey is every input of the simple moving averages of an instrument.
+type smaKey struct {
+	instrument string
+	large      int
+	small      int
+}
+
+// smaColumns is the large and small simple moving average of every row of an instrument.
+type smaColumns struct {
+	large []float64
+	small []float64
+}
+
+// boundaryKey is every input of the stochastic oscillator and the unbroken highs and lows of an instrument,
+// which are calculated together as the boundaries depend on the oscillator.
+type boundaryKey struct {
+	instrument string
+	kPeriods   int
+	dPeriods   int
+	leftBars   int
+	rightBars  int
+	memory     int
+	upperBand  float64
+	lowerBand  float64
+}
+
+// cacheEntry is one value of a cache, calculated at most once.
+type cacheEntry struct {
+	once  sync.Once
+	value any
+	err   error
+}
+
+// cache is a concurrency safe store of calculated values, so combinations evaluated in parallel share every
+// indicator calculation with the same inputs.
+type cache struct {
+	mutex   sync.Mutex
+	entries map[any]*cacheEntry
+}
+
+// get returns the value of the key, calling calculate to find it if this is the first time the key is requested.
+// Concurrent requests for the same key wait for the one calculation.
+func (c *cache) get(key any, calculate func() (any, error)) (any, error) {
+	c.mutex.Lock()
+	if c.entries == nil {
+		c.entries = make(map[any]*cacheEntry)
+	}
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = new(cacheEntry)
+		c.entries[key] = entry
+	}
+	c.mutex.Unlock()
+
+	entry.once.Do(func() {
+		entry.value, entry.err = calculate()
+	})
+
+	return entry.value, entry.err
+}
+
+// Evaluator backtests combinations of parameters on the same data, reusing the indicators shared between them.
+// It is safe to use from multiple goroutines.
+type Evaluator struct {
+	// configuration is the users configuration the parameters are applied to.
+	configuration *utils.Configuration
+
+	// specs is the contract specification of every instrument.
+	specs utils.ContractSpecRegistry
+
+	// converter converts every currency value into the base currency.
+	converter *fx.Converter
+
+	// data is the candles of every instrument as loaded, before any indicator is calculated.
+	data map[string]backtestData.Data
+
+	// smas is the simple moving averages of each smaKey.
+	smas cache
+
+	// boundaries is the candles with the stochastic oscillator and boundaries calculated for each boundaryKey.
+	boundaries cache
+}
+
+// NewEvaluator creates an Evaluator of the configuration on the loaded candles of every instrument.
+func NewEvaluator(
+	configuration *utils.Configuration,
+	specs utils.ContractSpecRegistry,
+	converter *fx.Converter,
+	data map[string]backtestData.Data,
+) *Evaluator {
+	return &Evaluator{configuration: configuration, specs: specs, converter: converter, data: data}
+}
+
+// configure returns a copy of the configuration with the parameters applied to every optimised instrument.
+func (e *Evaluator) configure(parameters Parameters) (*utils.Configuration, error) {
+	// Copy through JSON so no combination changes the configuration of another
+	encoded, err := json.Marshal(e.configuration)
+	if err != nil {
+		return nil, err
+	}
+	cfg := new(utils.Configuration)
+	if err := json.Unmarshal(encoded, cfg); err != nil {
+		return nil, err
+	}
+
+	for instrument, instrumentConfig := range cfg.Instruments {
+		if cfg.Optimization != nil && len(cfg.Optimization.Instruments) > 0 &&
+			!slices.Contains(cfg.Optimization.Instruments, instrument) {
+			continue
+		}
+		if err := parameters.Apply(instrumentConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	return cfg, nil
+}
+
+// prepare returns a copy of the candles of an instrument with every indicator of its configuration calculated,
+// from the cache if another combination has already calculated them.
+func (e *Evaluator) prepare(instrument string, cfg *utils.InstrumentConfiguration, tickSize float64) (backtestData.Data, error) {
+	raw := e.data[instrument]
+
+	smas, err := e.smas.get(
+		smaKey{instrument, cfg.LargeSMALookbackAmount, cfg.SmallSMALookbackAmount},
+		func() (any, error) {
+			data := raw.Clone()
+			if err := data.CalculateSMA(cfg, tickSize); err != nil {
+				return nil, err
+			}
+
+			columns := smaColumns{large: make([]float64, len(data)), small: make([]float64, len(data))}
+			for index, row := range data {
+				columns.large[index], columns.small[index] = row.LargeSMA, row.SmallSMA
+			}
+			return columns, nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	boundaries, err := e.boundaries.get(
+		boundaryKey{
+			instrument,
+			cfg.StochasticKPeriods,
+			cfg.StochasticDPeriods,
+			cfg.UnbrokenBoundaryLeftBars,
+			cfg.UnbrokenBoundaryRightBars,
+			cfg.UnbrokenBoundaryMemoryLimit,
+			cfg.StochasticUpperBand,
+			cfg.StochasticLowerBand,
+		},
+		func() (any, error) {
+			data := raw.Clone()
+			data.CalculateStochasticOscillator(cfg.StochasticKPeriods, cfg.StochasticDPeriods)
+			data.CalculateUnbrokenHighsLows(
+				cfg.UnbrokenBoundaryLeftBars,
+				cfg.UnbrokenBoundaryRightBars,
+				cfg.UnbrokenBoundaryMemoryLimit,
+				cfg.StochasticUpperBand,
+				cfg.StochasticLowerBand,
+			)
+			return data, nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Combine both calculations on a copy, as the cached candles are shared with other combinations
+	cached := boundaries.(backtestData.Data)
+	data := cached.Clone()
+	columns := smas.(smaColumns)
+	for index, row := range data {
+		row.LargeSMA, row.SmallSMA = columns.large[index], columns.small[index]
+	}
+
+	return data, nil
+}
+
+// Evaluate backtests the parameters on the candles from the start time to the end time, and scores the trades
+// by the objective of the configuration. Instruments without a contract specification, or without candles in the
+// window, are not traded.
+func (e *Evaluator) Evaluate(parameters Parameters, start, end time.Time) (*Evaluation, error) {
+	return e.EvaluateWithBalance(parameters, start, end, e.configuration.StartingBalance)
+}
+
+// EvaluateWithBalance evaluates the parameters as Evaluate does, trading an account that starts with the given balance
+// instead of the StartingBalance of the configuration.
+func (e *Evaluator) EvaluateWithBalance(
+	parameters Parameters,
+	start, end time.Time,
+	startingBalance float64,
+) (*Evaluation, error) {
+	cfg, err := e.configure(parameters)
+	if err != nil {
+		return nil, err
+	}
+	cfg.StartingBalance = startingBalance
+
+	var sessions []*portfolio.Session
+	for instrument, instrumentConfig := range cfg.Instruments {
+		spec, ok := e.specs[instrument]
+		if !ok || e.data[instrument] == nil {
+			continue
+		}
+
+		prepared, err := e.prepare(instrument, instrumentConfig, spec.TickSize)
+		if err != nil {
+			return nil, err
+		}
+		window, err := prepared.FilterByTimes(start, end)
+		if errors.Is(err, backtestData.FilterEmptyError) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, portfolio.BuildSessions(instrument, window)...)
+	}
+
+	engine, err := portfolio.NewEngine(cfg, e.specs, e.converter)
+	if err != nil {
+		return nil, err
+	}
+	result, err := engine.Run(sessions)
+	if err != nil {
+		return nil, err
+	}
+
+	trades := tradeLog.NewLog()
+	for _, trade := range result.Trades {
+		trades = tradeLog.AddRow(trades, trade)
+	}
+	summary := stats.Calculate(trades, cfg.StartingBalance)
+
+	return &Evaluation{
+		Parameters: parameters,
+		Summary:    summary,
+		Trades:     trades,
+		Score:      Score(summary, e.objective()),
+	}, nil
+}
+
+// objective returns the objective of the configuration, NET_R if it has no Optimization section.
+func (e *Evaluator) objective() string {
+	if e.configuration.Optimization == nil {
+		return utils.Objective.NET_R
+	}
+
+	return e.configuration.Optimization.Objective
+}
@@ -0,0 +1,145 @@
+package optimize
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/utils"
+)
+
+// HeatmapCell is the evaluations with one value, or bin, of each of the two parameters of a heatmap.
+type HeatmapCell struct {
+	// Evaluations is the amount of evaluations in the cell.
+	Evaluations int
+
+	// Average is the average score of the evaluations, across every value of the other parameters.
+	Average float64
+
+	// Best is the highest score of the evaluations.
+	Best float64
+}
+
+// Heatmap is the score of every pair of values of two parameters, to see how sensitive the score is to them.
+type Heatmap struct {
+	// X is the name of the parameter of the columns.
+	X string
+
+	// Y is the name of the parameter of the rows.
+	Y string
+
+	// XValues is the value of each column, the centre of each bin for a continuous range.
+	XValues []float64
+
+	// YValues is the value of each row, the centre of each bin for a continuous range.
+	YValues []float64
+
+	// Cells is the cell of each row then column, nil where nothing was evaluated.
+	Cells [][]*HeatmapCell
+}
+
+// HeatmapPairs returns the pairs of parameters to draw heatmaps of, the configured pairs or every pair in name order
+// if none are configured.
+func HeatmapPairs(optimization *utils.OptimizationConfiguration) [][2]string {
+	var pairs [][2]string
+	for _, pair := range optimization.Heatmaps {
+		pairs = append(pairs, [2]string{pair[0], pair[1]})
+	}
+	if len(pairs) > 0 {
+		return pairs
+	}
+
+	names := make([]string, 0, len(optimization.Parameters))
+	for name := range optimization.Parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for first := range names {
+		for second := first + 1; second < len(names); second++ {
+			pairs = append(pairs, [2]string{names[first], names[second]})
+		}
+	}
+
+	return pairs
+}
+
+// NewHeatmap returns the heatmap of two parameters from every evaluation. Each cell averages the evaluations with its
+// values of both parameters whatever the value of the others, so a cell that stays high is a plateau rather than one
+// lucky combination. A continuous range is split into bins.
+func NewHeatmap(evaluations []*Evaluation, ranges map[string]*utils.ParameterRange, x, y string) *Heatmap {
+	xAxis, yAxis := newAxis(x, ranges[x]), newAxis(y, ranges[y])
+	heatmap := &Heatmap{
+		X:       x,
+		Y:       y,
+		XValues: xAxis.values,
+		YValues: yAxis.values,
+		Cells:   make([][]*HeatmapCell, len(yAxis.values)),
+	}
+	for row := range heatmap.Cells {
+		heatmap.Cells[row] = make([]*HeatmapCell, len(xAxis.values))
+	}
+
+	for _, evaluation := range evaluations {
+		column, row := xAxis.index(evaluation.Parameters[x]), yAxis.index(evaluation.Parameters[y])
+		if column < 0 || row < 0 {
+			continue
+		}
+
+		cell := heatmap.Cells[row][column]
+		if cell == nil {
+			cell = &HeatmapCell{Best: math.Inf(-1)}
+			heatmap.Cells[row][column] = cell
+		}
+		cell.Average = (cell.Average*float64(cell.Evaluations) + evaluation.Score) / float64(cell.Evaluations+1)
+		cell.Evaluations++
+		cell.Best = max(cell.Best, evaluation.Score)
+	}
+
+	return heatmap
+}
+
+// FileName returns the name of the heatmap without an extension, like "MinimumRR-StopSizeAddition".
+func (h *Heatmap) FileName() string {
+	return fmt.Sprintf("%s-%s", h.X, h.Y)
+}
+
+// WriteCSV writes every cell of the heatmap to a CSV on disk, creating or overwriting it, with a row for each cell
+// that has evaluations.
+func (h *Heatmap) WriteCSV(filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write([]string{h.X, h.Y, "Evaluations", "Average", "Best"}); err != nil {
+		return err
+	}
+
+	format := func(value float64) string {
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	for row, cells := range h.Cells {
+		for column, cell := range cells {
+			if cell == nil {
+				continue
+			}
+			if err := writer.Write([]string{
+				format(h.XValues[column]),
+				format(h.YValues[row]),
+				strconv.Itoa(cell.Evaluations),
+				format(cell.Average),
+				format(cell.Best),
+			}); err != nil {
+				return fmt.Errorf("error writing heatmap: %w", err)
+			}
+		}
+	}
+	writer.Flush()
+
+	return writer.Error()
+}
@@ -0,0 +1,79 @@
+package optimize
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/stats"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHeatmapPairs tests the configured pairs are used, or every pair in name order without any
+func TestHeatmapPairs(t *testing.T) {
+	optimization := &utils.OptimizationConfiguration{Parameters: map[string]*utils.ParameterRange{
+		"StopSizeAddition":       {Values: []float64{2}},
+		"MinimumRR":              {Values: []float64{2}},
+		"LargeSMALookbackAmount": {Values: []float64{50}},
+	}}
+	require.Equal(t, [][2]string{
+		{"LargeSMALookbackAmount", "MinimumRR"},
+		{"LargeSMALookbackAmount", "StopSizeAddition"},
+		{"MinimumRR", "StopSizeAddition"},
+	}, HeatmapPairs(optimization))
+
+	optimization.Heatmaps = [][]string{{"StopSizeAddition", "MinimumRR"}}
+	require.Equal(t, [][2]string{{"StopSizeAddition", "MinimumRR"}}, HeatmapPairs(optimization))
+}
+
+// TestNewHeatmap tests each cell averages the evaluations with its values whatever the value of the other parameters
+func TestNewHeatmap(t *testing.T) {
+	evaluations, ranges := mockGrid()
+	for _, evaluation := range evaluations {
+		evaluation.Parameters["LargeSMALookbackAmount"] = 50
+	}
+	evaluations = append(evaluations, &Evaluation{
+		Parameters: Parameters{"MinimumRR": 1, "StopSizeAddition": 2, "LargeSMALookbackAmount": 100},
+		Summary:    &stats.Summary{},
+		Score:      3,
+	})
+	ranges["LargeSMALookbackAmount"] = &utils.ParameterRange{Values: []float64{50, 100}}
+
+	heatmap := NewHeatmap(evaluations, ranges, "StopSizeAddition", "MinimumRR")
+	require.Equal(t, []float64{2, 3, 4}, heatmap.XValues)
+	require.Equal(t, []float64{1, 2, 3}, heatmap.YValues)
+	require.Equal(t, &HeatmapCell{Evaluations: 2, Average: 6, Best: 9}, heatmap.Cells[0][0])
+	require.Equal(t, &HeatmapCell{Evaluations: 1, Average: 5, Best: 5}, heatmap.Cells[2][2])
+	require.Equal(t, "StopSizeAddition-MinimumRR", heatmap.FileName())
+
+	filePath := filepath.Join(t.TempDir(), heatmap.FileName()+".csv")
+	require.NoError(t, heatmap.WriteCSV(filePath))
+	contents, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(contents)), "\n")
+	require.Len(t, lines, 10)
+	require.Equal(t, "StopSizeAddition,MinimumRR,Evaluations,Average,Best", lines[0])
+	require.Equal(t, "2,1,2,6,9", lines[1])
+}
+
+// TestNewHeatmapContinuous tests a continuous range is split into bins and empty bins have no cell
+func TestNewHeatmapContinuous(t *testing.T) {
+	evaluations := []*Evaluation{
+		{Parameters: Parameters{"MinimumRR": 1.05, "SmallSMALookbackAmount": 5}, Summary: &stats.Summary{}, Score: 2},
+		{Parameters: Parameters{"MinimumRR": 1.15, "SmallSMALookbackAmount": 5}, Summary: &stats.Summary{}, Score: 4},
+		{Parameters: Parameters{"MinimumRR": 3, "SmallSMALookbackAmount": 8}, Summary: &stats.Summary{}, Score: -1},
+	}
+	heatmap := NewHeatmap(evaluations, map[string]*utils.ParameterRange{
+		"MinimumRR":              {From: 1, To: 3},
+		"SmallSMALookbackAmount": {From: 5, To: 8},
+	}, "MinimumRR", "SmallSMALookbackAmount")
+
+	require.Len(t, heatmap.XValues, heatmapBins)
+	require.InDelta(t, 1.1, heatmap.XValues[0], 1e-9)
+	require.Equal(t, []float64{5, 6, 7, 8}, heatmap.YValues)
+	require.Equal(t, &HeatmapCell{Evaluations: 2, Average: 3, Best: 4}, heatmap.Cells[0][0])
+	require.Equal(t, &HeatmapCell{Evaluations: 1, Average: -1, Best: -1}, heatmap.Cells[3][heatmapBins-1])
+	require.Nil(t, heatmap.Cells[1][0])
+}
@@ -0,0 +1,216 @@
+package optimize
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/manifest"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/utils"
+	"github.com/rs/zerolog/log"
+)
+
+// JournalMismatch is an error for when a journal was not made with the same configuration and data as the search
+// resuming it, so its evaluations would not score the same.
+var JournalMismatch = errors.New("journal was made with a different configuration or data")
+
+// Fingerprint is everything an evaluation depends on besides its parameters. It is the first line of a journal, so a
+// search is only resumed from evaluations made on the same configuration and data.
+type Fingerprint struct {
+	// Configuration is the configuration without its Optimization section and with every optimised parameter zeroed,
+	// as the parameters of each evaluation replace them and resumed evaluations are scored by the current objective.
+	Configuration *utils.Configuration `json:"Configuration"`
+
+	// Start is the time the evaluations are backtested from.
+	Start time.Time `json:"Start"`
+
+	// End is the time the evaluations are backtested to.
+	End time.Time `json:"End"`
+
+	// Data is the SHA-256 of every file the candles, contract specifications and rates were read from.
+	Data []manifest.DataFile `json:"Data"`
+}
+
+// NewFingerprint returns the fingerprint of a search of the named parameters on the configuration, from the start
+// to the end of its backtest, and on the data files.
+func NewFingerprint(cfg *utils.Configuration, names []string, data []manifest.DataFile) (*Fingerprint, error) {
+	// Copy through JSON so the configuration of the search is left as it is
+	encoded, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	copied := new(utils.Configuration)
+	if err := json.Unmarshal(encoded, copied); err != nil {
+		return nil, err
+	}
+
+	zeroed := make(Parameters, len(names))
+	for _, name := range names {
+		zeroed[name] = 0
+	}
+	for instrument, instrumentConfig := range copied.Instruments {
+		if cfg.Optimization != nil && len(cfg.Optimization.Instruments) > 0 &&
+			!slices.Contains(cfg.Optimization.Instruments, instrument) {
+			continue
+		}
+		if err := zeroed.Apply(instrumentConfig); err != nil {
+			return nil, err
+		}
+	}
+	copied.Optimization = nil
+
+	return &Fingerprint{
+		Configuration: copied,
+		Start:         cfg.BacktestStartDate.Time,
+		End:           cfg.BacktestEndDate.Time,
+		Data:          data,
+	}, nil
+}
+
+// journalHeader is the first line of a journal.
+type journalHeader struct {
+	Fingerprint *Fingerprint `json:"Fingerprint"`
+}
+
+// fields returns the encoded value of every field of the fingerprint by name, so two can be compared field by field.
+func (f *Fingerprint) fields() (map[string]json.RawMessage, error) {
+	encoded, err := json.Marshal(f)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	return fields, json.Unmarshal(encoded, &fields)
+}
+
+// Journal appends every evaluation of a search to a JSON lines file as it is made, so an interrupted search can be
+// resumed without evaluating the same combinations again. It is safe to use from multiple goroutines.
+type Journal struct {
+	// mutex stops evaluations being written over each other.
+	mutex sync.Mutex
+
+	// file is the journal open for appending.
+	file *os.File
+}
+
+// OpenJournal opens a journal to append to, creating it with the fingerprint as its first line if it does not exist,
+// and returns every evaluation already in it. A journal with a different fingerprint, or without one, returns a
+// JournalMismatch error. A line that cannot be read, like one cut short when a search was stopped, is logged and
+// skipped.
+func OpenJournal(filePath string, fingerprint *Fingerprint) (*Journal, []*Evaluation, error) {
+	expected, err := fingerprint.fields()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	evaluations, err := readJournal(filePath, expected)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, err
+	}
+
+	file, err := os.OpenFile(filePath, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Start a new journal with its fingerprint, or end a line cut short so the next evaluation starts on its own line
+	info, err := file.Stat()
+	if err == nil && info.Size() == 0 {
+		var header []byte
+		if header, err = json.Marshal(journalHeader{fingerprint}); err == nil {
+			_, err = file.Write(append(header, '\n'))
+		}
+	} else if err == nil {
+		last := make([]byte, 1)
+		if _, err = file.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			_, err = file.Write([]byte{'\n'})
+		}
+	}
+	if err != nil {
+		return nil, nil, errors.Join(err, file.Close())
+	}
+
+	return &Journal{file: file}, evaluations, nil
+}
+
+// readJournal returns every evaluation of a journal, or a JournalMismatch error if its fingerprint does not have the
+// expected fields. An empty journal has nothing to compare so returns no evaluations.
+func readJournal(filePath string, expected map[string]json.RawMessage) ([]*Evaluation, error) {
+	existing, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer existing.Close()
+
+	var evaluations []*Evaluation
+	scanner := bufio.NewScanner(existing)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if line == 1 {
+			var header struct {
+				Fingerprint map[string]json.RawMessage `json:"Fingerprint"`
+			}
+			if err := json.Unmarshal(scanner.Bytes(), &header); err != nil || header.Fingerprint == nil {
+				return nil, fmt.Errorf("%s does not start with a fingerprint: %w", filePath, JournalMismatch)
+			}
+			if differing := differingRaw(expected, header.Fingerprint); len(differing) > 0 {
+				return nil, fmt.Errorf("%s differs on %v: %w", filePath, differing, JournalMismatch)
+			}
+			continue
+		}
+
+		evaluation := new(Evaluation)
+		if err := json.Unmarshal(scanner.Bytes(), evaluation); err != nil {
+			log.Warn().Msgf("Skipping line %d of %s: %s", line, filePath, err.Error())
+			continue
+		}
+		evaluations = append(evaluations, evaluation)
+	}
+
+	return evaluations, scanner.Err()
+}
+
+// differingRaw returns the names of every field that is not equal between two sets of encoded fields, in order.
+func differingRaw(a, b map[string]json.RawMessage) []string {
+	var fields []string
+	for name, value := range a {
+		if !bytes.Equal(value, b[name]) {
+			fields = append(fields, name)
+		}
+	}
+	for name := range b {
+		if _, ok := a[name]; !ok {
+			fields = append(fields, name)
+		}
+	}
+	sort.Strings(fields)
+
+	return fields
+}
+
+// Record appends an evaluation to the journal as a single line.
+func (j *Journal) Record(evaluation *Evaluation) error {
+	data, err := json.Marshal(evaluation)
+	if err != nil {
+		return err
+	}
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	_, err = j.file.Write(append(data, '\n'))
+
+	return err
+}
+
+// Close closes the journal file.
+func (j *Journal) Close() error {
+	return j.file.Close()
+}
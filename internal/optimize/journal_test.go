@@ -0,0 +1,97 @@
+package optimize
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/manifest"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/stats"
+	"github.com/stretchr/testify/require"
+)
+
+// mockFingerprint is the fingerprint of a search of the mock candles.
+var mockFingerprint = &Fingerprint{
+	Start: mockStart,
+	End:   mockStart.AddDate(0, 0, 7),
+	Data:  []manifest.DataFile{{Path: "data/ES.csv", SHA256: "e3b0c442", Bytes: 1024}},
+}
+
+// TestJournal tests evaluations are read back when a journal is reopened, skipping a line cut short
+func TestJournal(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "evaluations.jsonl")
+
+	journal, previous, err := OpenJournal(filePath, mockFingerprint)
+	require.NoError(t, err)
+	require.Empty(t, previous)
+	first := &Evaluation{Parameters: Parameters{"MinimumRR": 2}, Summary: &stats.Summary{Trades: 12, NetProfitR: 4.5}, Score: 4.5}
+	require.NoError(t, journal.Record(first))
+	require.NoError(t, journal.Close())
+
+	// Cut a second evaluation short as if the search was stopped while writing it
+	file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_APPEND, 0644)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"Parameters":{"MinimumRR":3},"Sum`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	journal, previous, err = OpenJournal(filePath, mockFingerprint)
+	require.NoError(t, err)
+	require.Len(t, previous, 1)
+	require.Equal(t, first.Parameters, previous[0].Parameters)
+	require.Equal(t, first.Summary, previous[0].Summary)
+	require.Nil(t, previous[0].Trades)
+
+	// The next evaluation starts on a new line so it can be read
+	second := &Evaluation{Parameters: Parameters{"MinimumRR": 3}, Summary: &stats.Summary{Trades: 8}}
+	require.NoError(t, journal.Record(second))
+	require.NoError(t, journal.Close())
+
+	_, previous, err = OpenJournal(filePath, mockFingerprint)
+	require.NoError(t, err)
+	require.Len(t, previous, 2)
+	require.Equal(t, second.Parameters, previous[1].Parameters)
+}
+
+// TestJournalFingerprint tests a journal is only resumed by a search with the same fingerprint
+func TestJournalFingerprint(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "evaluations.jsonl")
+
+	journal, _, err := OpenJournal(filePath, mockFingerprint)
+	require.NoError(t, err)
+	require.NoError(t, journal.Record(&Evaluation{Parameters: Parameters{"MinimumRR": 2}, Summary: &stats.Summary{}}))
+	require.NoError(t, journal.Close())
+
+	journal, previous, err := OpenJournal(filePath, mockFingerprint)
+	require.NoError(t, err)
+	require.Len(t, previous, 1)
+	require.NoError(t, journal.Close())
+
+	// Changed data or dates score differently so are refused
+	changed := *mockFingerprint
+	changed.Data = []manifest.DataFile{{Path: "data/ES.csv", SHA256: "5feceb66", Bytes: 1024}}
+	changed.End = mockStart.AddDate(0, 0, 5)
+	_, _, err = OpenJournal(filePath, &changed)
+	require.ErrorIs(t, err, JournalMismatch)
+	require.ErrorContains(t, err, "[Data End]")
+
+	// A journal without a fingerprint cannot be checked so is refused
+	unfingerprinted := filepath.Join(t.TempDir(), "unfingerprinted.jsonl")
+	require.NoError(t, os.WriteFile(unfingerprinted, []byte(`{"Parameters":{"MinimumRR":2},"Summary":{}}`+"\n"), 0644))
+	_, _, err = OpenJournal(unfingerprinted, mockFingerprint)
+	require.ErrorIs(t, err, JournalMismatch)
+}
+
+// TestNewFingerprint tests the optimised parameters and Optimization section are left out of the fingerprint
+func TestNewFingerprint(t *testing.T) {
+	cfg := mockEvaluator(t).configuration
+	fingerprint, err := NewFingerprint(cfg, []string{"MinimumRR"}, nil)
+	require.NoError(t, err)
+	require.Nil(t, fingerprint.Configuration.Optimization)
+	require.Zero(t, fingerprint.Configuration.Instruments["ES"].MinimumRR)
+	require.Equal(t, 20, fingerprint.Configuration.Instruments["ES"].SmallSMALookbackAmount)
+
+	// The configuration searched is left as it is
+	require.Equal(t, 1.5, cfg.Instruments["ES"].MinimumRR)
+	require.NotNil(t, cfg.Optimization)
+}
@@ -0,0 +1,186 @@
+package optimize
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/stats"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/utils"
+	"github.com/rs/zerolog/log"
+)
+
+// ReturnOverDrawdown returns the total return divided by the max drawdown, both as percentages.
+// A drawdown below 1% counts as 1% so a combination with few losses is not scored as infinitely good.
+func ReturnOverDrawdown(summary *stats.Summary) float64 {
+	if summary.StartingBalance == 0 {
+		return 0
+	}
+
+	return summary.NetProfit / summary.StartingBalance * 100 / max(summary.Drawdown.DepthPercent, 1)
+}
+
+// Score returns the value of an objective for a summary, higher is better.
+func Score(summary *stats.Summary, objective string) float64 {
+	switch objective {
+	case utils.Objective.PROFIT_FACTOR:
+		return summary.ProfitFactor
+	case utils.Objective.SHARPE:
+		return summary.Sharpe
+	case utils.Objective.RETURN_OVER_DRAWDOWN:
+		return ReturnOverDrawdown(summary)
+	default:
+		return summary.NetProfitR
+	}
+}
+
+// Search evaluates every combination of parameters from the start time to the end time, with the given amount of
+// workers in parallel. Combinations that cannot be evaluated are logged and left out, the rest are returned in the
+// order of combinations.
+func Search(evaluator *Evaluator, combinations []Parameters, start, end time.Time, workers int) []*Evaluation {
+	var results []*Evaluation
+	for _, evaluation := range evaluate(evaluator, combinations, start, end, workers, true) {
+		if evaluation != nil {
+			results = append(results, evaluation)
+		}
+	}
+
+	return results
+}
+
+// evaluate evaluates every combination of parameters with the given amount of workers in parallel, and returns the
+// evaluations in the order of combinations with nil for those that could not be evaluated, which are logged. The
+// progress is logged every 10% if asked for.
+func evaluate(evaluator *Evaluator, combinations []Parameters, start, end time.Time, workers int, progress bool) []*Evaluation {
+	var (
+		evaluations = make([]*Evaluation, len(combinations))
+		indexes     = make(chan int)
+		wg          = new(sync.WaitGroup)
+		mutex       = new(sync.Mutex)
+		evaluated   int
+	)
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				evaluation, err := evaluator.Evaluate(combinations[index], start, end)
+				if err != nil {
+					log.Error().Str("parameters", combinations[index].Key()).Msg(err.Error())
+				}
+				evaluations[index] = evaluation
+
+				// Log the progress every 10% so long searches can be followed
+				mutex.Lock()
+				evaluated++
+				if progress && evaluated%max(len(combinations)/10, 1) == 0 {
+					log.Info().Msgf("Evaluated %d of %d combinations", evaluated, len(combinations))
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+	for index := range combinations {
+		indexes <- index
+	}
+	close(indexes)
+	wg.Wait()
+
+	return evaluations
+}
+
+// better returns whether an evaluation ranks above another, evaluations with fewer than minimumTrades trades rank
+// below every evaluation with enough.
+func better(evaluation, other *Evaluation, minimumTrades int) bool {
+	enough := evaluation.Summary.Trades >= minimumTrades
+	otherEnough := other.Summary.Trades >= minimumTrades
+	if enough != otherEnough {
+		return enough
+	}
+
+	return evaluation.Score > other.Score
+}
+
+// Rank sorts evaluations from the best to the worst score. Evaluations with fewer than minimumTrades trades are
+// ranked below every evaluation with enough, and equal scores keep their order.
+func Rank(evaluations []*Evaluation, minimumTrades int) {
+	sort.SliceStable(evaluations, func(i, j int) bool {
+		return better(evaluations[i], evaluations[j], minimumTrades)
+	})
+}
+
+// WriteCSV writes ranked evaluations to a CSV on disk, creating or overwriting it, with a column for each parameter
+// followed by the score and the headline statistics, and the robustness if it has been scored.
+func WriteCSV(evaluations []*Evaluation, filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var names []string
+	if len(evaluations) > 0 {
+		names = evaluations[0].Parameters.Names()
+	}
+
+	writer := csv.NewWriter(file)
+	header := append([]string{"Rank"}, names...)
+	header = append(header,
+		"Score",
+		"Trades",
+		"WinRate",
+		"NetProfit",
+		"NetProfitR",
+		"ProfitFactor",
+		"Sharpe",
+		"MaxDrawdownPercent",
+		"ReturnOverDrawdown",
+	)
+	robust := len(evaluations) > 0 && evaluations[0].Robustness != nil
+	if robust {
+		header = append(header, "Neighbours", "NeighbourAverage", "NeighbourWorst", "RobustScore")
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	format := func(value float64) string {
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	for rank, evaluation := range evaluations {
+		record := []string{strconv.Itoa(rank + 1)}
+		for _, name := range names {
+			record = append(record, format(evaluation.Parameters[name]))
+		}
+		summary := evaluation.Summary
+		record = append(record,
+			format(evaluation.Score),
+			strconv.Itoa(summary.Trades),
+			format(summary.WinRate),
+			format(summary.NetProfit),
+			format(summary.NetProfitR),
+			format(summary.ProfitFactor),
+			format(summary.Sharpe),
+			format(summary.Drawdown.DepthPercent),
+			format(ReturnOverDrawdown(summary)),
+		)
+		if robust {
+			record = append(record,
+				strconv.Itoa(evaluation.Robustness.Neighbours),
+				format(evaluation.Robustness.NeighbourAverage),
+				format(evaluation.Robustness.NeighbourWorst),
+				format(evaluation.Robustness.Score),
+			)
+		}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("error writing optimization results: %w", err)
+		}
+	}
+	writer.Flush()
+
+	return writer.Error()
+}
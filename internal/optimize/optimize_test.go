@@ -0,0 +1,156 @@
+package optimize
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/backtestData"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/fx"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/stats"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// mockStart is the time of the first mock candle, a Monday.
+var mockStart = time.Date(2023, 10, 16, 0, 0, 0, 0, time.UTC)
+
+// mockEvaluator returns an Evaluator of ES over a week of five minute candles that oscillate around 4000.
+func mockEvaluator(t *testing.T) *Evaluator {
+	var data backtestData.Data
+	for index := 0; index < 5*24*12; index++ {
+		price := 4000 + 20*math.Sin(float64(index)/15) + 5*math.Sin(float64(index)/4)
+		data = append(data, &backtestData.Row{
+			Time:  mockStart.Add(time.Duration(index) * 5 * time.Minute),
+			Open:  price - 1,
+			High:  price + 3,
+			Low:   price - 3,
+			Close: price,
+		})
+	}
+
+	cfg := &utils.Configuration{
+		StartingBalance:       10000,
+		AccountPositionSizing: &utils.PositionSizingConfiguration{Model: utils.PositionSizingModel.PERCENT_RISK, RiskPercent: 1},
+		Instruments: map[string]*utils.InstrumentConfiguration{
+			"ES": {
+				MinimumRR:                   1.5,
+				LargeSMALookbackAmount:      50,
+				SmallSMALookbackAmount:      20,
+				UnbrokenBoundaryLeftBars:    3,
+				UnbrokenBoundaryRightBars:   3,
+				UnbrokenBoundaryMemoryLimit: 50,
+				StochasticUpperBand:         80,
+				StochasticLowerBand:         20,
+				StochasticKPeriods:          14,
+				StochasticDPeriods:          3,
+			},
+		},
+		Optimization: &utils.OptimizationConfiguration{Objective: utils.Objective.NET_R, Workers: 2},
+	}
+	specs := utils.ContractSpecRegistry{"ES": {TickSize: 0.25, TickValue: 12.5, Currency: "USD"}}
+	converter, err := fx.NewConverter("USD", nil)
+	require.NoError(t, err)
+
+	return NewEvaluator(cfg, specs, converter, map[string]backtestData.Data{"ES": data})
+}
+
+// TestEvaluate tests combinations only recalculate the indicators their parameters change
+func TestEvaluate(t *testing.T) {
+	evaluator := mockEvaluator(t)
+	end := mockStart.AddDate(0, 0, 7)
+
+	first, err := evaluator.Evaluate(Parameters{"MinimumRR": 1}, mockStart, end)
+	require.NoError(t, err)
+	require.Equal(t, first.Summary.NetProfitR, first.Score)
+	require.Equal(t, len(*first.Trades), first.Summary.Trades)
+
+	// A trade management parameter reuses every indicator
+	_, err = evaluator.Evaluate(Parameters{"MinimumRR": 3}, mockStart, end)
+	require.NoError(t, err)
+	require.Len(t, evaluator.smas.entries, 1)
+	require.Len(t, evaluator.boundaries.entries, 1)
+
+	// A moving average parameter only recalculates the moving averages
+	_, err = evaluator.Evaluate(Parameters{"SmallSMALookbackAmount": 10}, mockStart, end)
+	require.NoError(t, err)
+	require.Len(t, evaluator.smas.entries, 2)
+	require.Len(t, evaluator.boundaries.entries, 1)
+
+	// The configuration and cached candles are not changed, so the same combination gives the same result
+	require.Equal(t, 20, evaluator.configuration.Instruments["ES"].SmallSMALookbackAmount)
+	again, err := evaluator.Evaluate(Parameters{"MinimumRR": 1}, mockStart, end)
+	require.NoError(t, err)
+	require.Equal(t, first.Summary, again.Summary)
+
+	_, err = evaluator.Evaluate(Parameters{"Unknown": 1}, mockStart, end)
+	require.ErrorIs(t, err, ConfigurationInvalid)
+}
+
+// TestSearch tests every combination is evaluated in parallel and returned in order
+func TestSearch(t *testing.T) {
+	evaluator := mockEvaluator(t)
+	grid := Grid(map[string]*utils.ParameterRange{
+		"MinimumRR":                {Values: []float64{1, 2}},
+		"SmallSMALookbackAmount":   {Values: []float64{10, 20}},
+		"UnbrokenBoundaryLeftBars": {Values: []float64{-1}},
+	})
+	grid = append(grid, Parameters{"Unknown": 1})
+
+	evaluations := Search(evaluator, grid, mockStart, mockStart.AddDate(0, 0, 7), 3)
+
+	require.Len(t, evaluations, 4)
+	for index, evaluation := range evaluations {
+		require.Equal(t, grid[index], evaluation.Parameters)
+	}
+}
+
+// TestScore tests each objective reads the right statistic
+func TestScore(t *testing.T) {
+	summary := &stats.Summary{StartingBalance: 1000, NetProfit: 200, NetProfitR: 12, ProfitFactor: 1.5, Sharpe: 0.8}
+	summary.Drawdown.DepthPercent = 5
+
+	require.Equal(t, 12.0, Score(summary, utils.Objective.NET_R))
+	require.Equal(t, 1.5, Score(summary, utils.Objective.PROFIT_FACTOR))
+	require.Equal(t, 0.8, Score(summary, utils.Objective.SHARPE))
+	require.Equal(t, 4.0, Score(summary, utils.Objective.RETURN_OVER_DRAWDOWN))
+
+	// A drawdown below 1% counts as 1%
+	summary.Drawdown.DepthPercent = 0.1
+	require.Equal(t, 20.0, Score(summary, utils.Objective.RETURN_OVER_DRAWDOWN))
+}
+
+// TestRankWriteCSV tests combinations with too few trades are ranked last and the ranking is written to disk
+func TestRankWriteCSV(t *testing.T) {
+	evaluations := []*Evaluation{
+		{Parameters: Parameters{"MinimumRR": 1}, Summary: &stats.Summary{Trades: 2}, Score: 50},
+		{Parameters: Parameters{"MinimumRR": 2}, Summary: &stats.Summary{Trades: 20}, Score: 5},
+		{Parameters: Parameters{"MinimumRR": 3}, Summary: &stats.Summary{Trades: 30}, Score: 10},
+	}
+
+	Rank(evaluations, 10)
+	require.Equal(t, 3.0, evaluations[0].Parameters["MinimumRR"])
+	require.Equal(t, 2.0, evaluations[1].Parameters["MinimumRR"])
+	require.Equal(t, 1.0, evaluations[2].Parameters["MinimumRR"])
+
+	filePath := filepath.Join(t.TempDir(), "optimize.csv")
+	require.NoError(t, WriteCSV(evaluations, filePath))
+	contents, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(contents)), "\n")
+	require.Len(t, lines, 4)
+	require.True(t, strings.HasPrefix(lines[0], "Rank,MinimumRR,Score,Trades"))
+	require.True(t, strings.HasPrefix(lines[1], "1,3,10,30,"))
+
+	// The robustness of each combination is written once it has been scored
+	ScoreRobustness(evaluations, map[string]*utils.ParameterRange{"MinimumRR": {From: 1, To: 3, Step: 1}})
+	require.NoError(t, WriteCSV(evaluations, filePath))
+	contents, err = os.ReadFile(filePath)
+	require.NoError(t, err)
+	lines = strings.Split(strings.TrimSpace(string(contents)), "\n")
+	require.True(t, strings.HasSuffix(lines[0], ",Neighbours,NeighbourAverage,NeighbourWorst,RobustScore"))
+	require.True(t, strings.HasSuffix(lines[1], ",1,5,5,7.5"))
+}
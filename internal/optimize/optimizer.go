@@ -0,0 +1,276 @@
+package optimize
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/utils"
+	"github.com/rs/zerolog/log"
+)
+
+// Budget is when a search stops picking new combinations to evaluate.
+type Budget struct {
+	// Evaluations is the amount of combinations to evaluate, including any resumed from a journal, 0 for no limit.
+	Evaluations int
+
+	// Deadline is the time to stop evaluating new combinations at, the zero time for no limit.
+	Deadline time.Time
+}
+
+// Optimizer evaluates batches of combinations within a budget, never evaluating the same combination twice and
+// journaling every evaluation as it is made.
+type Optimizer struct {
+	// evaluator backtests every combination.
+	evaluator *Evaluator
+
+	// start is the start time of every backtest.
+	start time.Time
+
+	// end is the end time of every backtest.
+	end time.Time
+
+	// workers is the amount of combinations evaluated in parallel.
+	workers int
+
+	// minimumTrades is the amount of trades a combination needs to rank above the combinations with fewer.
+	minimumTrades int
+
+	// budget is when to stop evaluating new combinations.
+	budget Budget
+
+	// journal records every new evaluation (optional)
+	journal *Journal
+
+	// journaled is the evaluations of an earlier search by the key of their parameters, which are used instead of
+	// evaluating the combination again when the search asks for it.
+	journaled map[string]*Evaluation
+
+	// evaluations is every evaluation so far by the key of its parameters.
+	evaluations map[string]*Evaluation
+
+	// failed is the key of every combination that could not be evaluated, so it is not tried again.
+	failed map[string]bool
+
+	// order is every evaluation so far in the order it was made.
+	order []*Evaluation
+}
+
+// NewOptimizer creates an Optimizer that backtests from the start time to the end time. Previous evaluations, like
+// those of a journal being resumed, are rescored with the objective of the evaluator and used instead of evaluating
+// the same combination again. They count towards the budget once the search asks for them, so a search resumed with
+// the same seed picks the same combinations as one that was never stopped.
+func NewOptimizer(
+	evaluator *Evaluator,
+	start, end time.Time,
+	workers, minimumTrades int,
+	budget Budget,
+	journal *Journal,
+	previous []*Evaluation,
+) *Optimizer {
+	o := &Optimizer{
+		evaluator:     evaluator,
+		start:         start,
+		end:           end,
+		workers:       workers,
+		minimumTrades: minimumTrades,
+		budget:        budget,
+		journal:       journal,
+		journaled:     make(map[string]*Evaluation),
+		evaluations:   make(map[string]*Evaluation),
+		failed:        make(map[string]bool),
+	}
+	for _, evaluation := range previous {
+		evaluation.Score = Score(evaluation.Summary, evaluator.objective())
+		o.journaled[evaluation.Parameters.Key()] = evaluation
+	}
+
+	return o
+}
+
+// Exhausted returns whether the budget has run out.
+func (o *Optimizer) Exhausted() bool {
+	if o.budget.Evaluations > 0 && len(o.evaluations)+len(o.failed) >= o.budget.Evaluations {
+		return true
+	}
+
+	return !o.budget.Deadline.IsZero() && time.Now().After(o.budget.Deadline)
+}
+
+// Evaluate evaluates every combination of a batch not already evaluated while the budget lasts, taking those in the
+// journal of an earlier search from it, and returns the evaluation of every combination of the batch that has one, in
+// the order of the batch. The deadline is only checked before the batch starts.
+func (o *Optimizer) Evaluate(batch []Parameters) []*Evaluation {
+	var pending []Parameters
+	if !o.Exhausted() {
+		queued := make(map[string]bool)
+		for _, parameters := range batch {
+			key := parameters.Key()
+			if o.evaluations[key] != nil || o.failed[key] || queued[key] {
+				continue
+			}
+			if o.budget.Evaluations > 0 && len(o.evaluations)+len(o.failed)+len(pending) >= o.budget.Evaluations {
+				break
+			}
+			if journaled := o.journaled[key]; journaled != nil {
+				o.evaluations[key] = journaled
+				o.order = append(o.order, journaled)
+				continue
+			}
+			queued[key] = true
+			pending = append(pending, parameters)
+		}
+	}
+
+	for index, evaluation := range evaluate(o.evaluator, pending, o.start, o.end, o.workers, false) {
+		if evaluation == nil {
+			o.failed[pending[index].Key()] = true
+			continue
+		}
+		o.evaluations[evaluation.Parameters.Key()] = evaluation
+		o.order = append(o.order, evaluation)
+		if o.journal != nil {
+			if err := o.journal.Record(evaluation); err != nil {
+				log.Error().Msg(err.Error())
+			}
+		}
+	}
+	if len(pending) > 0 {
+		best := o.Best()
+		log.Info().Msgf(
+			"Evaluated %d combinations, the best is %s with a score of %.2f over %d trades",
+			len(o.order),
+			best.Parameters.Key(),
+			best.Score,
+			best.Summary.Trades,
+		)
+	}
+
+	var results []*Evaluation
+	for _, parameters := range batch {
+		if evaluation := o.evaluations[parameters.Key()]; evaluation != nil {
+			results = append(results, evaluation)
+		}
+	}
+
+	return results
+}
+
+// evaluated returns whether a combination has been evaluated, or could not be.
+func (o *Optimizer) evaluated(parameters Parameters) bool {
+	key := parameters.Key()
+
+	return o.evaluations[key] != nil || o.failed[key]
+}
+
+// Best returns the best evaluation so far, or nil if there are none.
+func (o *Optimizer) Best() *Evaluation {
+	var best *Evaluation
+	for _, evaluation := range o.order {
+		if best == nil || better(evaluation, best, o.minimumTrades) {
+			best = evaluation
+		}
+	}
+
+	return best
+}
+
+// Evaluations returns every evaluation so far ranked from the best to the worst.
+func (o *Optimizer) Evaluations() []*Evaluation {
+	evaluations := append([]*Evaluation(nil), o.order...)
+	Rank(evaluations, o.minimumTrades)
+
+	return evaluations
+}
+
+// GridSearch evaluates the combinations in order until they are all evaluated or the budget runs out, in batches of
+// a tenth of the combinations so the progress is logged.
+func GridSearch(o *Optimizer, combinations []Parameters) {
+	batchSize := max(len(combinations)/10, o.workers)
+	for start := 0; start < len(combinations) && !o.Exhausted(); start += batchSize {
+		o.Evaluate(combinations[start:min(start+batchSize, len(combinations))])
+	}
+}
+
+// RandomSearch evaluates combinations drawn at random from the space until the budget runs out, or every combination
+// of a space without continuous ranges has been evaluated. The same random source always draws the same combinations.
+func RandomSearch(o *Optimizer, space *Space, random *rand.Rand) {
+	batchSize := o.workers * 4
+	for !o.Exhausted() {
+		// Draw combinations not evaluated yet, giving up on a batch once the space is too full to find more
+		var (
+			batch []Parameters
+			drawn = make(map[string]bool)
+		)
+		for attempt := 0; attempt < batchSize*100 && len(batch) < batchSize; attempt++ {
+			parameters := space.Sample(random)
+			if key := parameters.Key(); !o.evaluated(parameters) && !drawn[key] {
+				drawn[key] = true
+				batch = append(batch, parameters)
+			}
+		}
+		if len(batch) == 0 {
+			log.Info().Msg("Every combination has been evaluated")
+			return
+		}
+		o.Evaluate(batch)
+	}
+}
+
+// GeneticSearch breeds generations of combinations until the budget runs out, or a generation has no combination
+// that has not been evaluated before. The first generation is drawn at random, and each after keeps the elite of the
+// last and fills the rest with children of parents picked by tournament, mixed by crossover and then mutated. A child
+// that repeats a combination is mutated again, so the search only converges once it cannot find anything new. The
+// same random source always breeds the same generations.
+func GeneticSearch(o *Optimizer, space *Space, random *rand.Rand, cfg *utils.GeneticConfiguration) {
+	population := make([]Parameters, cfg.PopulationSize)
+	for index := range population {
+		population[index] = space.Sample(random)
+	}
+
+	for generation := 1; !o.Exhausted(); generation++ {
+		evaluated := len(o.order) + len(o.failed)
+		ranked := o.Evaluate(population)
+		if len(ranked) == 0 {
+			log.Error().Msgf("No combination of generation %d could be evaluated", generation)
+			return
+		}
+		if generation > 1 && len(o.order)+len(o.failed) == evaluated {
+			log.Info().Msgf("Generation %d has converged, every combination has been evaluated before", generation)
+			return
+		}
+		Rank(ranked, o.minimumTrades)
+		log.Info().Msgf("Generation %d best score %.2f with %s", generation, ranked[0].Score, ranked[0].Parameters.Key())
+
+		// Pick the best of a few combinations drawn at random, which favours the best without always picking them
+		tournament := func() Parameters {
+			winner := ranked[random.Intn(len(ranked))]
+			for round := 1; round < cfg.TournamentSize; round++ {
+				if challenger := ranked[random.Intn(len(ranked))]; better(challenger, winner, o.minimumTrades) {
+					winner = challenger
+				}
+			}
+			return winner.Parameters
+		}
+
+		next := make([]Parameters, 0, cfg.PopulationSize)
+		for _, elite := range ranked[:min(cfg.EliteCount, len(ranked))] {
+			next = append(next, elite.Parameters)
+		}
+		bred := make(map[string]bool)
+		for len(next) < cfg.PopulationSize {
+			child := tournament()
+			if random.Float64() < cfg.CrossoverRate {
+				child = space.Crossover(random, child, tournament())
+			}
+			child = space.Mutate(random, child, cfg.MutationRate)
+
+			// Mutate a child that repeats a combination further, so each generation explores something new
+			for attempt := 0; attempt < 10 && (o.evaluated(child) || bred[child.Key()]); attempt++ {
+				child = space.Mutate(random, child, 1/float64(len(space.names)))
+			}
+			bred[child.Key()] = true
+			next = append(next, child)
+		}
+		population = next
+	}
+}
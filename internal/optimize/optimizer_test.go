@@ -0,0 +1,153 @@
+package optimize
+
+import (
+	"math/rand"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// mockRanges is a continuous integer and float range to search on the mock candles.
+var mockRanges = map[string]*utils.ParameterRange{
+	"SmallSMALookbackAmount": {From: 5, To: 40},
+	"MinimumRR":              {From: 0.5, To: 3},
+}
+
+// keys returns the key of every evaluation in order.
+func keys(evaluations []*Evaluation) []string {
+	var evaluationKeys []string
+	for _, evaluation := range evaluations {
+		evaluationKeys = append(evaluationKeys, evaluation.Parameters.Key())
+	}
+
+	return evaluationKeys
+}
+
+// newMockOptimizer returns an Optimizer of the mock candles with a budget of evaluations.
+func newMockOptimizer(t *testing.T, evaluations int, journal *Journal, previous []*Evaluation) *Optimizer {
+	return NewOptimizer(
+		mockFundedEvaluator(t),
+		mockStart,
+		mockStart.AddDate(0, 0, 7),
+		2,
+		0,
+		Budget{Evaluations: evaluations},
+		journal,
+		previous,
+	)
+}
+
+// TestOptimizerEvaluate tests combinations are only evaluated once and not beyond the budget
+func TestOptimizerEvaluate(t *testing.T) {
+	o := newMockOptimizer(t, 3, nil, nil)
+	first, second := Parameters{"MinimumRR": 1}, Parameters{"MinimumRR": 2}
+
+	evaluations := o.Evaluate([]Parameters{first, second, first})
+	require.Equal(t, []string{"MinimumRR=1", "MinimumRR=2", "MinimumRR=1"}, keys(evaluations))
+	require.Same(t, evaluations[0], evaluations[2])
+	require.False(t, o.Exhausted())
+
+	// Only one more combination fits in the budget, and the failed one counts towards it
+	evaluations = o.Evaluate([]Parameters{{"Unknown": 1}, {"MinimumRR": 3}, first})
+	require.Equal(t, []string{"MinimumRR=1"}, keys(evaluations))
+	require.True(t, o.Exhausted())
+	require.Len(t, o.Evaluations(), 2)
+
+	// A deadline that has passed stops anything new being evaluated
+	late := NewOptimizer(mockFundedEvaluator(t), mockStart, mockStart.AddDate(0, 0, 7), 2, 0, Budget{Deadline: time.Now()}, nil, nil)
+	require.True(t, late.Exhausted())
+	require.Empty(t, late.Evaluate([]Parameters{first}))
+}
+
+// TestRandomSearch tests a seeded search evaluates the budget of different combinations, and stops once a finite
+// space is exhausted
+func TestRandomSearch(t *testing.T) {
+	o := newMockOptimizer(t, 12, nil, nil)
+	space, err := NewSpace(mockRanges)
+	require.NoError(t, err)
+	RandomSearch(o, space, rand.New(rand.NewSource(7)))
+	require.Len(t, o.Evaluations(), 12)
+
+	again := newMockOptimizer(t, 12, nil, nil)
+	RandomSearch(again, space, rand.New(rand.NewSource(7)))
+	require.Equal(t, keys(o.order), keys(again.order))
+
+	finite, err := NewSpace(map[string]*utils.ParameterRange{"MinimumRR": {Values: []float64{1, 2, 3}}})
+	require.NoError(t, err)
+	small := newMockOptimizer(t, 100, nil, nil)
+	RandomSearch(small, finite, rand.New(rand.NewSource(7)))
+	require.Len(t, small.Evaluations(), 3)
+}
+
+// TestRandomSearchResume tests a search resumed from its journal continues where it stopped
+func TestRandomSearchResume(t *testing.T) {
+	space, err := NewSpace(mockRanges)
+	require.NoError(t, err)
+	uninterrupted := newMockOptimizer(t, 10, nil, nil)
+	RandomSearch(uninterrupted, space, rand.New(rand.NewSource(3)))
+
+	filePath := filepath.Join(t.TempDir(), "evaluations.jsonl")
+	journal, _, err := OpenJournal(filePath, mockFingerprint)
+	require.NoError(t, err)
+	stopped := newMockOptimizer(t, 4, journal, nil)
+	RandomSearch(stopped, space, rand.New(rand.NewSource(3)))
+	require.NoError(t, journal.Close())
+
+	journal, previous, err := OpenJournal(filePath, mockFingerprint)
+	require.NoError(t, err)
+	require.Len(t, previous, 4)
+	resumed := newMockOptimizer(t, 10, journal, previous)
+	RandomSearch(resumed, space, rand.New(rand.NewSource(3)))
+	require.NoError(t, journal.Close())
+
+	require.Equal(t, keys(uninterrupted.order), keys(resumed.order))
+	_, journaled, err := OpenJournal(filePath, mockFingerprint)
+	require.NoError(t, err)
+	require.Len(t, journaled, 10)
+}
+
+// TestGeneticSearch tests a seeded genetic search breeds the same generations within the budget
+func TestGeneticSearch(t *testing.T) {
+	space, err := NewSpace(mockRanges)
+	require.NoError(t, err)
+	cfg := &utils.GeneticConfiguration{PopulationSize: 6, EliteCount: 2, TournamentSize: 3, CrossoverRate: 0.8, MutationRate: 0.3}
+
+	o := newMockOptimizer(t, 20, nil, nil)
+	GeneticSearch(o, space, rand.New(rand.NewSource(5)), cfg)
+	require.Len(t, o.Evaluations(), 20)
+
+	again := newMockOptimizer(t, 20, nil, nil)
+	GeneticSearch(again, space, rand.New(rand.NewSource(5)), cfg)
+	require.Equal(t, keys(o.order), keys(again.order))
+
+	// The best of every generation is kept, so the best evaluation is never worse than the first generation's
+	first := append([]*Evaluation(nil), o.order[:6]...)
+	Rank(first, 0)
+	require.GreaterOrEqual(t, o.Best().Score, first[0].Score)
+}
+
+// TestGeneticSearchResume tests a genetic search resumed from its journal breeds the same generations
+func TestGeneticSearchResume(t *testing.T) {
+	space, err := NewSpace(mockRanges)
+	require.NoError(t, err)
+	cfg := &utils.GeneticConfiguration{PopulationSize: 6, EliteCount: 2, TournamentSize: 3, CrossoverRate: 0.8, MutationRate: 0.1}
+	uninterrupted := newMockOptimizer(t, 20, nil, nil)
+	GeneticSearch(uninterrupted, space, rand.New(rand.NewSource(5)), cfg)
+
+	filePath := filepath.Join(t.TempDir(), "evaluations.jsonl")
+	journal, _, err := OpenJournal(filePath, mockFingerprint)
+	require.NoError(t, err)
+	GeneticSearch(newMockOptimizer(t, 15, journal, nil), space, rand.New(rand.NewSource(5)), cfg)
+	require.NoError(t, journal.Close())
+
+	journal, previous, err := OpenJournal(filePath, mockFingerprint)
+	require.NoError(t, err)
+	resumed := newMockOptimizer(t, 20, journal, previous)
+	GeneticSearch(resumed, space, rand.New(rand.NewSource(5)), cfg)
+	require.NoError(t, journal.Close())
+
+	require.Equal(t, keys(uninterrupted.order), keys(resumed.order))
+}
@@ -0,0 +1,199 @@
+package optimize
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/utils"
+)
+
+// ConfigurationInvalid is an error for when the optimization configuration cannot be used to search parameters.
+var ConfigurationInvalid = errors.New("optimization configuration is invalid")
+
+// Parameters is a value for every optimised field, keyed by the InstrumentConfiguration field name.
+type Parameters map[string]float64
+
+// Names returns the names of the parameters in alphabetical order.
+func (p Parameters) Names() []string {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Key returns the parameters as a string like "MinimumRR=2,SmallSMALookbackAmount=50" in name order,
+// which is the same for any Parameters with the same values.
+func (p Parameters) Key() string {
+	pairs := make([]string, 0, len(p))
+	for _, name := range p.Names() {
+		pairs = append(pairs, fmt.Sprintf("%s=%g", name, p[name]))
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+// Apply sets every parameter on an instrument configuration. It returns a ConfigurationInvalid error if a parameter
+// is not a numeric field, or is not a whole number for an integer field.
+func (p Parameters) Apply(cfg *utils.InstrumentConfiguration) error {
+	value := reflect.ValueOf(cfg).Elem()
+	for _, name := range p.Names() {
+		field := value.FieldByName(name)
+		switch {
+		case !field.IsValid():
+			return fmt.Errorf("%s is not an instrument configuration field: %w", name, ConfigurationInvalid)
+		case field.Kind() == reflect.Int:
+			if p[name] != math.Trunc(p[name]) {
+				return fmt.Errorf("%s must be a whole number, got %g: %w", name, p[name], ConfigurationInvalid)
+			}
+			field.SetInt(int64(p[name]))
+		case field.Kind() == reflect.Float64:
+			field.SetFloat(p[name])
+		default:
+			return fmt.Errorf("%s is not a numeric field: %w", name, ConfigurationInvalid)
+		}
+	}
+
+	return nil
+}
+
+// Domain returns every value of a parameter range in order, Values if set, otherwise every Step from From to To.
+func Domain(r *utils.ParameterRange) []float64 {
+	if len(r.Values) > 0 {
+		return r.Values
+	}
+	if r.Step <= 0 || r.To < r.From {
+		return nil
+	}
+
+	// Count the steps with a tolerance, and round each value, so float steps like 0.1 do not drift
+	steps := int(math.Floor((r.To-r.From)/r.Step + 1e-9))
+	values := make([]float64, 0, steps+1)
+	for step := 0; step <= steps; step++ {
+		values = append(values, math.Round((r.From+float64(step)*r.Step)*1e9)/1e9)
+	}
+
+	return values
+}
+
+// Grid returns every combination of the values of the parameter ranges, with the alphabetically last parameter
+// changing fastest.
+func Grid(ranges map[string]*utils.ParameterRange) []Parameters {
+	names := make([]string, 0, len(ranges))
+	for name := range ranges {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	grid := []Parameters{{}}
+	for _, name := range names {
+		var next []Parameters
+		for _, combination := range grid {
+			for _, value := range Domain(ranges[name]) {
+				parameters := make(Parameters, len(combination)+1)
+				for existing, existingValue := range combination {
+					parameters[existing] = existingValue
+				}
+				parameters[name] = value
+				next = append(next, parameters)
+			}
+		}
+		grid = next
+	}
+
+	return grid
+}
+
+// Validate returns a ConfigurationInvalid error if the optimization configuration cannot be used to search
+// parameters.
+func Validate(cfg *utils.Configuration) error {
+	optimization := cfg.Optimization
+	if optimization == nil {
+		return fmt.Errorf("Optimization is not set: %w", ConfigurationInvalid)
+	}
+
+	switch optimization.Objective {
+	case utils.Objective.NET_R, utils.Objective.PROFIT_FACTOR, utils.Objective.SHARPE, utils.Objective.RETURN_OVER_DRAWDOWN:
+	default:
+		return fmt.Errorf("got Objective %q: %w", optimization.Objective, ConfigurationInvalid)
+	}
+
+	switch optimization.Method {
+	case utils.SearchMethod.GRID, utils.SearchMethod.RANDOM, utils.SearchMethod.GENETIC:
+	default:
+		return fmt.Errorf("got Method %q: %w", optimization.Method, ConfigurationInvalid)
+	}
+
+	if len(optimization.Parameters) == 0 {
+		return fmt.Errorf("Parameters must have at least one field: %w", ConfigurationInvalid)
+	}
+	if _, err := NewSpace(optimization.Parameters); err != nil {
+		return err
+	}
+	for name, parameterRange := range optimization.Parameters {
+		// Only the random and genetic methods can draw from a continuous range
+		values := Domain(parameterRange)
+		if len(values) == 0 && optimization.Method == utils.SearchMethod.GRID {
+			return fmt.Errorf("%s must have Values, or a Step greater than 0 from From to To: %w", name, ConfigurationInvalid)
+		}
+		for _, value := range append(values, 0) {
+			if err := (Parameters{name: value}).Apply(&utils.InstrumentConfiguration{}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if optimization.MaxEvaluations < 0 || optimization.MaxMinutes < 0 {
+		return fmt.Errorf("MaxEvaluations and MaxMinutes cannot be negative: %w", ConfigurationInvalid)
+	}
+	if optimization.Method != utils.SearchMethod.GRID && optimization.MaxEvaluations == 0 && optimization.MaxMinutes == 0 {
+		return fmt.Errorf("a %s search needs MaxEvaluations or MaxMinutes: %w", optimization.Method, ConfigurationInvalid)
+	}
+
+	for _, pair := range optimization.Heatmaps {
+		if len(pair) != 2 || pair[0] == pair[1] ||
+			optimization.Parameters[pair[0]] == nil || optimization.Parameters[pair[1]] == nil {
+			return fmt.Errorf("every heatmap must be two different parameters, got %v: %w", pair, ConfigurationInvalid)
+		}
+	}
+
+	if genetic := optimization.Genetic; genetic != nil {
+		switch {
+		case genetic.PopulationSize < 2:
+			return fmt.Errorf("PopulationSize must be at least 2: %w", ConfigurationInvalid)
+		case genetic.EliteCount < 0 || genetic.EliteCount >= genetic.PopulationSize:
+			return fmt.Errorf("EliteCount must be from 0 to less than PopulationSize: %w", ConfigurationInvalid)
+		case genetic.TournamentSize < 1:
+			return fmt.Errorf("TournamentSize must be at least 1: %w", ConfigurationInvalid)
+		case genetic.CrossoverRate < 0 || genetic.CrossoverRate > 1 || genetic.MutationRate < 0 || genetic.MutationRate > 1:
+			return fmt.Errorf("CrossoverRate and MutationRate must be from 0 to 1: %w", ConfigurationInvalid)
+		}
+	}
+
+	for _, instrument := range optimization.Instruments {
+		if !slices.Contains(cfg.Keys(), instrument) {
+			return fmt.Errorf("%s is not a configured instrument: %w", instrument, ConfigurationInvalid)
+		}
+	}
+	if optimization.Workers <= 0 {
+		return fmt.Errorf("Workers must be greater than 0: %w", ConfigurationInvalid)
+	}
+
+	if walkForward := optimization.WalkForward; walkForward != nil {
+		if walkForward.Mode != utils.WalkForwardMode.ROLLING && walkForward.Mode != utils.WalkForwardMode.ANCHORED {
+			return fmt.Errorf("got WalkForward Mode %q: %w", walkForward.Mode, ConfigurationInvalid)
+		}
+		if walkForward.InSampleDays <= 0 || walkForward.OutOfSampleDays <= 0 {
+			return fmt.Errorf("InSampleDays and OutOfSampleDays must be greater than 0: %w", ConfigurationInvalid)
+		}
+	}
+
+	return nil
+}
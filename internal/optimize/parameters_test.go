@@ -0,0 +1,107 @@
+package optimize
+
+import (
+	"testing"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDomain tests lists are used as is and ranges include both ends without drifting
+func TestDomain(t *testing.T) {
+	require.Equal(t, []float64{3, 1}, Domain(&utils.ParameterRange{Values: []float64{3, 1}, From: 5, To: 6, Step: 1}))
+	require.Equal(t, []float64{1, 1.1, 1.2, 1.3}, Domain(&utils.ParameterRange{From: 1, To: 1.3, Step: 0.1}))
+	require.Equal(t, []float64{10, 30}, Domain(&utils.ParameterRange{From: 10, To: 40, Step: 20}))
+	require.Empty(t, Domain(&utils.ParameterRange{From: 2, To: 1, Step: 1}))
+	require.Empty(t, Domain(&utils.ParameterRange{From: 1, To: 2}))
+}
+
+// TestGrid tests every combination is made with the last parameter changing fastest
+func TestGrid(t *testing.T) {
+	grid := Grid(map[string]*utils.ParameterRange{
+		"SmallSMALookbackAmount": {Values: []float64{20, 50}},
+		"MinimumRR":              {From: 1, To: 3, Step: 1},
+	})
+
+	require.Len(t, grid, 6)
+	require.Equal(t, Parameters{"MinimumRR": 1, "SmallSMALookbackAmount": 20}, grid[0])
+	require.Equal(t, Parameters{"MinimumRR": 1, "SmallSMALookbackAmount": 50}, grid[1])
+	require.Equal(t, Parameters{"MinimumRR": 3, "SmallSMALookbackAmount": 50}, grid[5])
+	require.Equal(t, "MinimumRR=3,SmallSMALookbackAmount=50", grid[5].Key())
+}
+
+// TestApply tests parameters are set on integer and float fields and invalid parameters are rejected
+func TestApply(t *testing.T) {
+	cfg := &utils.InstrumentConfiguration{}
+	require.NoError(t, Parameters{"MinimumRR": 2.5, "StochasticKPeriods": 9}.Apply(cfg))
+	require.Equal(t, 2.5, cfg.MinimumRR)
+	require.Equal(t, 9, cfg.StochasticKPeriods)
+
+	require.ErrorIs(t, Parameters{"StochasticKPeriods": 9.5}.Apply(cfg), ConfigurationInvalid)
+	require.ErrorIs(t, Parameters{"Unknown": 1}.Apply(cfg), ConfigurationInvalid)
+	require.ErrorIs(t, Parameters{"HoldOvernight": 1}.Apply(cfg), ConfigurationInvalid)
+}
+
+// TestValidate tests an invalid optimization configuration is rejected
+func TestValidate(t *testing.T) {
+	valid := func() *utils.Configuration {
+		return &utils.Configuration{
+			Instruments: map[string]*utils.InstrumentConfiguration{"ES": {}},
+			Optimization: &utils.OptimizationConfiguration{
+				Parameters: map[string]*utils.ParameterRange{"MinimumRR": {From: 1, To: 2, Step: 0.5}},
+				Objective:  utils.Objective.SHARPE,
+				Workers:    2,
+				Method:     utils.SearchMethod.GRID,
+			},
+		}
+	}
+	require.NoError(t, Validate(valid()))
+
+	require.ErrorIs(t, Validate(&utils.Configuration{}), ConfigurationInvalid)
+
+	// A random search can draw from a continuous range within a budget
+	random := valid()
+	random.Optimization.Method = utils.SearchMethod.RANDOM
+	random.Optimization.MaxEvaluations = 10
+	random.Optimization.Parameters["StopSizeAddition"] = &utils.ParameterRange{From: 1.5, To: 8}
+	require.NoError(t, Validate(random))
+
+	for _, invalidate := range []func(cfg *utils.Configuration){
+		func(cfg *utils.Configuration) { cfg.Optimization.Objective = "PROFIT" },
+		func(cfg *utils.Configuration) { cfg.Optimization.Parameters = nil },
+		func(cfg *utils.Configuration) { cfg.Optimization.Parameters["MinimumRR"].Step = 0 },
+		func(cfg *utils.Configuration) {
+			cfg.Optimization.Parameters["SmallSMALookbackAmount"] = &utils.ParameterRange{Values: []float64{10.5}}
+		},
+		func(cfg *utils.Configuration) { cfg.Optimization.Instruments = []string{"NQ"} },
+		func(cfg *utils.Configuration) { cfg.Optimization.Workers = 0 },
+		func(cfg *utils.Configuration) { cfg.Optimization.Method = "ANNEALING" },
+		func(cfg *utils.Configuration) { cfg.Optimization.Heatmaps = [][]string{{"MinimumRR", "MinimumRR"}} },
+		func(cfg *utils.Configuration) {
+			cfg.Optimization.Heatmaps = [][]string{{"MinimumRR", "StopSizeAddition"}}
+		},
+		func(cfg *utils.Configuration) { cfg.Optimization.MaxMinutes = -1 },
+		func(cfg *utils.Configuration) { cfg.Optimization.Method = utils.SearchMethod.RANDOM },
+		func(cfg *utils.Configuration) {
+			cfg.Optimization.Method = utils.SearchMethod.RANDOM
+			cfg.Optimization.MaxEvaluations = 10
+			cfg.Optimization.Parameters["StopSizeAddition"] = &utils.ParameterRange{From: 1.2, To: 1.8}
+		},
+		func(cfg *utils.Configuration) {
+			cfg.Optimization.Genetic = &utils.GeneticConfiguration{PopulationSize: 10, EliteCount: 10, TournamentSize: 3}
+		},
+		func(cfg *utils.Configuration) {
+			cfg.Optimization.Genetic = &utils.GeneticConfiguration{PopulationSize: 10, TournamentSize: 3, MutationRate: 1.5}
+		},
+		func(cfg *utils.Configuration) {
+			cfg.Optimization.WalkForward = &utils.WalkForwardConfiguration{Mode: "EXPANDING", InSampleDays: 60, OutOfSampleDays: 20}
+		},
+		func(cfg *utils.Configuration) {
+			cfg.Optimization.WalkForward = &utils.WalkForwardConfiguration{Mode: utils.WalkForwardMode.ROLLING, InSampleDays: 60}
+		},
+	} {
+		cfg := valid()
+		invalidate(cfg)
+		require.ErrorIs(t, Validate(cfg), ConfigurationInvalid)
+	}
+}
@@ -0,0 +1,169 @@
+package optimize
+
+import (
+	"math"
+	"slices"
+	"sort"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/utils"
+)
+
+// heatmapBins is the amount of bins a continuous range is split into on a heatmap axis.
+const heatmapBins = 10
+
+// Robustness is how the neighbours of an evaluation performed, the combinations one step away on a single parameter.
+// A combination on a plateau keeps its score while a spike among worse neighbours is pulled down.
+type Robustness struct {
+	// Neighbours is the amount of neighbours that were evaluated.
+	Neighbours int
+
+	// NeighbourAverage is the average score of the neighbours, 0 without any.
+	NeighbourAverage float64
+
+	// NeighbourWorst is the lowest score of the neighbours, 0 without any.
+	NeighbourWorst float64
+
+	// Score is the average score of the evaluation and its neighbours.
+	Score float64
+}
+
+// axis is the values of one parameter in order, either every value of a list or Step, or bins of a continuous range.
+type axis struct {
+	// values is every value of the axis in ascending order, the centre of each bin for a continuous range.
+	values []float64
+
+	// continuous is whether the values are bins of a continuous range.
+	continuous bool
+
+	// from is the lowest value of a continuous range.
+	from float64
+
+	// width is the width of each bin of a continuous range.
+	width float64
+}
+
+// newAxis returns the axis of a parameter range, splitting a continuous range into heatmapBins bins, or a bin for
+// every whole value of an integer field if there are fewer.
+func newAxis(name string, r *utils.ParameterRange) axis {
+	if values := Domain(r); len(values) > 0 && (len(r.Values) > 0 || r.Step > 0) {
+		sorted := slices.Clone(values)
+		sort.Float64s(sorted)
+
+		return axis{values: slices.Compact(sorted)}
+	}
+
+	a := axis{continuous: true, from: r.From, width: (r.To - r.From) / heatmapBins}
+	if isInteger(name) && math.Floor(r.To)-math.Ceil(r.From) < heatmapBins {
+		a.from, a.width = math.Ceil(r.From)-0.5, 1
+		for value := math.Ceil(r.From); value <= r.To; value++ {
+			a.values = append(a.values, value)
+		}
+		return a
+	}
+	for bin := 0; bin < heatmapBins; bin++ {
+		// Round away the error of adding up floats so the centres read well in a CSV
+		a.values = append(a.values, math.Round((r.From+(float64(bin)+0.5)*a.width)*1e9)/1e9)
+	}
+
+	return a
+}
+
+// index returns the position of a value on the axis, or -1 if it is not one of the values of a list or Step.
+func (a axis) index(value float64) int {
+	if a.continuous {
+		if a.width == 0 {
+			return 0
+		}
+		return max(0, min(len(a.values)-1, int(math.Floor((value-a.from)/a.width))))
+	}
+
+	for index, existing := range a.values {
+		if math.Abs(existing-value) < 1e-9 {
+			return index
+		}
+	}
+
+	return -1
+}
+
+// ScoreRobustness sets the Robustness of every evaluation from the evaluations of its neighbours. A neighbour has
+// the next or previous value of one parameter with a list of Values or a Step, and the same value of every other.
+// Continuous ranges have no steps so are not stepped along, and neighbours that were not evaluated are left out.
+func ScoreRobustness(evaluations []*Evaluation, ranges map[string]*utils.ParameterRange) {
+	byKey := make(map[string]*Evaluation, len(evaluations))
+	for _, evaluation := range evaluations {
+		byKey[evaluation.Parameters.Key()] = evaluation
+	}
+
+	axes := make(map[string]axis, len(ranges))
+	for name, parameterRange := range ranges {
+		axes[name] = newAxis(name, parameterRange)
+	}
+
+	for _, evaluation := range evaluations {
+		robustness := &Robustness{Score: evaluation.Score, NeighbourWorst: math.Inf(1)}
+		total := 0.0
+		for _, name := range evaluation.Parameters.Names() {
+			a, ok := axes[name]
+			if !ok || a.continuous {
+				continue
+			}
+			index := a.index(evaluation.Parameters[name])
+			if index < 0 {
+				continue
+			}
+
+			for _, step := range []int{index - 1, index + 1} {
+				if step < 0 || step >= len(a.values) {
+					continue
+				}
+				neighbour := make(Parameters, len(evaluation.Parameters))
+				for existing, value := range evaluation.Parameters {
+					neighbour[existing] = value
+				}
+				neighbour[name] = a.values[step]
+
+				if evaluated := byKey[neighbour.Key()]; evaluated != nil {
+					robustness.Neighbours++
+					total += evaluated.Score
+					robustness.NeighbourWorst = min(robustness.NeighbourWorst, evaluated.Score)
+				}
+			}
+		}
+
+		if robustness.Neighbours > 0 {
+			robustness.NeighbourAverage = total / float64(robustness.Neighbours)
+			robustness.Score = (evaluation.Score + total) / float64(robustness.Neighbours+1)
+		} else {
+			robustness.NeighbourWorst = 0
+		}
+		evaluation.Robustness = robustness
+	}
+}
+
+// RankRobust sorts evaluations from the best to the worst robustness score, with the same rules as Rank. Evaluations
+// without any evaluated neighbours have nothing to show they are not a lone peak, so rank below every evaluation with
+// neighbours. When no evaluation has neighbours they are ranked by their score.
+func RankRobust(evaluations []*Evaluation, minimumTrades int) {
+	tested := func(evaluation *Evaluation) bool {
+		return evaluation.Robustness != nil && evaluation.Robustness.Neighbours > 0
+	}
+	robustScore := func(evaluation *Evaluation) float64 {
+		if evaluation.Robustness == nil {
+			return evaluation.Score
+		}
+		return evaluation.Robustness.Score
+	}
+
+	sort.SliceStable(evaluations, func(i, j int) bool {
+		enoughI := evaluations[i].Summary.Trades >= minimumTrades
+		enoughJ := evaluations[j].Summary.Trades >= minimumTrades
+		if enoughI != enoughJ {
+			return enoughI
+		}
+		if testedI, testedJ := tested(evaluations[i]), tested(evaluations[j]); testedI != testedJ {
+			return testedI
+		}
+		return robustScore(evaluations[i]) > robustScore(evaluations[j])
+	})
+}
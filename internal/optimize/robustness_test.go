@@ -0,0 +1,118 @@
+package optimize
+
+import (
+	"testing"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/stats"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// mockGrid returns a 3 by 3 grid of MinimumRR and StopSizeAddition with a spike at the corner and a plateau in the
+// middle, and the ranges it was evaluated over.
+func mockGrid() ([]*Evaluation, map[string]*utils.ParameterRange) {
+	scores := [][]float64{
+		{9, 1, 1},
+		{1, 5, 5},
+		{1, 5, 5},
+	}
+
+	var evaluations []*Evaluation
+	for row, values := range scores {
+		for column, score := range values {
+			evaluations = append(evaluations, &Evaluation{
+				Parameters: Parameters{"MinimumRR": float64(row + 1), "StopSizeAddition": float64(column + 2)},
+				Summary:    &stats.Summary{Trades: 20},
+				Score:      score,
+			})
+		}
+	}
+
+	return evaluations, map[string]*utils.ParameterRange{
+		"MinimumRR":        {From: 1, To: 3, Step: 1},
+		"StopSizeAddition": {Values: []float64{4, 3, 2}},
+	}
+}
+
+// find returns the evaluation with the given MinimumRR and StopSizeAddition.
+func find(evaluations []*Evaluation, minimumRR, stopSizeAddition float64) *Evaluation {
+	for _, evaluation := range evaluations {
+		if evaluation.Parameters["MinimumRR"] == minimumRR && evaluation.Parameters["StopSizeAddition"] == stopSizeAddition {
+			return evaluation
+		}
+	}
+
+	return nil
+}
+
+// TestScoreRobustness tests neighbours are one step away on a single parameter and a spike scores below a plateau
+func TestScoreRobustness(t *testing.T) {
+	evaluations, ranges := mockGrid()
+	ScoreRobustness(evaluations, ranges)
+
+	spike := find(evaluations, 1, 2)
+	require.Equal(t, &Robustness{Neighbours: 2, NeighbourAverage: 1, NeighbourWorst: 1, Score: 11.0 / 3}, spike.Robustness)
+
+	centre := find(evaluations, 2, 3)
+	require.Equal(t, 4, centre.Robustness.Neighbours)
+	require.Equal(t, 1.0, centre.Robustness.NeighbourWorst)
+	require.Equal(t, 3.0, centre.Robustness.NeighbourAverage)
+
+	plateau := find(evaluations, 3, 4)
+	require.Equal(t, &Robustness{Neighbours: 2, NeighbourAverage: 5, NeighbourWorst: 5, Score: 5}, plateau.Robustness)
+
+	// Neighbours that were not evaluated are left out
+	ScoreRobustness([]*Evaluation{spike}, ranges)
+	require.Equal(t, &Robustness{Score: 9}, spike.Robustness)
+}
+
+// TestScoreRobustnessContinuous tests a continuous range is not stepped along
+func TestScoreRobustnessContinuous(t *testing.T) {
+	evaluations := []*Evaluation{
+		{Parameters: Parameters{"MinimumRR": 1.5, "StopSizeAddition": 2}, Summary: &stats.Summary{}, Score: 4},
+		{Parameters: Parameters{"MinimumRR": 1.5, "StopSizeAddition": 3}, Summary: &stats.Summary{}, Score: 2},
+		{Parameters: Parameters{"MinimumRR": 1.6, "StopSizeAddition": 2}, Summary: &stats.Summary{}, Score: 8},
+	}
+	ScoreRobustness(evaluations, map[string]*utils.ParameterRange{
+		"MinimumRR":        {From: 1, To: 3},
+		"StopSizeAddition": {From: 2, To: 3, Step: 1},
+	})
+
+	require.Equal(t, &Robustness{Neighbours: 1, NeighbourAverage: 2, NeighbourWorst: 2, Score: 3}, evaluations[0].Robustness)
+	require.Equal(t, &Robustness{Score: 8}, evaluations[2].Robustness)
+}
+
+// TestRankRobust tests combinations are ranked by their robustness score and those with too few trades last
+func TestRankRobust(t *testing.T) {
+	evaluations, ranges := mockGrid()
+	find(evaluations, 2, 3).Summary = &stats.Summary{Trades: 2}
+	ScoreRobustness(evaluations, ranges)
+	RankRobust(evaluations, 10)
+
+	require.Equal(t, Parameters{"MinimumRR": 3, "StopSizeAddition": 4}, evaluations[0].Parameters)
+	require.Equal(t, Parameters{"MinimumRR": 2, "StopSizeAddition": 3}, evaluations[len(evaluations)-1].Parameters)
+
+	Rank(evaluations, 10)
+	require.Equal(t, Parameters{"MinimumRR": 1, "StopSizeAddition": 2}, evaluations[0].Parameters)
+}
+
+// TestRankRobustIsolated tests a spike without any evaluated neighbours ranks below a plateau however high it scores
+func TestRankRobustIsolated(t *testing.T) {
+	_, ranges := mockGrid()
+	evaluations := []*Evaluation{
+		{Parameters: Parameters{"MinimumRR": 1, "StopSizeAddition": 2}, Summary: &stats.Summary{Trades: 20}, Score: 50},
+		{Parameters: Parameters{"MinimumRR": 2, "StopSizeAddition": 4}, Summary: &stats.Summary{Trades: 20}, Score: 5},
+		{Parameters: Parameters{"MinimumRR": 3, "StopSizeAddition": 4}, Summary: &stats.Summary{Trades: 20}, Score: 5},
+	}
+	ScoreRobustness(evaluations, ranges)
+	require.Equal(t, &Robustness{Score: 50}, evaluations[0].Robustness)
+	RankRobust(evaluations, 0)
+
+	require.Equal(t, Parameters{"MinimumRR": 1, "StopSizeAddition": 2}, evaluations[len(evaluations)-1].Parameters)
+	require.Equal(t, 5.0, evaluations[0].Robustness.Score)
+
+	// Without any neighbours at all the evaluations are ranked by their score
+	ScoreRobustness(evaluations, map[string]*utils.ParameterRange{"MinimumRR": {From: 1, To: 3}})
+	RankRobust(evaluations, 0)
+	require.Equal(t, Parameters{"MinimumRR": 1, "StopSizeAddition": 2}, evaluations[0].Parameters)
+}
@@ -0,0 +1,162 @@
+package optimize
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"slices"
+	"sort"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/utils"
+)
+
+// dimension is the values one parameter can take, either a list of values or any value from one bound to another.
+type dimension struct {
+	// values is every value of a parameter range with a list of Values or a Step, nil for a continuous range.
+	values []float64
+
+	// from is the lowest value of a continuous range.
+	from float64
+
+	// to is the highest value of a continuous range.
+	to float64
+
+	// integer is whether the parameter is an integer field, so a continuous range only takes whole values.
+	integer bool
+}
+
+// Space is the values every parameter of a random or genetic search can take.
+type Space struct {
+	// names is the name of every parameter in alphabetical order, so the same seed always draws the same values.
+	names []string
+
+	// dimensions is the values of each parameter by name.
+	dimensions map[string]dimension
+}
+
+// isInteger returns whether an InstrumentConfiguration field is an integer.
+func isInteger(name string) bool {
+	field, ok := reflect.TypeOf(utils.InstrumentConfiguration{}).FieldByName(name)
+
+	return ok && field.Type.Kind() == reflect.Int
+}
+
+// NewSpace creates the Space of parameter ranges, where a range without Values or a Step is continuous. It returns a
+// ConfigurationInvalid error if a range has no values.
+func NewSpace(ranges map[string]*utils.ParameterRange) (*Space, error) {
+	space := &Space{dimensions: make(map[string]dimension, len(ranges))}
+	for name, parameterRange := range ranges {
+		space.names = append(space.names, name)
+
+		d := dimension{values: Domain(parameterRange), integer: isInteger(name)}
+		if len(parameterRange.Values) == 0 && parameterRange.Step == 0 {
+			d.values, d.from, d.to = nil, parameterRange.From, parameterRange.To
+			if d.integer {
+				d.from, d.to = math.Ceil(d.from), math.Floor(d.to)
+			}
+			if d.to < d.from {
+				return nil, fmt.Errorf("%s has no values from %g to %g: %w", name, parameterRange.From, parameterRange.To, ConfigurationInvalid)
+			}
+		} else if len(d.values) == 0 {
+			return nil, fmt.Errorf("%s must have Values, or a Step greater than 0 from From to To: %w", name, ConfigurationInvalid)
+		}
+		space.dimensions[name] = d
+	}
+	sort.Strings(space.names)
+
+	return space, nil
+}
+
+// Names returns the name of every parameter in alphabetical order.
+func (s *Space) Names() []string {
+	return slices.Clone(s.names)
+}
+
+// Size returns the amount of combinations in the space, or 0 if a range is continuous.
+func (s *Space) Size() int {
+	size := 1
+	for _, d := range s.dimensions {
+		if d.values == nil {
+			return 0
+		}
+		size *= len(d.values)
+	}
+
+	return size
+}
+
+// sample returns a random value of the dimension.
+func (d dimension) sample(random *rand.Rand) float64 {
+	switch {
+	case d.values != nil:
+		return d.values[random.Intn(len(d.values))]
+	case d.integer:
+		return d.from + float64(random.Intn(int(d.to-d.from)+1))
+	default:
+		return d.from + random.Float64()*(d.to-d.from)
+	}
+}
+
+// mutate returns a value of the dimension near value, the next or previous value of a list, or a value up to a
+// tenth of the range away for a continuous range.
+func (d dimension) mutate(random *rand.Rand, value float64) float64 {
+	if d.values != nil {
+		index := 0
+		for candidate, existing := range d.values {
+			if math.Abs(existing-value) < math.Abs(d.values[index]-value) {
+				index = candidate
+			}
+		}
+		if random.Intn(2) == 0 {
+			index--
+		} else {
+			index++
+		}
+
+		return d.values[max(0, min(len(d.values)-1, index))]
+	}
+
+	mutated := max(d.from, min(d.to, value+random.NormFloat64()*(d.to-d.from)/10))
+	if d.integer {
+		mutated = math.Round(mutated)
+	}
+
+	return mutated
+}
+
+// Sample returns a combination with a random value for every parameter.
+func (s *Space) Sample(random *rand.Rand) Parameters {
+	parameters := make(Parameters, len(s.names))
+	for _, name := range s.names {
+		parameters[name] = s.dimensions[name].sample(random)
+	}
+
+	return parameters
+}
+
+// Mutate returns a copy of a combination where every parameter has the given chance of moving to a nearby value.
+func (s *Space) Mutate(random *rand.Rand, parameters Parameters, rate float64) Parameters {
+	mutated := make(Parameters, len(s.names))
+	for _, name := range s.names {
+		mutated[name] = parameters[name]
+		if random.Float64() < rate {
+			mutated[name] = s.dimensions[name].mutate(random, parameters[name])
+		}
+	}
+
+	return mutated
+}
+
+// Crossover returns a combination with the value of each parameter taken from either parent at random.
+func (s *Space) Crossover(random *rand.Rand, first, second Parameters) Parameters {
+	child := make(Parameters, len(s.names))
+	for _, name := range s.names {
+		child[name] = first[name]
+		if random.Intn(2) == 0 {
+			child[name] = second[name]
+		}
+	}
+
+	return child
+}
@@ -0,0 +1,94 @@
+package optimize
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// mockSpace returns a space with a list, a continuous integer range and a continuous float range.
+func mockSpace(t *testing.T) *Space {
+	space, err := NewSpace(map[string]*utils.ParameterRange{
+		"LargeSMALookbackAmount": {Values: []float64{50, 100, 200}},
+		"SmallSMALookbackAmount": {From: 4.5, To: 30},
+		"MinimumRR":              {From: 1, To: 3},
+	})
+	require.NoError(t, err)
+
+	return space
+}
+
+// TestNewSpace tests ranges without a Step are continuous and ranges without values are rejected
+func TestNewSpace(t *testing.T) {
+	space := mockSpace(t)
+	require.Equal(t, []string{"LargeSMALookbackAmount", "MinimumRR", "SmallSMALookbackAmount"}, space.Names())
+	require.Equal(t, dimension{from: 5, to: 30, integer: true}, space.dimensions["SmallSMALookbackAmount"])
+	require.Zero(t, space.Size())
+
+	finite, err := NewSpace(map[string]*utils.ParameterRange{
+		"LargeSMALookbackAmount": {Values: []float64{50, 100, 200}},
+		"MinimumRR":              {From: 1, To: 2, Step: 0.5},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 9, finite.Size())
+
+	_, err = NewSpace(map[string]*utils.ParameterRange{"SmallSMALookbackAmount": {From: 4.2, To: 4.8}})
+	require.ErrorIs(t, err, ConfigurationInvalid)
+	_, err = NewSpace(map[string]*utils.ParameterRange{"MinimumRR": {From: 2, To: 1, Step: 1}})
+	require.ErrorIs(t, err, ConfigurationInvalid)
+}
+
+// TestSample tests every value is drawn from its range and the same seed draws the same values
+func TestSample(t *testing.T) {
+	space := mockSpace(t)
+	random, again := rand.New(rand.NewSource(1)), rand.New(rand.NewSource(1))
+	for draw := 0; draw < 100; draw++ {
+		parameters := space.Sample(random)
+		require.Equal(t, parameters, space.Sample(again))
+
+		require.Contains(t, []float64{50, 100, 200}, parameters["LargeSMALookbackAmount"])
+		require.Equal(t, math.Trunc(parameters["SmallSMALookbackAmount"]), parameters["SmallSMALookbackAmount"])
+		require.GreaterOrEqual(t, parameters["SmallSMALookbackAmount"], 5.0)
+		require.LessOrEqual(t, parameters["SmallSMALookbackAmount"], 30.0)
+		require.GreaterOrEqual(t, parameters["MinimumRR"], 1.0)
+		require.LessOrEqual(t, parameters["MinimumRR"], 3.0)
+	}
+}
+
+// TestMutate tests mutation moves to a neighbouring value and stays in range
+func TestMutate(t *testing.T) {
+	space := mockSpace(t)
+	random := rand.New(rand.NewSource(1))
+	parameters := Parameters{"LargeSMALookbackAmount": 50, "SmallSMALookbackAmount": 30, "MinimumRR": 2}
+
+	require.Equal(t, parameters, space.Mutate(random, parameters, 0))
+	for draw := 0; draw < 100; draw++ {
+		mutated := space.Mutate(random, parameters, 1)
+		require.Contains(t, []float64{50, 100}, mutated["LargeSMALookbackAmount"])
+		require.Equal(t, math.Trunc(mutated["SmallSMALookbackAmount"]), mutated["SmallSMALookbackAmount"])
+		require.LessOrEqual(t, mutated["SmallSMALookbackAmount"], 30.0)
+		require.GreaterOrEqual(t, mutated["MinimumRR"], 1.0)
+		require.LessOrEqual(t, mutated["MinimumRR"], 3.0)
+	}
+
+	// The original combination is not changed
+	require.Equal(t, 50.0, parameters["LargeSMALookbackAmount"])
+}
+
+// TestCrossover tests a child only has values from its parents
+func TestCrossover(t *testing.T) {
+	space := mockSpace(t)
+	random := rand.New(rand.NewSource(1))
+	first := Parameters{"LargeSMALookbackAmount": 50, "SmallSMALookbackAmount": 5, "MinimumRR": 1}
+	second := Parameters{"LargeSMALookbackAmount": 200, "SmallSMALookbackAmount": 30, "MinimumRR": 3}
+
+	for draw := 0; draw < 20; draw++ {
+		child := space.Crossover(random, first, second)
+		for _, name := range space.names {
+			require.Contains(t, []float64{first[name], second[name]}, child[name])
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package optimize
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/tradeLog"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/utils"
+	"github.com/rs/zerolog/log"
+)
+
+// Window is the in-sample and out-of-sample time ranges of one segment of a walk-forward analysis.
+// The in-sample range ends where the out-of-sample range starts.
+type Window struct {
+	// InSampleStart is the start of the range the parameters are optimised on.
+	InSampleStart time.Time
+
+	// OutOfSampleStart is the end of the in-sample range and the start of the range the best parameters are traded on.
+	OutOfSampleStart time.Time
+
+	// OutOfSampleEnd is the end of the out-of-sample range, exclusive so windows never share a candle.
+	OutOfSampleEnd time.Time
+}
+
+// Segment is the result of optimising one window and trading the best parameters out-of-sample.
+type Segment struct {
+	Window
+
+	// InSample is the best evaluation of the in-sample range.
+	InSample *Evaluation
+
+	// OutOfSample is the evaluation of the best parameters on the out-of-sample range.
+	OutOfSample *Evaluation
+
+	// Efficiency is the R per day out-of-sample divided by the R per day in-sample, 0 if the in-sample lost.
+	Efficiency float64
+}
+
+// WalkForwardResult is every segment of a walk-forward analysis and their out-of-sample trades stitched together.
+type WalkForwardResult struct {
+	// Segments is every segment that could be evaluated in time order.
+	Segments []*Segment
+
+	// Trades is the out-of-sample trades of every segment in time order.
+	Trades *tradeLog.Log
+
+	// Efficiency is the walk-forward efficiency, the R per day across every out-of-sample range divided by the R per
+	// day across every in-sample range, 0 if the in-sample ranges lost.
+	Efficiency float64
+}
+
+// Windows splits the time from start to end into walk-forward windows. The first in-sample range starts at start,
+// and each out-of-sample range follows the last, the final one is cut short at end. A rolling in-sample range is
+// always InSampleDays long and an anchored one always starts at start.
+func Windows(start, end time.Time, cfg *utils.WalkForwardConfiguration) ([]Window, error) {
+	if cfg.InSampleDays <= 0 || cfg.OutOfSampleDays <= 0 {
+		return nil, fmt.Errorf("InSampleDays and OutOfSampleDays must be greater than 0: %w", ConfigurationInvalid)
+	}
+
+	var windows []Window
+	for outOfSampleStart := start.AddDate(0, 0, cfg.InSampleDays); outOfSampleStart.Before(end); {
+		outOfSampleEnd := outOfSampleStart.AddDate(0, 0, cfg.OutOfSampleDays)
+		if outOfSampleEnd.After(end) {
+			outOfSampleEnd = end
+		}
+
+		inSampleStart := start
+		if cfg.Mode != utils.WalkForwardMode.ANCHORED {
+			inSampleStart = outOfSampleStart.AddDate(0, 0, -cfg.InSampleDays)
+		}
+
+		windows = append(windows, Window{inSampleStart, outOfSampleStart, outOfSampleEnd})
+		outOfSampleStart = outOfSampleEnd
+	}
+
+	if len(windows) == 0 {
+		return nil, fmt.Errorf(
+			"the backtest from %v to %v is shorter than InSampleDays: %w",
+			start,
+			end,
+			ConfigurationInvalid,
+		)
+	}
+
+	return windows, nil
+}
+
+// rPerDay returns the net profit in R of an evaluation divided by the days from start to end.
+func rPerDay(evaluation *Evaluation, start, end time.Time) float64 {
+	return evaluation.Summary.NetProfitR / end.Sub(start).Hours() * 24
+}
+
+// WalkForward searches every combination on the in-sample range of each window, ranked as Rank does, and evaluates
+// the best on the out-of-sample range. Each out-of-sample range trades the equity the last one ended with, so the
+// stitched trades are sized as one account. Windows where nothing could be evaluated are logged and left out.
+func WalkForward(
+	evaluator *Evaluator,
+	combinations []Parameters,
+	windows []Window,
+	workers int,
+	minimumTrades int,
+) *WalkForwardResult {
+	result := new(WalkForwardResult)
+
+	var (
+		logs                          []*tradeLog.Log
+		balance                       = evaluator.configuration.StartingBalance
+		inSampleR, inSampleDays       float64
+		outOfSampleR, outOfSampleDays float64
+	)
+	for index, window := range windows {
+		log.Info().Msgf(
+			"Walk-forward segment %d of %d: optimising %s to %s, trading %s to %s",
+			index+1,
+			len(windows),
+			window.InSampleStart.Format(time.DateOnly),
+			window.OutOfSampleStart.Format(time.DateOnly),
+			window.OutOfSampleStart.Format(time.DateOnly),
+			window.OutOfSampleEnd.Format(time.DateOnly),
+		)
+
+		// The candle on the boundary belongs to the next range
+		inSampleEnd, outOfSampleEnd := window.OutOfSampleStart.Add(-time.Nanosecond), window.OutOfSampleEnd.Add(-time.Nanosecond)
+		evaluations := Search(evaluator, combinations, window.InSampleStart, inSampleEnd, workers)
+		if len(evaluations) == 0 {
+			log.Error().Msgf("No combination could be evaluated in segment %d", index+1)
+			continue
+		}
+		Rank(evaluations, minimumTrades)
+		best := evaluations[0]
+
+		outOfSample, err := evaluator.EvaluateWithBalance(best.Parameters, window.OutOfSampleStart, outOfSampleEnd, balance)
+		if err != nil {
+			log.Error().Str("parameters", best.Parameters.Key()).Msg(err.Error())
+			continue
+		}
+
+		segment := &Segment{Window: window, InSample: best, OutOfSample: outOfSample}
+		if inSample := rPerDay(best, window.InSampleStart, window.OutOfSampleStart); inSample > 0 {
+			segment.Efficiency = rPerDay(outOfSample, window.OutOfSampleStart, window.OutOfSampleEnd) / inSample
+		}
+		result.Segments = append(result.Segments, segment)
+		logs = append(logs, outOfSample.Trades)
+		balance = outOfSample.Summary.EndingBalance
+
+		inSampleR += best.Summary.NetProfitR
+		inSampleDays += window.OutOfSampleStart.Sub(window.InSampleStart).Hours() / 24
+		outOfSampleR += outOfSample.Summary.NetProfitR
+		outOfSampleDays += window.OutOfSampleEnd.Sub(window.OutOfSampleStart).Hours() / 24
+	}
+
+	result.Trades = tradeLog.Merge(logs...)
+	if inSampleR > 0 {
+		result.Efficiency = (outOfSampleR / outOfSampleDays) / (inSampleR / inSampleDays)
+	}
+
+	return result
+}
+
+// WriteCSV writes every segment to a CSV on disk, creating or overwriting it, with its windows, best parameters,
+// and the score, trades and net R both in-sample and out-of-sample.
+func (r *WalkForwardResult) WriteCSV(filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write([]string{
+		"Segment",
+		"InSampleStart",
+		"OutOfSampleStart",
+		"OutOfSampleEnd",
+		"Parameters",
+		"InSampleScore",
+		"InSampleTrades",
+		"InSampleNetProfitR",
+		"OutOfSampleScore",
+		"OutOfSampleTrades",
+		"OutOfSampleNetProfitR",
+		"Efficiency",
+	}); err != nil {
+		return err
+	}
+
+	format := func(value float64) string {
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	for index, segment := range r.Segments {
+		if err := writer.Write([]string{
+			strconv.Itoa(index + 1),
+			segment.InSampleStart.Format(time.DateOnly),
+			segment.OutOfSampleStart.Format(time.DateOnly),
+			segment.OutOfSampleEnd.Format(time.DateOnly),
+			segment.InSample.Parameters.Key(),
+			format(segment.InSample.Score),
+			strconv.Itoa(segment.InSample.Summary.Trades),
+			format(segment.InSample.Summary.NetProfitR),
+			format(segment.OutOfSample.Score),
+			strconv.Itoa(segment.OutOfSample.Summary.Trades),
+			format(segment.OutOfSample.Summary.NetProfitR),
+			format(segment.Efficiency),
+		}); err != nil {
+			return fmt.Errorf("error writing walk-forward results: %w", err)
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
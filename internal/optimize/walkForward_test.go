@@ -0,0 +1,111 @@
+package optimize
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/stats"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// mockFundedEvaluator returns the mock Evaluator with a balance large enough to size at least one contract on every
+// trade, so each window has trades to stitch.
+func mockFundedEvaluator(t *testing.T) *Evaluator {
+	evaluator := mockEvaluator(t)
+	evaluator.configuration.StartingBalance = 1000000
+
+	return evaluator
+}
+
+// TestWindows tests rolling and anchored windows follow each other and the last is cut short at the end
+func TestWindows(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 100)
+	day := func(days int) time.Time { return start.AddDate(0, 0, days) }
+
+	rolling, err := Windows(start, end, &utils.WalkForwardConfiguration{
+		Mode:            utils.WalkForwardMode.ROLLING,
+		InSampleDays:    60,
+		OutOfSampleDays: 15,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []Window{
+		{day(0), day(60), day(75)},
+		{day(15), day(75), day(90)},
+		{day(30), day(90), day(100)},
+	}, rolling)
+
+	anchored, err := Windows(start, end, &utils.WalkForwardConfiguration{
+		Mode:            utils.WalkForwardMode.ANCHORED,
+		InSampleDays:    60,
+		OutOfSampleDays: 20,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []Window{
+		{day(0), day(60), day(80)},
+		{day(0), day(80), day(100)},
+	}, anchored)
+
+	_, err = Windows(start, end, &utils.WalkForwardConfiguration{InSampleDays: 100, OutOfSampleDays: 10})
+	require.ErrorIs(t, err, ConfigurationInvalid)
+	_, err = Windows(start, end, &utils.WalkForwardConfiguration{InSampleDays: 10})
+	require.ErrorIs(t, err, ConfigurationInvalid)
+}
+
+// TestWalkForward tests the best in-sample parameters are traded out-of-sample and their trades are stitched together
+// as one account
+func TestWalkForward(t *testing.T) {
+	evaluator := mockFundedEvaluator(t)
+	windows, err := Windows(mockStart, mockStart.AddDate(0, 0, 5), &utils.WalkForwardConfiguration{
+		Mode:            utils.WalkForwardMode.ROLLING,
+		InSampleDays:    2,
+		OutOfSampleDays: 1,
+	})
+	require.NoError(t, err)
+	require.Len(t, windows, 3)
+
+	combinations := Grid(map[string]*utils.ParameterRange{"SmallSMALookbackAmount": {Values: []float64{10, 20}}})
+	result := WalkForward(evaluator, combinations, windows, 2, 0)
+
+	require.Len(t, result.Segments, 3)
+	trades, balance := 0, 1000000.0
+	for _, segment := range result.Segments {
+		// Each out-of-sample range trades the equity the last one ended with
+		require.Equal(t, balance, segment.OutOfSample.Summary.StartingBalance)
+		balance = segment.OutOfSample.Summary.EndingBalance
+
+		// The best in-sample combination is the one traded
+		require.Equal(t, segment.InSample.Parameters, segment.OutOfSample.Parameters)
+		for _, evaluation := range Search(evaluator, combinations, segment.InSampleStart, segment.OutOfSampleStart.Add(-time.Nanosecond), 1) {
+			require.LessOrEqual(t, evaluation.Score, segment.InSample.Score)
+		}
+
+		// Every out-of-sample trade is taken in the out-of-sample range
+		for _, row := range *segment.OutOfSample.Trades {
+			require.False(t, row.TakenAt.Before(segment.OutOfSampleStart))
+			require.True(t, row.TakenAt.Before(segment.OutOfSampleEnd))
+		}
+		trades += len(*segment.OutOfSample.Trades)
+	}
+	require.NotZero(t, trades)
+	require.Len(t, *result.Trades, trades)
+
+	filePath := filepath.Join(t.TempDir(), "walkforward.csv")
+	require.NoError(t, result.WriteCSV(filePath))
+	contents, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(contents)), "\n")
+	require.Len(t, lines, 4)
+	require.True(t, strings.HasPrefix(lines[1], "1,2023-10-16,2023-10-18,2023-10-19,SmallSMALookbackAmount="))
+}
+
+// TestRPerDay tests the efficiency compares the rate of R rather than the total
+func TestRPerDay(t *testing.T) {
+	evaluation := &Evaluation{Summary: &stats.Summary{NetProfitR: 30}}
+	require.Equal(t, 0.5, rPerDay(evaluation, mockStart, mockStart.AddDate(0, 0, 60)))
+	require.Equal(t, 2.0, rPerDay(evaluation, mockStart, mockStart.AddDate(0, 0, 15)))
+}
@@ -0,0 +1,87 @@
+package portfolio
+
+import (
+	"sort"
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/positionSizing"
+)
+
+// MarginPoint is the margin held by the account at a point in time.
+type MarginPoint struct {
+	// Time is the time of the bar the margin was recorded on.
+	Time time.Time
+
+	// Equity is the equity of the account including the unrealised profit of every open position.
+	Equity float64
+
+	// OpenPositions is the amount of positions open.
+	OpenPositions int
+
+	// InitialMargin is the initial margin of every open position in the base currency.
+	InitialMargin float64
+
+	// MaintenanceMargin is the maintenance margin of every open position in the base currency.
+	MaintenanceMargin float64
+
+	// Utilisation is the percentage of Equity held as InitialMargin.
+	Utilisation float64
+}
+
+// BuyingPower returns the amount of equity not already held as initial margin.
+func (p *MarginPoint) BuyingPower() float64 {
+	return p.Equity - p.InitialMargin
+}
+
+// marginPoint marks every open position to market at its last price and totals the margin held.
+// The unrealised profit includes commission, so equity is what the account would be left with if closed.
+func (e *Engine) marginPoint(result *Result, positions map[string]*position, at time.Time) (*MarginPoint, error) {
+	point := &MarginPoint{Time: at, Equity: result.EndingEquity, OpenPositions: len(positions)}
+
+	for _, open := range positions {
+		spec := e.specs[open.trade.Instrument]
+		fxRate, err := e.converter.Rate(spec.Currency, at)
+		if err != nil {
+			return nil, err
+		}
+
+		contracts := float64(open.trade.Contracts)
+		point.Equity += positionSizing.ProfitValue(
+			spec,
+			open.trade.Direction,
+			open.trade.Contracts,
+			open.trade.EntryPrice,
+			open.lastPrice,
+		) * fxRate
+		point.InitialMargin += contracts * spec.InitialMargin * fxRate
+		point.MaintenanceMargin += contracts * spec.MaintenanceMargin * fxRate
+	}
+
+	if point.Equity > 0 {
+		point.Utilisation = point.InitialMargin / point.Equity * 100
+	}
+
+	return point, nil
+}
+
+// liquidate closes every open position at its last price when the account is margin called.
+// The positions are closed in instrument order so the equity curve is deterministic.
+func (e *Engine) liquidate(result *Result, positions map[string]*position, at time.Time) ([]string, error) {
+	instruments := make([]string, 0, len(positions))
+	for instrument := range positions {
+		instruments = append(instruments, instrument)
+	}
+	sort.Strings(instruments)
+
+	for _, instrument := range instruments {
+		open := positions[instrument]
+		open.trade.Liquidate(open.lastPrice, at)
+		if err := e.realise(result, open); err != nil {
+			return nil, err
+		}
+		delete(positions, instrument)
+	}
+	result.MarginCalls++
+
+	return instruments, nil
+}
@@ -0,0 +1,96 @@
+package portfolio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/tradeConfig"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// mockMarginEngine is a helper to create an Engine using mockEntries where ES and NQ hold the given margins.
+func mockMarginEngine(
+	t *testing.T,
+	margin *utils.MarginConfiguration,
+	initialMargin, maintenanceMargin float64,
+	entries map[string][]time.Time,
+) *Engine {
+	configuration := mockConfiguration()
+	configuration.Margin = margin
+
+	specs := utils.ContractSpecRegistry{}
+	for _, instrument := range []string{"ES", "NQ"} {
+		spec := mockSpecs[instrument]
+		spec.InitialMargin = initialMargin
+		spec.MaintenanceMargin = maintenanceMargin
+		specs[instrument] = spec
+	}
+
+	engine, err := NewEngine(configuration, specs, mockConverter(t))
+	require.NoError(t, err)
+	engine.SetEntryFunc(mockEntries(entries))
+	return engine
+}
+
+// TestRunBuyingPower tests that entries are skipped when their initial margin is more than the buying power left
+func TestRunBuyingPower(t *testing.T) {
+	entries := map[string][]time.Time{
+		"ES": {mockBarTime(mockDay, 0)},
+		// NQ is entered while ES holds 6000 of the 10000 equity
+		"NQ": {mockBarTime(mockDay, 1)},
+	}
+	sessions := func() []*Session {
+		return []*Session{
+			mockSession("ES", mockDay, 10, map[int]int{3: 1}),
+			mockSession("NQ", mockDay, 10, map[int]int{4: 1}),
+		}
+	}
+
+	engine := mockMarginEngine(t, &utils.MarginConfiguration{EnforceBuyingPower: true}, 6000, 5000, entries)
+	result, err := engine.Run(sessions())
+	require.NoError(t, err)
+	require.Len(t, result.Trades, 1)
+	require.Len(t, result.Suppressed, 1)
+	require.Equal(t, Scope, result.Suppressed[0].Scope)
+	require.Equal(t, Reason.InsufficientBuyingPower, result.Suppressed[0].Reason)
+
+	// The utilisation is recorded from the entry until the bar the position closed on
+	require.Len(t, result.MarginUtilisation, 4)
+	require.Equal(t, 6000.0, result.MarginUtilisation[0].InitialMargin)
+	require.Equal(t, 60.0, result.MarginUtilisation[0].Utilisation)
+	require.Zero(t, result.MarginUtilisation[3].OpenPositions)
+
+	// Without the buying power enforced both are taken
+	engine = mockMarginEngine(t, nil, 6000, 5000, entries)
+	result, err = engine.Run(sessions())
+	require.NoError(t, err)
+	require.Len(t, result.Trades, 2)
+}
+
+// TestRunMarginCall tests that every position is liquidated when equity falls below the maintenance margin
+func TestRunMarginCall(t *testing.T) {
+	entries := map[string][]time.Time{
+		"ES": {mockBarTime(mockDay, 0)},
+		"NQ": {mockBarTime(mockDay, 0)},
+	}
+	sessions := []*Session{mockSession("ES", mockDay, 10, nil), mockSession("NQ", mockDay, 10, nil)}
+	// Both fall 0.3 points without hitting their stops, leaving 9940 of equity against 9950 of maintenance margin
+	for _, session := range sessions {
+		session.Data[2].Low = 99.1
+		session.Data[2].Close = 99.7
+	}
+
+	engine := mockMarginEngine(t, &utils.MarginConfiguration{LiquidateOnMarginCall: true}, 5000, 4975, entries)
+	result, err := engine.Run(sessions)
+
+	require.NoError(t, err)
+	require.Equal(t, 1, result.MarginCalls)
+	require.Len(t, result.Trades, 2)
+	for _, trade := range result.Trades {
+		require.Equal(t, tradeConfig.ExitReason.MARGIN_CALL, trade.ExitReason)
+		require.Equal(t, mockBarTime(mockDay, 2), trade.ClosedAtTime)
+		require.Equal(t, 99.7, trade.ClosedAtPrice)
+	}
+	require.InDelta(t, 9940.0, result.EndingEquity, 1e-9)
+}
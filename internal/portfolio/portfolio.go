@@ -0,0 +1,614 @@
+package portfolio
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/backtestData"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/fx"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/positionSizing"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/riskGovernor"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/tradeConfig"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/utils"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// Scope is the scope used when a trade is suppressed by a portfolio constraint.
+	Scope = "PORTFOLIO"
+
+	// Reason is an equivalent to an enum for which portfolio constraint suppressed a trade.
+	Reason = reason{
+		MaxOpenPositions:        "MAX_OPEN_POSITIONS",
+		MaxOpenRisk:             "MAX_OPEN_RISK",
+		InsufficientBuyingPower: "INSUFFICIENT_BUYING_POWER",
+	}
+
+	// Outcome is an equivalent to an enum for what happened to a signal.
+	Outcome = outcome{TAKEN: "TAKEN", REJECTED: "REJECTED", SUPPRESSED: "SUPPRESSED"}
+)
+
+type outcome struct {
+	TAKEN      string
+	REJECTED   string
+	SUPPRESSED string
+}
+
+type reason struct {
+	MaxOpenPositions        string
+	MaxOpenRisk             string
+	InsufficientBuyingPower string
+}
+
+// Session is one trade window of an instrument's data for a region.
+type Session struct {
+	// Instrument is the instrument the data is for.
+	Instrument string
+
+	// Region is the name of the region the window was subset with.
+	Region string
+
+	// Data is the rows of the window in time order.
+	Data backtestData.Data
+}
+
+// key returns the region and the date the session starts, which is the same for every instrument traded in it.
+func (s *Session) key() string {
+	return s.Region + "|" + s.Data[0].Time.Format(time.DateOnly)
+}
+
+// BuildSessions subsets the data of an instrument into a Session for every trade window of each region in
+// utils.StandardConfiguration. A region that cannot be subset is logged and skipped.
+func BuildSessions(instrument string, data backtestData.Data) []*Session {
+	var sessions []*Session
+	for _, region := range utils.StandardConfiguration.Regions {
+		subsets, err := data.SubsetDataForMultipleDays(region.MarketOpen, region.MarketClose)
+		if err != nil {
+			log.Error().Str("error", err.Error()).Msg("Got error on generating subsets")
+			continue
+		}
+
+		for _, subset := range *subsets {
+			sessions = append(sessions, &Session{Instrument: instrument, Region: region.RegionName, Data: subset})
+		}
+		log.Debug().Str("instrument", instrument).Str("region", region.RegionName).Msgf("Generated %d subsets", len(*subsets))
+	}
+
+	return sessions
+}
+
+// SuppressedTrade is a trade that met every entry rule but was not taken by the account.
+type SuppressedTrade struct {
+	// Trade is the trade that was suppressed.
+	Trade *tradeConfig.Trade
+
+	// Scope is what suppressed the trade, either a riskGovernor.Scope, positionSizing.Scope or Scope.
+	Scope string
+
+	// Reason is why the trade was suppressed.
+	Reason string
+}
+
+// JournalEntry is a candle the entry rules were applied to while its instrument had no open position.
+type JournalEntry struct {
+	// Signal is the result of the entry rules.
+	Signal *tradeConfig.Signal
+
+	// Region is the name of the region the candle's session is for.
+	Region string
+
+	// MinimumRR is the minimum RR of the instrument when the signal was evaluated.
+	MinimumRR float64
+
+	// Outcome is what happened to the signal, one of Outcome.
+	Outcome string
+
+	// Scope is what suppressed the trade when the Outcome is SUPPRESSED.
+	Scope string
+
+	// Reason is the tradeConfig.Rejection of a REJECTED signal, or the reason a SUPPRESSED trade was not taken.
+	Reason string
+}
+
+// EquityPoint is the equity of the account at a point in time.
+type EquityPoint struct {
+	// Time is the time of the bar the equity was recorded on.
+	Time time.Time
+
+	// Equity is the realised equity of the account in the base currency.
+	Equity float64
+}
+
+// Result is the outcome of a portfolio simulation.
+type Result struct {
+	// Trades is every trade the account took, in the order they were taken, with their size and profit values set.
+	Trades tradeConfig.Trades
+
+	// Suppressed is every trade that was not taken by the account.
+	Suppressed []*SuppressedTrade
+
+	// Journal is every candle the entry rules were applied to and what happened to it.
+	Journal []*JournalEntry
+
+	// EquityCurve is the realised equity of the account after every trade closed.
+	EquityCurve []*EquityPoint
+
+	// MarginUtilisation is the margin held by the account on every bar a position was open or closed.
+	MarginUtilisation []*MarginPoint
+
+	// MarginCalls is the amount of times every open position was liquidated by a margin call.
+	MarginCalls int
+
+	// EndingEquity is the equity of the account after every trade has closed, in the base currency.
+	EndingEquity float64
+
+	// BaseCurrency is the currency the account equity and every ProfitValue is in.
+	BaseCurrency string
+}
+
+// EntryFunc evaluates a single candle and returns the trade to enter on its close, or nil,
+// along with the Signal to record in the journal, or nil to record nothing.
+// tradeConfig.EvaluateSignal is the strategy used by default.
+type EntryFunc func(
+	row *backtestData.Row,
+	instrumentConfig *utils.InstrumentConfiguration,
+	instrument string,
+	tickSize float64,
+) (*tradeConfig.Trade, *tradeConfig.Signal)
+
+// position is a trade that is currently open in the engine.
+type position struct {
+	// trade is the open trade.
+	trade *tradeConfig.Trade
+
+	// session is the session the trade was entered in, only its bars are used to manage the trade.
+	session *Session
+
+	// risk is the amount of base currency lost if the initial stop is hit.
+	risk float64
+
+	// lastPrice is the close of the latest bar applied to the position, used to mark it to market.
+	lastPrice float64
+
+	// carried is true when the position was held past the end of a session and its next bar opens a new session.
+	carried bool
+}
+
+// bar is one row of a session on the merged timeline.
+type bar struct {
+	// session is the session the row belongs to.
+	session *Session
+
+	// index is the index of the row in the session data.
+	index int
+}
+
+// row returns the data row of the bar.
+func (b bar) row() *backtestData.Row {
+	return b.session.Data[b.index]
+}
+
+// isLast returns true if the bar is the final row of its session.
+func (b bar) isLast() bool {
+	return b.index == len(b.session.Data)-1
+}
+
+// Engine is an event driven simulation of one account trading every instrument at the same time.
+// Every instrument's bars are merged into one timeline and processed in time order against shared equity.
+type Engine struct {
+	// configuration is the users configuration.
+	configuration *utils.Configuration
+
+	// specs is the contract specification of every instrument.
+	specs utils.ContractSpecRegistry
+
+	// converter converts every currency value into the base currency.
+	converter *fx.Converter
+
+	// governor applies the daily risk governors.
+	governor *riskGovernor.Governor
+
+	// evaluateEntry is the strategy used to find entries.
+	evaluateEntry EntryFunc
+
+	// nextSessions is a mapping of each session to the next session of the same instrument and region,
+	// used to carry positions overnight.
+	nextSessions map[*Session]*Session
+}
+
+// NewEngine creates an Engine for the users configuration, validating the position sizing of every instrument.
+// Every instrument a Session is passed to Run for must have a contract specification in specs.
+func NewEngine(
+	configuration *utils.Configuration,
+	specs utils.ContractSpecRegistry,
+	converter *fx.Converter,
+) (*Engine, error) {
+	for instrument := range configuration.Instruments {
+		if err := positionSizing.Validate(configuration.PositionSizing(instrument)); err != nil {
+			return nil, fmt.Errorf("%s: %w", instrument, err)
+		}
+	}
+
+	return &Engine{
+		configuration: configuration,
+		specs:         specs,
+		converter:     converter,
+		governor:      riskGovernor.New(configuration.RiskGovernor, configuration.Instruments),
+		evaluateEntry: tradeConfig.EvaluateSignal,
+	}, nil
+}
+
+// SetEntryFunc replaces the strategy used to find entries.
+func (e *Engine) SetEntryFunc(entryFunc EntryFunc) {
+	e.evaluateEntry = entryFunc
+}
+
+// linkSessions maps each session to the next session of the same instrument and region in time order.
+func linkSessions(sessions []*Session) map[*Session]*Session {
+	grouped := make(map[string][]*Session)
+	for _, session := range sessions {
+		if len(session.Data) == 0 {
+			continue
+		}
+		key := session.Instrument + "|" + session.Region
+		grouped[key] = append(grouped[key], session)
+	}
+
+	next := make(map[*Session]*Session)
+	for _, group := range grouped {
+		sort.SliceStable(group, func(i, j int) bool {
+			return group[i].Data[0].Time.Before(group[j].Data[0].Time)
+		})
+		for index := 0; index < len(group)-1; index++ {
+			next[group[index]] = group[index+1]
+		}
+	}
+
+	return next
+}
+
+// spansWeekend returns true if a Saturday or Sunday falls between two times.
+func spansWeekend(from, to time.Time) bool {
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			return true
+		}
+	}
+
+	return to.Weekday() == time.Saturday || to.Weekday() == time.Sunday
+}
+
+// timeline merges the bars of every session into one slice in time order,
+// using the instrument and region to keep bars at the same time deterministic.
+func timeline(sessions []*Session) []bar {
+	var bars []bar
+	for _, session := range sessions {
+		for index := range session.Data {
+			bars = append(bars, bar{session: session, index: index})
+		}
+	}
+
+	sort.SliceStable(bars, func(i, j int) bool {
+		timeI, timeJ := bars[i].row().Time, bars[j].row().Time
+		switch {
+		case !timeI.Equal(timeJ):
+			return timeI.Before(timeJ)
+		case bars[i].session.Instrument != bars[j].session.Instrument:
+			return bars[i].session.Instrument < bars[j].session.Instrument
+		default:
+			return bars[i].session.Region < bars[j].session.Region
+		}
+	})
+
+	return bars
+}
+
+// Run simulates the account over every session.
+// On each time in the timeline every open position is managed first, then new entries are checked
+// against the risk governors, position sizing and portfolio constraints.
+func (e *Engine) Run(sessions []*Session) (*Result, error) {
+	var (
+		result = &Result{
+			EndingEquity: e.configuration.StartingBalance,
+			BaseCurrency: e.converter.BaseCurrency(),
+		}
+		// positions is a mapping of instrument name to its open position
+		positions = make(map[string]*position)
+		bars      = timeline(sessions)
+	)
+	e.nextSessions = linkSessions(sessions)
+
+	for start := 0; start < len(bars); {
+		// Find every bar at the same time
+		end := start
+		for end < len(bars) && bars[end].row().Time.Equal(bars[start].row().Time) {
+			end++
+		}
+		group := bars[start:end]
+		at := group[0].row().Time
+		start = end
+
+		// Manage open positions first, remembering which instruments closed on this bar
+		closedThisBar := make(map[string]bool)
+		for _, current := range group {
+			open, ok := positions[current.session.Instrument]
+			if !ok || open.session != current.session {
+				continue
+			}
+
+			if e.manage(open, current) {
+				if err := e.realise(result, open); err != nil {
+					return nil, err
+				}
+				delete(positions, current.session.Instrument)
+				closedThisBar[current.session.Instrument] = true
+				continue
+			}
+			open.lastPrice = current.row().Close
+		}
+
+		// Liquidate every open position if the equity has fallen below the maintenance margin held
+		if e.configuration.Margin != nil && e.configuration.Margin.LiquidateOnMarginCall && len(positions) > 0 {
+			point, err := e.marginPoint(result, positions, at)
+			if err != nil {
+				return nil, err
+			}
+			if point.Equity < point.MaintenanceMargin {
+				log.Warn().Msgf(
+					"Margin call at %v with equity %.2f below maintenance margin %.2f, liquidating %d positions",
+					at,
+					point.Equity,
+					point.MaintenanceMargin,
+					len(positions),
+				)
+
+				liquidated, err := e.liquidate(result, positions, at)
+				if err != nil {
+					return nil, err
+				}
+				for _, instrument := range liquidated {
+					closedThisBar[instrument] = true
+				}
+			}
+		}
+
+		// Then look for new entries
+		for _, current := range group {
+			instrument := current.session.Instrument
+			if _, inPosition := positions[instrument]; inPosition || closedThisBar[instrument] {
+				continue
+			}
+
+			opened, err := e.enter(result, positions, current)
+			if err != nil {
+				return nil, err
+			}
+			if opened == nil {
+				continue
+			}
+
+			// A trade entered on the final bar of its session is closed straight away unless it is carried
+			if current.isLast() && !e.carry(opened) {
+				opened.trade.CloseAtEndOfSession(current.row())
+				if err := e.realise(result, opened); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			positions[instrument] = opened
+		}
+
+		// Record the margin held whenever a position was open or closed on this bar
+		if len(positions) > 0 || len(closedThisBar) > 0 {
+			point, err := e.marginPoint(result, positions, at)
+			if err != nil {
+				return nil, err
+			}
+			result.MarginUtilisation = append(result.MarginUtilisation, point)
+		}
+	}
+
+	return result, nil
+}
+
+// manage applies a bar to an open position and returns true if the position was closed.
+func (e *Engine) manage(open *position, current bar) bool {
+	instrument := current.session.Instrument
+
+	// The first bar after a position was carried may have gapped through the stop or target
+	if open.carried {
+		open.carried = false
+		if open.trade.GapThrough(current.row(), e.specs[instrument].TickSize) {
+			return true
+		}
+	}
+
+	if open.trade.Update(current.row(), e.configuration.Instruments[instrument], e.specs[instrument].TickSize) {
+		return true
+	}
+
+	// The position is closed on the close of the final bar of its session unless it is carried
+	if current.isLast() && !e.carry(open) {
+		open.trade.CloseAtEndOfSession(current.row())
+		return true
+	}
+
+	return false
+}
+
+// carry moves a position at the end of its session into the next session of its instrument and region,
+// returning false if the instrument does not hold overnight, the position has been held for MaxSessionsHeld,
+// or there is no next session.
+func (e *Engine) carry(open *position) bool {
+	instrumentConfig := e.configuration.Instruments[open.trade.Instrument]
+	if !instrumentConfig.HoldOvernight {
+		return false
+	}
+	if instrumentConfig.MaxSessionsHeld > 0 && open.trade.SessionsHeld >= instrumentConfig.MaxSessionsHeld {
+		return false
+	}
+
+	next, ok := e.nextSessions[open.session]
+	if !ok {
+		return false
+	}
+
+	lastTime := open.session.Data[len(open.session.Data)-1].Time
+	if spansWeekend(lastTime, next.Data[0].Time) {
+		open.trade.HeldOverWeekend = true
+	}
+	open.trade.SessionsHeld++
+	open.session = next
+	open.carried = true
+
+	log.Debug().Str(
+		"instrument",
+		open.trade.Instrument,
+	).Msgf("Holding trade taken at %v into the session starting %v", open.trade.TakenAt, next.Data[0].Time)
+
+	return true
+}
+
+// enter evaluates a bar for an entry and returns the opened position, or nil if no trade was taken.
+func (e *Engine) enter(result *Result, positions map[string]*position, current bar) (*position, error) {
+	instrument := current.session.Instrument
+	instrumentConfig := e.configuration.Instruments[instrument]
+	spec := e.specs[instrument]
+
+	trade, signal := e.evaluateEntry(current.row(), instrumentConfig, instrument, spec.TickSize)
+
+	// Record the signal in the journal, the outcome is updated as the trade is checked
+	entry := &JournalEntry{Signal: signal, Region: current.session.Region, MinimumRR: instrumentConfig.MinimumRR}
+	if signal != nil {
+		result.Journal = append(result.Journal, entry)
+	}
+	if trade == nil {
+		entry.Outcome = Outcome.REJECTED
+		if signal != nil {
+			entry.Reason = signal.Rejection
+		}
+		return nil, nil
+	}
+
+	// suppress adds the trade to the suppressed list
+	suppress := func(scope, suppressedReason string) {
+		log.Debug().Str(
+			"instrument",
+			instrument,
+		).Msgf("Suppressing trade at %v due to %s %s", trade.TakenAt, scope, suppressedReason)
+
+		result.Suppressed = append(result.Suppressed, &SuppressedTrade{Trade: trade, Scope: scope, Reason: suppressedReason})
+		entry.Outcome, entry.Scope, entry.Reason = Outcome.SUPPRESSED, scope, suppressedReason
+	}
+
+	// Check the governors and suppress the trade if any are breached
+	if governorScope, governorReason := e.governor.CanEnter(instrument, current.session.key(), trade.TakenAt); governorReason != "" {
+		suppress(governorScope, governorReason)
+		return nil, nil
+	}
+
+	// Size the trade with the equity we currently have, converting the risk with the rate at entry
+	fxRate, err := e.converter.Rate(spec.Currency, trade.TakenAt)
+	if err != nil {
+		return nil, err
+	}
+	contracts, sizingReason := positionSizing.Size(
+		e.configuration.PositionSizing(instrument),
+		spec,
+		result.EndingEquity,
+		trade.EntryPrice,
+		trade.InitialStopPrice,
+		fxRate,
+	)
+	if sizingReason != "" {
+		suppress(positionSizing.Scope, sizingReason)
+		return nil, nil
+	}
+
+	// Check the portfolio constraints with the risk of every open position
+	risk := float64(contracts) * positionSizing.RiskPerContract(spec, trade.EntryPrice, trade.InitialStopPrice, fxRate)
+	if portfolioReason := e.checkPortfolio(result.EndingEquity, positions, risk); portfolioReason != "" {
+		suppress(Scope, portfolioReason)
+		return nil, nil
+	}
+
+	// Check the initial margin of the new position fits in the buying power left
+	if e.configuration.Margin != nil && e.configuration.Margin.EnforceBuyingPower {
+		point, err := e.marginPoint(result, positions, trade.TakenAt)
+		if err != nil {
+			return nil, err
+		}
+		if float64(contracts)*spec.InitialMargin*fxRate > point.BuyingPower() {
+			suppress(Scope, Reason.InsufficientBuyingPower)
+			return nil, nil
+		}
+	}
+
+	trade.Contracts = contracts
+	trade.Context.Region = current.session.Region
+	entry.Outcome = Outcome.TAKEN
+	e.governor.RecordEntry(instrument, current.session.key(), trade.TakenAt)
+	result.Trades = append(result.Trades, trade)
+
+	return &position{trade: trade, session: current.session, risk: risk, lastPrice: trade.EntryPrice}, nil
+}
+
+// checkPortfolio returns the Reason a new position with the given risk breaches a portfolio constraint,
+// or an empty string if it does not.
+func (e *Engine) checkPortfolio(equity float64, positions map[string]*position, risk float64) string {
+	constraints := e.configuration.Portfolio
+	if constraints == nil {
+		return ""
+	}
+
+	openRisk := risk
+	for _, open := range positions {
+		openRisk += open.risk
+	}
+
+	switch {
+	case constraints.MaxOpenPositions > 0 && len(positions) >= constraints.MaxOpenPositions:
+		return Reason.MaxOpenPositions
+	case constraints.MaxOpenRiskPercent > 0 && openRisk > equity*constraints.MaxOpenRiskPercent/100:
+		return Reason.MaxOpenRisk
+	case constraints.MaxOpenRiskValue > 0 && openRisk > constraints.MaxOpenRiskValue:
+		return Reason.MaxOpenRisk
+	}
+
+	return ""
+}
+
+// realise applies the profit of a closed position to the account, converting it with the rate at close.
+func (e *Engine) realise(result *Result, closed *position) error {
+	trade := closed.trade
+	spec := e.specs[trade.Instrument]
+
+	fxRate, err := e.converter.Rate(spec.Currency, trade.ClosedAtTime)
+	if err != nil {
+		return err
+	}
+
+	trade.Currency = spec.Currency
+	trade.FxRate = fxRate
+	trade.NativeProfitValue = positionSizing.ProfitValue(
+		spec,
+		trade.Direction,
+		trade.Contracts,
+		trade.EntryPrice,
+		trade.ClosedAtPrice,
+	)
+	trade.ProfitValue = trade.NativeProfitValue * fxRate
+	result.EndingEquity += trade.ProfitValue
+	trade.Equity = result.EndingEquity
+
+	e.governor.RecordExit(trade.Instrument, trade.ClosedAtTime, trade.ProfitR(), trade.ProfitValue)
+	result.EquityCurve = append(result.EquityCurve, &EquityPoint{Time: trade.ClosedAtTime, Equity: result.EndingEquity})
+
+	log.Debug().Str(
+		"instrument",
+		trade.Instrument,
+	).Msgf("Trade closed at %v for %s with %.2f %s", trade.ClosedAtTime, trade.ExitReason, trade.ProfitValue, result.BaseCurrency)
+
+	return nil
+}
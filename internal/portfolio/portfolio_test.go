@@ -0,0 +1,391 @@
+package portfolio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/backtestData"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/fx"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/positionSizing"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/riskGovernor"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/tradeConfig"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// mockDay is the start of the first mock session.
+var mockDay = time.Date(2023, 10, 20, 9, 0, 0, 0, time.UTC)
+
+// mockSpecs is a registry where one point is worth 100 and there is no commission.
+var mockSpecs = utils.ContractSpecRegistry{
+	"ES":   {TickSize: 1, TickValue: 100, Currency: "USD"},
+	"NQ":   {TickSize: 1, TickValue: 100, Currency: "USD"},
+	"FDAX": {TickSize: 1, TickValue: 100, Currency: "EUR"},
+}
+
+// mockConverter returns a USD converter where one EUR is worth 1.5 USD.
+func mockConverter(t *testing.T) *fx.Converter {
+	converter, err := fx.NewConverter("USD", map[string]float64{"EUR": 1.5})
+	require.NoError(t, err)
+	return converter
+}
+
+// mockBarTime returns the time of a bar index in a session starting at start with 5 minute bars.
+func mockBarTime(start time.Time, index int) time.Time {
+	return start.Add(time.Duration(index) * 5 * time.Minute)
+}
+
+// mockSession is a helper to create a session of flat 5 minute bars around 100.
+// moves is a mapping of bar index to 1 for a bar that reaches the target of a mock trade or -1 for one that hits its stop.
+func mockSession(instrument string, start time.Time, bars int, moves map[int]int) *Session {
+	data := make(backtestData.Data, bars)
+	for index := range data {
+		row := &backtestData.Row{Time: mockBarTime(start, index), Open: 100, High: 100.5, Low: 99.5, Close: 100}
+		switch moves[index] {
+		case 1:
+			row.High = 103
+		case -1:
+			row.Low = 98
+		}
+		data[index] = row
+	}
+
+	return &Session{Instrument: instrument, Region: "New York", Data: data}
+}
+
+// mockEntries returns an EntryFunc that enters a LONG trade risking 1 point for a target of 2R
+// on the close of every bar in entries, a mapping of instrument to entry times. Every other bar is rejected.
+func mockEntries(entries map[string][]time.Time) EntryFunc {
+	return func(
+		row *backtestData.Row,
+		_ *utils.InstrumentConfiguration,
+		instrument string,
+		_ float64,
+	) (*tradeConfig.Trade, *tradeConfig.Signal) {
+		signal := &tradeConfig.Signal{Instrument: instrument, Time: row.Time, Rejection: tradeConfig.Rejection.INVALID_ENTRY}
+		for _, at := range entries[instrument] {
+			if row.Time.Equal(at) {
+				signal.Rejection = ""
+				return &tradeConfig.Trade{
+					Instrument:       instrument,
+					TakenAt:          row.Time,
+					Direction:        utils.TradeDirection.LONG,
+					EntryPrice:       100,
+					StopPrice:        99,
+					InitialStopPrice: 99,
+					TargetPrice:      102,
+				}, signal
+			}
+		}
+		return nil, signal
+	}
+}
+
+// mockConfiguration returns a configuration trading one contract of ES and NQ.
+func mockConfiguration() *utils.Configuration {
+	return &utils.Configuration{
+		Instruments: map[string]*utils.InstrumentConfiguration{
+			"ES": {},
+			"NQ": {},
+		},
+		StartingBalance: 10000,
+		AccountPositionSizing: &utils.PositionSizingConfiguration{
+			Model:     utils.PositionSizingModel.FIXED_CONTRACTS,
+			Contracts: 1,
+		},
+	}
+}
+
+// mockEngine is a helper to create an Engine using mockEntries.
+func mockEngine(
+	t *testing.T,
+	configuration *utils.Configuration,
+	converter *fx.Converter,
+	entries map[string][]time.Time,
+) *Engine {
+	engine, err := NewEngine(configuration, mockSpecs, converter)
+	require.NoError(t, err)
+	engine.SetEntryFunc(mockEntries(entries))
+	return engine
+}
+
+// TestRunInstrumentGovernor tests that the instrument governor only suppresses its own instrument
+func TestRunInstrumentGovernor(t *testing.T) {
+	nextDay := mockDay.Add(24 * time.Hour)
+	configuration := mockConfiguration()
+	configuration.Instruments["ES"].RiskGovernor = &utils.RiskGovernorConfiguration{MaxConsecutiveLosses: 2}
+
+	engine := mockEngine(t, configuration, mockConverter(t), map[string][]time.Time{
+		"ES": {mockBarTime(mockDay, 0), mockBarTime(mockDay, 2), mockBarTime(mockDay, 6), mockBarTime(nextDay, 0)},
+		"NQ": {mockBarTime(mockDay, 4)},
+	})
+	result, err := engine.Run([]*Session{
+		mockSession("ES", mockDay, 10, map[int]int{1: -1, 3: -1, 7: 1}),
+		mockSession("NQ", mockDay, 10, map[int]int{5: -1}),
+		// The next day the state is reset
+		mockSession("ES", nextDay, 10, map[int]int{1: 1}),
+	})
+
+	require.NoError(t, err)
+	require.Len(t, result.Trades, 4)
+	require.Len(t, result.Suppressed, 1)
+	require.Equal(t, mockBarTime(mockDay, 6), result.Suppressed[0].Trade.TakenAt)
+	require.Equal(t, riskGovernor.Scope.INSTRUMENT, result.Suppressed[0].Scope)
+	require.Equal(t, riskGovernor.Reason.ConsecutiveLosses, result.Suppressed[0].Reason)
+	// Three losses of 100 and one win of 200
+	require.Equal(t, 9900.0, result.EndingEquity)
+	require.Len(t, result.EquityCurve, 4)
+	require.Equal(t, 9900.0, result.EquityCurve[3].Equity)
+}
+
+// TestRunJournal tests that every bar evaluated while flat is journaled with its outcome
+func TestRunJournal(t *testing.T) {
+	configuration := mockConfiguration()
+	configuration.Instruments["ES"].RiskGovernor = &utils.RiskGovernorConfiguration{MaxTradesPerSession: 1}
+
+	engine := mockEngine(t, configuration, mockConverter(t), map[string][]time.Time{
+		"ES": {mockBarTime(mockDay, 1), mockBarTime(mockDay, 3)},
+	})
+	result, err := engine.Run([]*Session{mockSession("ES", mockDay, 5, map[int]int{2: 1})})
+	require.NoError(t, err)
+
+	// Bar 2 is not journaled as ES was in a position
+	require.Len(t, result.Journal, 4)
+	outcomes := make([]string, len(result.Journal))
+	for index, entry := range result.Journal {
+		outcomes[index] = entry.Outcome
+		require.Equal(t, "New York", entry.Region)
+	}
+	require.Equal(t, []string{Outcome.REJECTED, Outcome.TAKEN, Outcome.SUPPRESSED, Outcome.REJECTED}, outcomes)
+	require.Equal(t, tradeConfig.Rejection.INVALID_ENTRY, result.Journal[0].Reason)
+	require.Equal(t, riskGovernor.Scope.INSTRUMENT, result.Journal[2].Scope)
+	require.Equal(t, riskGovernor.Reason.MaxTrades, result.Journal[2].Reason)
+}
+
+// TestRunAccountGovernor tests that the account governor only counts trades that have closed
+func TestRunAccountGovernor(t *testing.T) {
+	configuration := mockConfiguration()
+	configuration.RiskGovernor = &utils.RiskGovernorConfiguration{MaxDailyLossValue: 150}
+
+	engine := mockEngine(t, configuration, mockConverter(t), map[string][]time.Time{
+		// ES and NQ both lose 100, by the second ES entry both have closed which breaches the 150 loss
+		"ES": {mockBarTime(mockDay, 0), mockBarTime(mockDay, 5)},
+		// NQ is entered while ES is still open
+		"NQ": {mockBarTime(mockDay, 1)},
+	})
+	result, err := engine.Run([]*Session{
+		mockSession("ES", mockDay, 10, map[int]int{2: -1}),
+		mockSession("NQ", mockDay, 10, map[int]int{3: -1}),
+	})
+
+	require.NoError(t, err)
+	require.Len(t, result.Trades, 2)
+	require.Len(t, result.Suppressed, 1)
+	require.Equal(t, riskGovernor.Scope.ACCOUNT, result.Suppressed[0].Scope)
+	require.Equal(t, riskGovernor.Reason.MaxDailyLoss, result.Suppressed[0].Reason)
+}
+
+// TestRunPortfolioConstraints tests that positions open at the same time are limited across instruments
+func TestRunPortfolioConstraints(t *testing.T) {
+	tests := []struct {
+		name        string
+		constraints *utils.PortfolioConfiguration
+		reason      string
+	}{
+		{"max open positions", &utils.PortfolioConfiguration{MaxOpenPositions: 1}, Reason.MaxOpenPositions},
+		{"max open risk value", &utils.PortfolioConfiguration{MaxOpenRiskValue: 150}, Reason.MaxOpenRisk},
+		{"max open risk percent", &utils.PortfolioConfiguration{MaxOpenRiskPercent: 1.5}, Reason.MaxOpenRisk},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configuration := mockConfiguration()
+			configuration.Portfolio = test.constraints
+
+			engine := mockEngine(t, configuration, mockConverter(t), map[string][]time.Time{
+				// The second ES entry is ignored as ES is already in a position
+				"ES": {mockBarTime(mockDay, 0), mockBarTime(mockDay, 1)},
+				// The first NQ entry overlaps the ES trade, the second is after it has closed
+				"NQ": {mockBarTime(mockDay, 1), mockBarTime(mockDay, 4)},
+			})
+			result, err := engine.Run([]*Session{
+				mockSession("ES", mockDay, 10, map[int]int{3: 1}),
+				mockSession("NQ", mockDay, 10, map[int]int{6: 1}),
+			})
+
+			require.NoError(t, err)
+			require.Len(t, result.Trades, 2)
+			require.Len(t, result.Suppressed, 1)
+			require.Equal(t, "NQ", result.Suppressed[0].Trade.Instrument)
+			require.Equal(t, Scope, result.Suppressed[0].Scope)
+			require.Equal(t, test.reason, result.Suppressed[0].Reason)
+			require.Equal(t, 10400.0, result.EndingEquity)
+		})
+	}
+}
+
+// TestRunSessionEnd tests that open trades are closed on the final bar of their session
+func TestRunSessionEnd(t *testing.T) {
+	configuration := mockConfiguration()
+
+	engine := mockEngine(t, configuration, mockConverter(t), map[string][]time.Time{
+		// The first trade runs until the end of the session, the second is entered on the final bar
+		"ES": {mockBarTime(mockDay, 0)},
+		"NQ": {mockBarTime(mockDay, 4)},
+	})
+	result, err := engine.Run([]*Session{
+		mockSession("ES", mockDay, 5, nil),
+		mockSession("NQ", mockDay, 5, nil),
+	})
+
+	require.NoError(t, err)
+	require.Len(t, result.Trades, 2)
+	for _, trade := range result.Trades {
+		require.Equal(t, tradeConfig.ExitReason.SESSION_END, trade.ExitReason)
+		require.Equal(t, mockBarTime(mockDay, 4), trade.ClosedAtTime)
+		require.Equal(t, 100.0, trade.ClosedAtPrice)
+	}
+}
+
+// TestRunSizing tests that trades are sized with the running equity and skipped when unaffordable
+func TestRunSizing(t *testing.T) {
+	configuration := mockConfiguration()
+	configuration.StartingBalance = 1000
+	configuration.AccountPositionSizing = &utils.PositionSizingConfiguration{
+		Model:       utils.PositionSizingModel.PERCENT_RISK,
+		RiskPercent: 20,
+	}
+	entries := map[string][]time.Time{"ES": {mockBarTime(mockDay, 0), mockBarTime(mockDay, 2), mockBarTime(mockDay, 4)}}
+	// Risks 200 of 1000 so 2 contracts, winning 2R is 400
+	// Risks 20% of 1400 so 2 contracts, losing 200
+	// Risks 20% of 1200 so 2 contracts, losing 200
+	sessions := []*Session{mockSession("ES", mockDay, 10, map[int]int{1: 1, 3: -1, 5: -1})}
+
+	result, err := mockEngine(t, configuration, mockConverter(t), entries).Run(sessions)
+
+	require.NoError(t, err)
+	require.Len(t, result.Trades, 3)
+	require.Equal(t, []int{2, 2, 2}, []int{result.Trades[0].Contracts, result.Trades[1].Contracts, result.Trades[2].Contracts})
+	require.Equal(t, 1400.0, result.Trades[0].Equity)
+	require.Equal(t, -200.0, result.Trades[1].ProfitValue)
+	require.Equal(t, 1000.0, result.EndingEquity)
+
+	// An account that cannot afford one contract skips the trade
+	configuration.StartingBalance = 50
+	result, err = mockEngine(t, configuration, mockConverter(t), entries).Run(sessions)
+	require.NoError(t, err)
+	require.Empty(t, result.Trades)
+	require.Equal(t, positionSizing.Scope, result.Suppressed[0].Scope)
+	require.Equal(t, positionSizing.Reason.InsufficientEquity, result.Suppressed[0].Reason)
+}
+
+// TestNewEngineInvalid tests that an invalid sizing model is returned as an error
+func TestNewEngineInvalid(t *testing.T) {
+	configuration := mockConfiguration()
+	configuration.AccountPositionSizing = &utils.PositionSizingConfiguration{Model: "ALL_IN"}
+
+	_, err := NewEngine(configuration, mockSpecs, mockConverter(t))
+	require.ErrorIs(t, err, positionSizing.ModelInvalid)
+}
+
+// TestRunCurrencyConversion tests that profit is converted into the base currency at close
+func TestRunCurrencyConversion(t *testing.T) {
+	configuration := mockConfiguration()
+	configuration.Instruments["FDAX"] = &utils.InstrumentConfiguration{}
+
+	converter := mockConverter(t)
+	converter.AddRates([]*fx.RateRow{{Time: mockBarTime(mockDay, 1), Currency: "EUR", Rate: 2}})
+
+	engine := mockEngine(t, configuration, converter, map[string][]time.Time{"FDAX": {mockBarTime(mockDay, 0)}})
+	result, err := engine.Run([]*Session{mockSession("FDAX", mockDay, 10, map[int]int{2: 1})})
+
+	require.NoError(t, err)
+	require.Equal(t, "USD", result.BaseCurrency)
+	trade := result.Trades[0]
+	require.Equal(t, "EUR", trade.Currency)
+	require.Equal(t, 200.0, trade.NativeProfitValue)
+	require.Equal(t, 2.0, trade.FxRate, "the rate at close is used")
+	require.Equal(t, 400.0, trade.ProfitValue)
+	require.Equal(t, 10400.0, result.EndingEquity)
+}
+
+// TestRunHoldOvernight tests that positions are carried into the next session and closed on a gap
+func TestRunHoldOvernight(t *testing.T) {
+	// mockDay is a Friday so the first carry is held over the weekend
+	monday := mockDay.Add(72 * time.Hour)
+	tuesday := monday.Add(24 * time.Hour)
+
+	configuration := mockConfiguration()
+	configuration.Instruments["ES"].HoldOvernight = true
+	configuration.Instruments["NQ"].HoldOvernight = true
+	configuration.Instruments["NQ"].MaxSessionsHeld = 1
+
+	sessions := func() []*Session {
+		gap := mockSession("ES", tuesday, 5, nil)
+		// ES opens Tuesday below its stop so is filled at the open
+		gap.Data[0].Open, gap.Data[0].Low = 97, 96
+		return []*Session{
+			mockSession("ES", mockDay, 5, nil),
+			mockSession("ES", monday, 5, nil),
+			gap,
+			mockSession("NQ", mockDay, 5, nil),
+			mockSession("NQ", monday, 5, nil),
+			mockSession("NQ", tuesday, 5, nil),
+		}
+	}
+	entries := map[string][]time.Time{
+		// The second ES entry is ignored as the first is still held
+		"ES": {mockBarTime(mockDay, 0), mockBarTime(monday, 1)},
+		"NQ": {mockBarTime(mockDay, 0)},
+	}
+
+	result, err := mockEngine(t, configuration, mockConverter(t), entries).Run(sessions())
+	require.NoError(t, err)
+	require.Len(t, result.Trades, 2)
+
+	es, nq := result.Trades[0], result.Trades[1]
+	require.Equal(t, 2, es.SessionsHeld)
+	require.True(t, es.HeldOverWeekend)
+	require.True(t, es.GapExit)
+	require.Equal(t, tradeConfig.ExitReason.STOP, es.ExitReason)
+	require.Equal(t, 97.0, es.ClosedAtPrice)
+	require.Equal(t, tuesday, es.ClosedAtTime)
+
+	// NQ can only be held through one session close
+	require.Equal(t, 1, nq.SessionsHeld)
+	require.False(t, nq.GapExit)
+	require.Equal(t, tradeConfig.ExitReason.SESSION_END, nq.ExitReason)
+	require.Equal(t, mockBarTime(monday, 4), nq.ClosedAtTime)
+
+	// Without HoldOvernight every trade is closed at the end of the session it was entered in
+	configuration.Instruments["ES"].HoldOvernight = false
+	result, err = mockEngine(t, configuration, mockConverter(t), entries).Run(sessions())
+	require.NoError(t, err)
+	require.Equal(t, mockBarTime(mockDay, 4), result.Trades[0].ClosedAtTime)
+	require.False(t, result.Trades[0].IsOvernight())
+}
+
+// TestBuildSessions tests only complete New York windows on weekdays become sessions
+func TestBuildSessions(t *testing.T) {
+	var data backtestData.Data
+	// Friday from 01:00 until Saturday 17:00, then Monday until 10:00 which never reaches the close of the window
+	for _, span := range []struct {
+		start time.Time
+		bars  int
+	}{
+		{time.Date(2023, 10, 20, 1, 0, 0, 0, time.UTC), 40 * 12},
+		{time.Date(2023, 10, 23, 1, 0, 0, 0, time.UTC), 9 * 12},
+	} {
+		for index := 0; index < span.bars; index++ {
+			data = append(data, &backtestData.Row{Time: mockBarTime(span.start, index), Close: 100})
+		}
+	}
+
+	sessions := BuildSessions("ES", data)
+
+	require.Len(t, sessions, 1)
+	require.Equal(t, "ES", sessions[0].Instrument)
+	require.Equal(t, "New York", sessions[0].Region)
+	require.Equal(t, time.Date(2023, 10, 20, 2, 0, 0, 0, time.UTC), sessions[0].Data[0].Time)
+	require.Equal(t, time.Date(2023, 10, 20, 16, 0, 0, 0, time.UTC), sessions[0].Data[len(sessions[0].Data)-1].Time)
+}
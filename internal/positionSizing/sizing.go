@@ -0,0 +1,114 @@
+package positionSizing
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/utils"
+)
+
+var (
+	// ModelInvalid is an error for when the position sizing model is not one of utils.PositionSizingModel.
+	ModelInvalid = errors.New("position sizing model is invalid")
+
+	// Scope is the scope used when a trade is suppressed because it could not be sized.
+	Scope = "POSITION_SIZING"
+
+	// Reason is an equivalent to an enum for why a trade could not be sized.
+	Reason = reason{
+		InsufficientEquity: "INSUFFICIENT_EQUITY",
+		BelowOneContract:   "BELOW_ONE_CONTRACT",
+	}
+)
+
+type reason struct {
+	InsufficientEquity string
+	BelowOneContract   string
+}
+
+// Validate returns a ModelInvalid error if the configuration cannot be used to size trades.
+func Validate(config *utils.PositionSizingConfiguration) error {
+	if config == nil {
+		return fmt.Errorf("no position sizing configured: %w", ModelInvalid)
+	}
+
+	switch config.Model {
+	case utils.PositionSizingModel.FIXED_CONTRACTS:
+		if config.Contracts <= 0 {
+			return fmt.Errorf("Contracts must be greater than 0 for %s: %w", config.Model, ModelInvalid)
+		}
+	case utils.PositionSizingModel.FIXED_RISK:
+		if config.RiskValue <= 0 {
+			return fmt.Errorf("RiskValue must be greater than 0 for %s: %w", config.Model, ModelInvalid)
+		}
+	case utils.PositionSizingModel.PERCENT_RISK:
+		if config.RiskPercent <= 0 {
+			return fmt.Errorf("RiskPercent must be greater than 0 for %s: %w", config.Model, ModelInvalid)
+		}
+	default:
+		return fmt.Errorf("got %q: %w", config.Model, ModelInvalid)
+	}
+
+	return nil
+}
+
+// RiskPerContract returns the amount of base currency lost by one contract if the stop is hit, including commission.
+// The stop distance is rounded to whole ticks so that it matches what the exchange would fill,
+// and fxRate is the amount of base currency one unit of the contract currency is worth.
+func RiskPerContract(spec utils.ContractSpec, entryPrice, stopPrice, fxRate float64) float64 {
+	stopTicks := math.Round(math.Abs(entryPrice-stopPrice) / spec.TickSize)
+	return (stopTicks*spec.TickValue + spec.Commission) * fxRate
+}
+
+// Size returns the whole number of contracts to trade for an entry given the current equity in base currency.
+// The amount is always rounded down, if it is below one contract or the account cannot afford the risk
+// then 0 is returned along with the Reason.
+func Size(
+	config *utils.PositionSizingConfiguration,
+	spec utils.ContractSpec,
+	equity float64,
+	entryPrice float64,
+	stopPrice float64,
+	fxRate float64,
+) (int, string) {
+	riskPerContract := RiskPerContract(spec, entryPrice, stopPrice, fxRate)
+	if equity <= 0 || riskPerContract > equity {
+		return 0, Reason.InsufficientEquity
+	}
+
+	var contracts int
+	switch config.Model {
+	case utils.PositionSizingModel.FIXED_CONTRACTS:
+		contracts = config.Contracts
+	case utils.PositionSizingModel.FIXED_RISK:
+		contracts = int(math.Floor(config.RiskValue / riskPerContract))
+	case utils.PositionSizingModel.PERCENT_RISK:
+		contracts = int(math.Floor(equity * config.RiskPercent / 100 / riskPerContract))
+	}
+
+	// Cap the contracts if a maximum is set
+	if config.MaxContracts > 0 {
+		contracts = min(contracts, config.MaxContracts)
+	}
+
+	switch {
+	case contracts < 1:
+		return 0, Reason.BelowOneContract
+	// A fixed amount of contracts can still risk more than the account holds
+	case float64(contracts)*riskPerContract > equity:
+		return 0, Reason.InsufficientEquity
+	}
+
+	return contracts, ""
+}
+
+// ProfitValue returns the profit or loss in the contract currency of a closed trade, after commission.
+func ProfitValue(spec utils.ContractSpec, direction string, contracts int, entryPrice, closedAtPrice float64) float64 {
+	priceMove := closedAtPrice - entryPrice
+	if direction == utils.TradeDirection.SHORT {
+		priceMove = -priceMove
+	}
+
+	return (priceMove*spec.PointValue() - spec.Commission) * float64(contracts)
+}
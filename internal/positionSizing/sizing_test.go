@@ -0,0 +1,84 @@
+package positionSizing
+
+import (
+	"testing"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// mockSpec is an ES like contract worth 12.5 a tick with a 2.5 round turn commission.
+var mockSpec = utils.ContractSpec{TickSize: 0.25, TickValue: 12.5, Currency: "USD", Commission: 2.5}
+
+// TestValidate tests each model requires its own value
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *utils.PositionSizingConfiguration
+		valid  bool
+	}{
+		{"nil", nil, false},
+		{"unknown model", &utils.PositionSizingConfiguration{Model: "ALL_IN"}, false},
+		{"fixed contracts", &utils.PositionSizingConfiguration{Model: "FIXED_CONTRACTS", Contracts: 1}, true},
+		{"fixed contracts missing", &utils.PositionSizingConfiguration{Model: "FIXED_CONTRACTS"}, false},
+		{"fixed risk", &utils.PositionSizingConfiguration{Model: "FIXED_RISK", RiskValue: 500}, true},
+		{"fixed risk missing", &utils.PositionSizingConfiguration{Model: "FIXED_RISK"}, false},
+		{"percent risk", &utils.PositionSizingConfiguration{Model: "PERCENT_RISK", RiskPercent: 1}, true},
+		{"percent risk missing", &utils.PositionSizingConfiguration{Model: "PERCENT_RISK"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.config)
+			if tt.valid {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, ModelInvalid)
+			}
+		})
+	}
+}
+
+// TestRiskPerContract tests the stop distance is valued in whole ticks plus commission
+func TestRiskPerContract(t *testing.T) {
+	// 4 points is 16 ticks, 16 * 12.5 = 200 plus 2.5 commission
+	require.Equal(t, 202.5, RiskPerContract(mockSpec, 4000, 3996, 1))
+	require.Equal(t, 202.5, RiskPerContract(mockSpec, 3996, 4000, 1))
+	// Converted into a base currency where one unit is worth 2
+	require.Equal(t, 405.0, RiskPerContract(mockSpec, 4000, 3996, 2))
+}
+
+// TestSize tests each model and the reasons a trade cannot be sized
+func TestSize(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        *utils.PositionSizingConfiguration
+		equity        float64
+		wantContracts int
+		wantReason    string
+	}{
+		{"fixed contracts", &utils.PositionSizingConfiguration{Model: "FIXED_CONTRACTS", Contracts: 3}, 10000, 3, ""},
+		{"fixed contracts unaffordable", &utils.PositionSizingConfiguration{Model: "FIXED_CONTRACTS", Contracts: 3}, 500, 0, Reason.InsufficientEquity},
+		{"fixed risk rounds down", &utils.PositionSizingConfiguration{Model: "FIXED_RISK", RiskValue: 500}, 10000, 2, ""},
+		{"percent risk", &utils.PositionSizingConfiguration{Model: "PERCENT_RISK", RiskPercent: 5}, 10000, 2, ""},
+		{"percent risk below one", &utils.PositionSizingConfiguration{Model: "PERCENT_RISK", RiskPercent: 1}, 10000, 0, Reason.BelowOneContract},
+		{"max contracts", &utils.PositionSizingConfiguration{Model: "FIXED_RISK", RiskValue: 5000, MaxContracts: 4}, 10000, 4, ""},
+		{"no equity", &utils.PositionSizingConfiguration{Model: "FIXED_CONTRACTS", Contracts: 1}, 0, 0, Reason.InsufficientEquity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contracts, reason := Size(tt.config, mockSpec, tt.equity, 4000, 3996, 1)
+			require.Equal(t, tt.wantContracts, contracts)
+			require.Equal(t, tt.wantReason, reason)
+		})
+	}
+}
+
+// TestProfitValue tests the profit is valued by direction and includes commission
+func TestProfitValue(t *testing.T) {
+	// LONG 2 contracts up 2 points: 2 * (100 - 2.5)
+	require.Equal(t, 195.0, ProfitValue(mockSpec, utils.TradeDirection.LONG, 2, 4000, 4002))
+	// SHORT 1 contract up 1 point is a loss: -50 - 2.5
+	require.Equal(t, -52.5, ProfitValue(mockSpec, utils.TradeDirection.SHORT, 1, 4000, 4001))
+}
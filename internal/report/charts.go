@@ -0,0 +1,302 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/stats"
+)
+
+const (
+	// chartWidth is the width of every chart in the report.
+	chartWidth = 960.0
+
+	// chartHeight is the height of the line and bar charts in the report.
+	chartHeight = 280.0
+
+	// chartTicks is the amount of labels on each axis of a chart.
+	chartTicks = 5
+
+	// positiveFill is the colour of profit in every chart.
+	positiveFill = "#2e7d32"
+
+	// negativeFill is the colour of loss in every chart.
+	negativeFill = "#c62828"
+)
+
+// margins is the space around the plot of a chart that the axis labels are drawn in.
+var margins = struct{ top, right, bottom, left float64 }{top: 10, right: 20, bottom: 30, left: 80}
+
+// label is a piece of text at a position in a chart.
+type label struct {
+	X    float64
+	Y    float64
+	Text string
+}
+
+// plot is the area of a chart the data is drawn in.
+type plot struct {
+	Width  float64
+	Height float64
+	Left   float64
+	Right  float64
+	Top    float64
+	Bottom float64
+}
+
+// newPlot returns the plot of a chart of the given size inside the margins.
+func newPlot(width, height float64) plot {
+	return plot{
+		Width:  width,
+		Height: height,
+		Left:   margins.left,
+		Right:  width - margins.right,
+		Top:    margins.top,
+		Bottom: height - margins.bottom,
+	}
+}
+
+// scale returns the position of a value between minimum and maximum from start to end.
+func scale(value, minimum, maximum, start, end float64) float64 {
+	if maximum == minimum {
+		return start
+	}
+	return start + (value-minimum)/(maximum-minimum)*(end-start)
+}
+
+// yLabels returns the labels of the value axis from minimum to maximum.
+func (p plot) yLabels(minimum, maximum float64, format string) []label {
+	labels := make([]label, 0, chartTicks)
+	for tick := 0; tick < chartTicks; tick++ {
+		value := minimum + (maximum-minimum)*float64(tick)/(chartTicks-1)
+		labels = append(labels, label{
+			X:    p.Left - 8,
+			Y:    scale(value, minimum, maximum, p.Bottom, p.Top),
+			Text: fmt.Sprintf(format, value),
+		})
+	}
+
+	return labels
+}
+
+// lineChart is the SVG geometry of a line of values over time, with the area between it and a baseline filled.
+type lineChart struct {
+	plot
+	Line    string
+	Area    string
+	Fill    string
+	XLabels []label
+	YLabels []label
+}
+
+// newLineChart returns a line chart of the values at each time, with the area between the line and the
+// baseline filled. It returns nil if there are no values.
+func newLineChart(times []time.Time, values []float64, baseline float64, fill, format string) *lineChart {
+	if len(values) == 0 {
+		return nil
+	}
+
+	chart := &lineChart{plot: newPlot(chartWidth, chartHeight), Fill: fill}
+	minimum, maximum := baseline, baseline
+	for _, value := range values {
+		minimum, maximum = math.Min(minimum, value), math.Max(maximum, value)
+	}
+	if minimum == maximum {
+		minimum, maximum = minimum-1, maximum+1
+	}
+
+	first, last := times[0], times[len(times)-1]
+	x := func(at time.Time) float64 {
+		return scale(float64(at.Sub(first)), 0, float64(last.Sub(first)), chart.Left, chart.Right)
+	}
+	y := func(value float64) float64 {
+		return scale(value, minimum, maximum, chart.Bottom, chart.Top)
+	}
+
+	for index, value := range values {
+		command := "L"
+		if index == 0 {
+			command = "M"
+		}
+		chart.Line += fmt.Sprintf("%s%.1f %.1f ", command, x(times[index]), y(value))
+	}
+	chart.Area = fmt.Sprintf(
+		"%sL%.1f %.1f L%.1f %.1f Z",
+		chart.Line,
+		x(last),
+		y(baseline),
+		x(first),
+		y(baseline),
+	)
+
+	for tick := 0; tick < chartTicks; tick++ {
+		at := first.Add(time.Duration(float64(last.Sub(first)) * float64(tick) / (chartTicks - 1)))
+		chart.XLabels = append(chart.XLabels, label{X: x(at), Y: chart.Bottom + 20, Text: at.Format(time.DateOnly)})
+	}
+	chart.YLabels = chart.yLabels(minimum, maximum, format)
+
+	return chart
+}
+
+// equityChart returns a line chart of the equity of the account.
+func equityChart(curve *stats.EquityCurve) *lineChart {
+	times, values := make([]time.Time, 0, len(*curve)), make([]float64, 0, len(*curve))
+	lowest := math.Inf(1)
+	for _, point := range *curve {
+		times, values = append(times, point.Time), append(values, point.Equity)
+		lowest = math.Min(lowest, point.Equity)
+	}
+
+	return newLineChart(times, values, lowest, positiveFill, "%.0f")
+}
+
+// drawdownChart returns a line chart of how far the equity of the account was below its peak, as a percentage.
+func drawdownChart(curve *stats.EquityCurve) *lineChart {
+	times, values := make([]time.Time, 0, len(*curve)), make([]float64, 0, len(*curve))
+	for _, point := range *curve {
+		times, values = append(times, point.Time), append(values, -point.DrawdownPercent)
+	}
+
+	return newLineChart(times, values, 0, negativeFill, "%.1f%%")
+}
+
+// bar is one bar of a bar chart.
+type bar struct {
+	X      float64
+	Y      float64
+	Width  float64
+	Height float64
+	Fill   string
+	Title  string
+}
+
+// barChart is the SVG geometry of a bar chart.
+type barChart struct {
+	plot
+	Bars    []bar
+	XLabels []label
+	YLabels []label
+}
+
+// histogramChart returns a bar chart of the amount of trades in each bin, losses in red and wins in green.
+// It returns nil if there are no bins.
+func histogramChart(bins []*stats.Bin) *barChart {
+	if len(bins) == 0 {
+		return nil
+	}
+
+	chart := &barChart{plot: newPlot(chartWidth, chartHeight)}
+	most := 1
+	for _, bin := range bins {
+		most = max(most, bin.Trades)
+	}
+
+	// Label at most 20 bins so the labels do not overlap
+	width := (chart.Right - chart.Left) / float64(len(bins))
+	every := int(math.Ceil(float64(len(bins)) / 20))
+	for index, bin := range bins {
+		x := chart.Left + float64(index)*width
+		y := scale(float64(bin.Trades), 0, float64(most), chart.Bottom, chart.Top)
+		fill := positiveFill
+		if bin.From < 0 {
+			fill = negativeFill
+		}
+
+		chart.Bars = append(chart.Bars, bar{
+			X:      x + 1,
+			Y:      y,
+			Width:  math.Max(width-2, 1),
+			Height: chart.Bottom - y,
+			Fill:   fill,
+			Title:  fmt.Sprintf("%gR to %gR: %d trades", bin.From, bin.To, bin.Trades),
+		})
+		if index%every == 0 {
+			chart.XLabels = append(chart.XLabels, label{X: x, Y: chart.Bottom + 20, Text: fmt.Sprintf("%gR", bin.From)})
+		}
+	}
+	chart.YLabels = chart.yLabels(0, float64(most), "%.0f")
+
+	return chart
+}
+
+// cell is one square of a heatmap.
+type cell struct {
+	X       float64
+	Y       float64
+	Width   float64
+	Height  float64
+	Fill    string
+	Opacity float64
+	Label   label
+	Tooltip string
+}
+
+// heatmap is the SVG geometry of a grid of coloured values.
+type heatmap struct {
+	Width   float64
+	Height  float64
+	Cells   []cell
+	Columns []label
+	Rows    []label
+}
+
+// monthlyHeatmap returns a heatmap of the return of every month, with a row for each year and a column for
+// each month. The stronger the colour the larger the return. It returns nil if there are no returns.
+func monthlyHeatmap(returns []*stats.MonthlyReturn) *heatmap {
+	if len(returns) == 0 {
+		return nil
+	}
+
+	const cellWidth, cellHeight = 70.0, 30.0
+	firstYear, lastYear := returns[0].Year, returns[len(returns)-1].Year
+	chart := &heatmap{
+		Width:  margins.left + 12*cellWidth,
+		Height: margins.top + 20 + float64(lastYear-firstYear+1)*cellHeight,
+	}
+
+	largest := 0.0
+	for _, monthly := range returns {
+		largest = math.Max(largest, math.Abs(monthly.ReturnPercent))
+	}
+
+	top := margins.top + 20
+	for month := time.January; month <= time.December; month++ {
+		chart.Columns = append(chart.Columns, label{
+			X:    margins.left + float64(month-1)*cellWidth + cellWidth/2,
+			Y:    top - 6,
+			Text: month.String()[:3],
+		})
+	}
+	for year := firstYear; year <= lastYear; year++ {
+		chart.Rows = append(chart.Rows, label{
+			X:    margins.left - 8,
+			Y:    top + float64(year-firstYear)*cellHeight + cellHeight/2,
+			Text: fmt.Sprint(year),
+		})
+	}
+
+	for _, monthly := range returns {
+		fill, intensity := positiveFill, 0.0
+		if monthly.ReturnPercent < 0 {
+			fill = negativeFill
+		}
+		if largest > 0 {
+			intensity = math.Abs(monthly.ReturnPercent) / largest
+		}
+
+		x := margins.left + float64(monthly.Month-1)*cellWidth
+		y := top + float64(monthly.Year-firstYear)*cellHeight
+		chart.Cells = append(chart.Cells, cell{
+			X:       x,
+			Y:       y,
+			Width:   cellWidth - 2,
+			Height:  cellHeight - 2,
+			Fill:    fill,
+			Opacity: 0.1 + 0.9*intensity,
+			Label:   label{X: x + cellWidth/2, Y: y + cellHeight/2, Text: fmt.Sprintf("%.1f%%", monthly.ReturnPercent)},
+		})
+	}
+
+	return chart
+}
@@ -0,0 +1,70 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/stats"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewLineChart tests the values are scaled into the plot by time and value
+func TestNewLineChart(t *testing.T) {
+	start := time.Date(2023, 10, 16, 0, 0, 0, 0, time.UTC)
+	chart := newLineChart(
+		[]time.Time{start, start.Add(time.Hour), start.Add(4 * time.Hour)},
+		[]float64{0, 10, 5},
+		0,
+		positiveFill,
+		"%.0f",
+	)
+
+	// The first value is on the left baseline, the highest is at the top, and the last is on the right
+	require.Equal(t, "M80.0 250.0 L295.0 10.0 L940.0 130.0 ", chart.Line)
+	require.Equal(t, chart.Line+"L940.0 250.0 L80.0 250.0 Z", chart.Area)
+	require.Len(t, chart.XLabels, chartTicks)
+	require.Equal(t, "2023-10-16", chart.XLabels[0].Text)
+	require.Equal(t, "0", chart.YLabels[0].Text)
+	require.Equal(t, "10", chart.YLabels[chartTicks-1].Text)
+
+	require.Nil(t, newLineChart(nil, nil, 0, positiveFill, "%.0f"))
+}
+
+// TestHistogramChart tests a bar is drawn for every bin, coloured by whether it is a loss
+func TestHistogramChart(t *testing.T) {
+	chart := histogramChart([]*stats.Bin{
+		{From: -1, To: -0.5, Trades: 4},
+		{From: -0.5, To: 0, Trades: 0},
+		{From: 0, To: 0.5, Trades: 2},
+	})
+
+	require.Len(t, chart.Bars, 3)
+	require.Equal(t, negativeFill, chart.Bars[0].Fill)
+	require.Equal(t, chart.Top, chart.Bars[0].Y)
+	require.Zero(t, chart.Bars[1].Height)
+	require.Equal(t, positiveFill, chart.Bars[2].Fill)
+	require.Equal(t, chart.Bars[0].Height/2, chart.Bars[2].Height)
+	require.Equal(t, "0R to 0.5R: 2 trades", chart.Bars[2].Title)
+
+	require.Nil(t, histogramChart(nil))
+}
+
+// TestMonthlyHeatmap tests a cell is drawn for every month, in the row of its year and shaded by its return
+func TestMonthlyHeatmap(t *testing.T) {
+	chart := monthlyHeatmap([]*stats.MonthlyReturn{
+		{Year: 2023, Month: time.December, ReturnPercent: -2},
+		{Year: 2024, Month: time.January, ReturnPercent: 4},
+	})
+
+	require.Len(t, chart.Rows, 2)
+	require.Len(t, chart.Columns, 12)
+	require.Len(t, chart.Cells, 2)
+	require.Equal(t, negativeFill, chart.Cells[0].Fill)
+	require.InDelta(t, 0.55, chart.Cells[0].Opacity, 1e-9)
+	require.Equal(t, "-2.0%", chart.Cells[0].Label.Text)
+	require.Equal(t, 1.0, chart.Cells[1].Opacity)
+	require.Greater(t, chart.Cells[1].Y, chart.Cells[0].Y)
+	require.Less(t, chart.Cells[1].X, chart.Cells[0].X)
+
+	require.Nil(t, monthlyHeatmap(nil))
+}
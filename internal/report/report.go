@@ -0,0 +1,104 @@
+package report
+
+import (
+	"embed"
+	"html/template"
+	"io"
+	"os"
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/stats"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/tradeLog"
+)
+
+// HistogramBinWidth is the width in R of every bin of the R multiple histogram.
+const HistogramBinWidth = 0.5
+
+//go:embed templates
+var templates embed.FS
+
+// reportTemplates is the HTML report and trade chart templates, they have no external dependencies so work offline.
+var reportTemplates = template.Must(template.ParseFS(templates, "templates/*"))
+
+// Report is the results of a backtest to render as a single HTML file.
+type Report struct {
+	// Title is the heading of the report.
+	Title string
+
+	// RunTime is the time the backtest was run.
+	RunTime time.Time
+
+	// BaseCurrency is the currency every value in the report is in.
+	BaseCurrency string
+
+	// Summary is the performance statistics of the trades.
+	Summary *stats.Summary
+
+	// Equity is the equity curve to chart, along with its drawdown.
+	Equity *stats.EquityCurve
+
+	// Breakdown is the statistics of the trades grouped by every dimension.
+	Breakdown *stats.Breakdown
+
+	// Trades is every trade of the backtest.
+	Trades *tradeLog.Log
+}
+
+// breakdownTable is the groups of one dimension of the breakdown.
+type breakdownTable struct {
+	Dimension string
+	Groups    []*stats.Group
+}
+
+// view is the data the report template is executed with.
+type view struct {
+	*Report
+	Statistics    []stats.Line
+	EquityChart   *lineChart
+	DrawdownChart *lineChart
+	Histogram     *barChart
+	Heatmap       *heatmap
+	Breakdowns    []breakdownTable
+}
+
+// newView returns the charts and tables of the report.
+func (r *Report) newView() *view {
+	return &view{
+		Report:        r,
+		Statistics:    r.Summary.Lines(),
+		EquityChart:   equityChart(r.Equity),
+		DrawdownChart: drawdownChart(r.Equity),
+		Histogram:     histogramChart(stats.RHistogram(r.Trades, HistogramBinWidth)),
+		Heatmap:       monthlyHeatmap(stats.MonthlyReturns(r.Trades, r.Summary.StartingBalance)),
+		Breakdowns:    breakdownTables(r.Breakdown),
+	}
+}
+
+// breakdownTables splits the breakdown into a table for each dimension, in the order of the breakdown.
+func breakdownTables(breakdown *stats.Breakdown) []breakdownTable {
+	var tables []breakdownTable
+	for _, group := range *breakdown {
+		if len(tables) == 0 || tables[len(tables)-1].Dimension != group.Dimension {
+			tables = append(tables, breakdownTable{Dimension: group.Dimension})
+		}
+		tables[len(tables)-1].Groups = append(tables[len(tables)-1].Groups, group)
+	}
+
+	return tables
+}
+
+// Render writes the report as HTML.
+func (r *Report) Render(w io.Writer) error {
+	return reportTemplates.ExecuteTemplate(w, "report.html", r.newView())
+}
+
+// WriteHTML takes a file path and writes the report to an HTML file on disk, creating or overwriting it.
+func (r *Report) WriteHTML(filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return r.Render(file)
+}
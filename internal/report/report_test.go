@@ -0,0 +1,76 @@
+package report
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/stats"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/tradeLog"
+	"github.com/stretchr/testify/require"
+)
+
+// mockReport returns a report of a win and a loss on ES.
+func mockReport() *Report {
+	takenAt := time.Date(2023, 10, 16, 10, 0, 0, 0, time.UTC)
+	trades := &tradeLog.Log{
+		{Instrument: "ES", Direction: "LONG", TakenAt: takenAt, ClosedAtTime: takenAt.Add(time.Hour), Profit: 2, ProfitValue: 200, Win: true},
+		{Instrument: "ES", Direction: "SHORT", TakenAt: takenAt.AddDate(0, 0, 1), ClosedAtTime: takenAt.AddDate(0, 0, 1).Add(time.Hour), Profit: -1, ProfitValue: -100},
+	}
+
+	return &Report{
+		Title:        "Backtest <ES>",
+		RunTime:      takenAt,
+		BaseCurrency: "USD",
+		Summary:      stats.Calculate(trades, 1000),
+		Equity:       stats.TradeEquityCurve(trades, 1000),
+		Breakdown:    stats.BreakdownBy(trades, stats.StandardDimensions...),
+		Trades:       trades,
+	}
+}
+
+// TestRender tests every section of the report is rendered without any external resources
+func TestRender(t *testing.T) {
+	var html bytes.Buffer
+	require.NoError(t, mockReport().Render(&html))
+
+	contents := html.String()
+	require.Contains(t, contents, "<title>Backtest &lt;ES&gt;</title>")
+	require.Contains(t, contents, "Profit factor")
+	require.Contains(t, contents, `<path d="M80.0`)
+	require.Contains(t, contents, "2R to 2.5R: 1 trades")
+	require.Contains(t, contents, "Oct")
+	require.Contains(t, contents, "<th>Weekday</th>")
+	require.Contains(t, contents, `<tr class="win">`)
+	require.NotContains(t, contents, "src=")
+	require.NotContains(t, contents, "href=")
+}
+
+// TestRenderNoTrades tests a report of a backtest without trades still renders
+func TestRenderNoTrades(t *testing.T) {
+	trades := tradeLog.NewLog()
+	report := &Report{
+		Title:     "Backtest",
+		Summary:   stats.Calculate(trades, 1000),
+		Equity:    stats.TradeEquityCurve(trades, 1000),
+		Breakdown: stats.BreakdownBy(trades, stats.StandardDimensions...),
+		Trades:    trades,
+	}
+
+	var html bytes.Buffer
+	require.NoError(t, report.Render(&html))
+	require.Contains(t, html.String(), "No trades were taken.")
+}
+
+// TestWriteHTML tests the report can be written to disk
+func TestWriteHTML(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "report.html")
+	require.NoError(t, mockReport().WriteHTML(filePath))
+	require.FileExists(t, filePath)
+
+	contents, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	require.Contains(t, string(contents), "<svg")
+}
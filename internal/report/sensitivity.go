@@ -0,0 +1,108 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/optimize"
+)
+
+// missingFill is the colour of a cell of a sensitivity chart without evaluations.
+const missingFill = "#eeeeee"
+
+// SensitivityChart is the SVG geometry of the heatmap of the score of two optimised parameters.
+type SensitivityChart struct {
+	heatmap
+	Title  string
+	XTitle label
+	YTitle label
+}
+
+// NewSensitivityChart returns the chart of a heatmap, with a column for each value of its X parameter and a row for
+// each value of its Y parameter, the highest first. Each cell is shaded by its average score, the stronger the colour
+// the larger the score, green for positive and red for negative.
+func NewSensitivityChart(h *optimize.Heatmap) *SensitivityChart {
+	cellWidth := min(70.0, (chartWidth-margins.left-margins.right)/float64(max(len(h.XValues), 1)))
+	const cellHeight = 30.0
+	top := margins.top + 40
+
+	// Leave room for the title above a chart with few columns
+	chart := &SensitivityChart{
+		heatmap: heatmap{
+			Width:  max(480, margins.left+float64(len(h.XValues))*cellWidth+margins.right),
+			Height: top + float64(len(h.YValues))*cellHeight + 50,
+		},
+		Title: fmt.Sprintf("Average score by %s and %s", h.X, h.Y),
+	}
+	bottom := top + float64(len(h.YValues))*cellHeight
+	chart.XTitle = label{X: margins.left + float64(len(h.XValues))*cellWidth/2, Y: bottom + 40, Text: h.X}
+	chart.YTitle = label{X: 10, Y: top - 10, Text: h.Y}
+
+	largest := 0.0
+	for _, cells := range h.Cells {
+		for _, c := range cells {
+			if c != nil {
+				largest = math.Max(largest, math.Abs(c.Average))
+			}
+		}
+	}
+
+	for column, value := range h.XValues {
+		chart.Columns = append(chart.Columns, label{
+			X:    margins.left + float64(column)*cellWidth + cellWidth/2,
+			Y:    bottom + 18,
+			Text: fmt.Sprintf("%.4g", value),
+		})
+	}
+	for row, value := range h.YValues {
+		// Draw the highest value at the top
+		y := bottom - float64(row+1)*cellHeight
+		chart.Rows = append(chart.Rows, label{X: margins.left - 8, Y: y + cellHeight/2, Text: fmt.Sprintf("%.4g", value)})
+
+		for column, c := range h.Cells[row] {
+			x := margins.left + float64(column)*cellWidth
+			square := cell{X: x, Y: y, Width: cellWidth - 2, Height: cellHeight - 2, Fill: missingFill, Opacity: 1}
+			if c != nil {
+				square.Fill, square.Opacity = positiveFill, 0.1
+				if c.Average < 0 {
+					square.Fill = negativeFill
+				}
+				if largest > 0 {
+					square.Opacity = 0.1 + 0.9*math.Abs(c.Average)/largest
+				}
+				square.Label = label{X: x + cellWidth/2, Y: y + cellHeight/2, Text: fmt.Sprintf("%.2f", c.Average)}
+				square.Tooltip = fmt.Sprintf(
+					"%s %.4g, %s %.4g: average %.2f, best %.2f over %d evaluations",
+					h.X,
+					h.XValues[column],
+					h.Y,
+					value,
+					c.Average,
+					c.Best,
+					c.Evaluations,
+				)
+			}
+			chart.Cells = append(chart.Cells, square)
+		}
+	}
+
+	return chart
+}
+
+// Render writes the chart as an SVG document.
+func (c *SensitivityChart) Render(w io.Writer) error {
+	return reportTemplates.ExecuteTemplate(w, "sensitivity.svg", c)
+}
+
+// WriteSVG takes a file path and writes the chart to an SVG file on disk, creating or overwriting it.
+func (c *SensitivityChart) WriteSVG(filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return c.Render(file)
+}
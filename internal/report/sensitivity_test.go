@@ -0,0 +1,63 @@
+package report
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/optimize"
+	"github.com/stretchr/testify/require"
+)
+
+// mockSensitivity returns a heatmap of 2 values of MinimumRR and StopSizeAddition with one cell not evaluated.
+func mockSensitivity() *optimize.Heatmap {
+	return &optimize.Heatmap{
+		X:       "MinimumRR",
+		Y:       "StopSizeAddition",
+		XValues: []float64{1.5, 2},
+		YValues: []float64{2, 3},
+		Cells: [][]*optimize.HeatmapCell{
+			{{Evaluations: 3, Average: 4, Best: 6}, {Evaluations: 3, Average: -2, Best: 1}},
+			{{Evaluations: 3, Average: 1, Best: 2}, nil},
+		},
+	}
+}
+
+// TestNewSensitivityChart tests a cell is drawn for every pair of values, the highest row at the top, shaded by its
+// average score and greyed out where nothing was evaluated
+func TestNewSensitivityChart(t *testing.T) {
+	chart := NewSensitivityChart(mockSensitivity())
+
+	require.Len(t, chart.Columns, 2)
+	require.Len(t, chart.Rows, 2)
+	require.Len(t, chart.Cells, 4)
+	require.Equal(t, "1.5", chart.Columns[0].Text)
+	require.Less(t, chart.Rows[1].Y, chart.Rows[0].Y)
+
+	require.Equal(t, positiveFill, chart.Cells[0].Fill)
+	require.Equal(t, 1.0, chart.Cells[0].Opacity)
+	require.Equal(t, "4.00", chart.Cells[0].Label.Text)
+	require.Equal(t, negativeFill, chart.Cells[1].Fill)
+	require.InDelta(t, 0.55, chart.Cells[1].Opacity, 1e-9)
+	require.Contains(t, chart.Cells[1].Tooltip, "best 1.00 over 3 evaluations")
+	require.Equal(t, missingFill, chart.Cells[3].Fill)
+	require.Empty(t, chart.Cells[3].Label.Text)
+}
+
+// TestSensitivityChartWriteSVG tests the chart is rendered as an SVG document and written to disk
+func TestSensitivityChartWriteSVG(t *testing.T) {
+	chart := NewSensitivityChart(mockSensitivity())
+
+	var svg bytes.Buffer
+	require.NoError(t, chart.Render(&svg))
+	require.Contains(t, svg.String(), "<svg width=")
+	require.Contains(t, svg.String(), "Average score by MinimumRR and StopSizeAddition")
+	require.Contains(t, svg.String(), "<title>MinimumRR 1.5, StopSizeAddition 2: average 4.00, best 6.00 over 3 evaluations</title>")
+
+	filePath := filepath.Join(t.TempDir(), "MinimumRR-StopSizeAddition.svg")
+	require.NoError(t, chart.WriteSVG(filePath))
+	contents, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	require.Equal(t, svg.String(), string(contents))
+}
@@ -0,0 +1,267 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/backtestData"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/tradeConfig"
+)
+
+const (
+	// tradeChartHeight is the height of every trade chart.
+	tradeChartHeight = 480.0
+
+	// entryFill is the colour of the entry marker of a trade chart.
+	entryFill = "#1565c0"
+
+	// targetStroke is the colour of the target of a trade chart.
+	targetStroke = "#2e7d32"
+
+	// highBoundaryStroke is the colour of the unbroken highs of a trade chart.
+	highBoundaryStroke = "#8d6e63"
+
+	// lowBoundaryStroke is the colour of the unbroken lows of a trade chart.
+	lowBoundaryStroke = "#546e7a"
+
+	// sweptStroke is the colour of the boundary the entry candle swept.
+	sweptStroke = "#6a1b9a"
+)
+
+// candle is one candlestick of a trade chart.
+type candle struct {
+	X          float64
+	HighY      float64
+	LowY       float64
+	BodyX      float64
+	BodyY      float64
+	BodyWidth  float64
+	BodyHeight float64
+	Fill       string
+}
+
+// level is a line drawn at a price in a trade chart, with a label at its end.
+type level struct {
+	X1     float64
+	X2     float64
+	Y      float64
+	Stroke string
+	Label  label
+}
+
+// marker is a point of interest in a trade chart, such as the entry or exit.
+type marker struct {
+	X     float64
+	Y     float64
+	Fill  string
+	Label label
+}
+
+// TradeChart is the SVG geometry of a candlestick chart of one trade and the candles around it.
+type TradeChart struct {
+	plot
+	Title      string
+	Candles    []candle
+	SmallSMA   string
+	LargeSMA   string
+	Boundaries []level
+	Swept      *level
+	Target     level
+	Stop       string
+	Markers    []marker
+	XLabels    []label
+	YLabels    []label
+}
+
+// TradeChartFileName returns the name of the SVG file a trade is charted in, named by its instrument,
+// entry time and direction so every trade has its own file.
+func TradeChartFileName(trade *tradeConfig.Trade) string {
+	return fmt.Sprintf("%s-%s-%s.svg", trade.Instrument, trade.TakenAt.UTC().Format("2006-01-02-15_04_05"), trade.Direction)
+}
+
+// NewTradeChart returns a candlestick chart of a closed trade from the processed data of its instrument,
+// showing barsBefore candles before the entry and barsAfter candles after the exit.
+// It returns an error if the entry candle is not in the data.
+func NewTradeChart(trade *tradeConfig.Trade, data backtestData.Data, barsBefore, barsAfter int) (*TradeChart, error) {
+	// Find the entry and exit candles, the trade is taken on the close of the entry candle
+	search := func(at time.Time) int {
+		return sort.Search(len(data), func(index int) bool {
+			return !data[index].Time.Before(at)
+		})
+	}
+	entryIndex, exitIndex := search(trade.TakenAt), search(trade.ClosedAtTime)
+	if entryIndex == len(data) || !data[entryIndex].Time.Equal(trade.TakenAt) {
+		return nil, fmt.Errorf("cannot chart the %s trade taken at %v as its entry candle is not in the data", trade.Instrument, trade.TakenAt)
+	}
+	exitIndex = min(exitIndex, len(data)-1)
+
+	rows := data[max(entryIndex-barsBefore, 0) : min(exitIndex+barsAfter, len(data)-1)+1]
+	entry := data[entryIndex]
+
+	chart := &TradeChart{
+		plot: newPlot(chartWidth, tradeChartHeight),
+		Title: fmt.Sprintf(
+			"%s %s %s, %.2fR %s",
+			trade.Instrument,
+			trade.Direction,
+			trade.TakenAt.Format("2006-01-02 15:04"),
+			trade.ProfitR(),
+			trade.ExitReason,
+		),
+	}
+	chart.Top += 20
+
+	// Scale the prices to the candles and every level of the trade
+	minimum, maximum := math.Inf(1), math.Inf(-1)
+	include := func(prices ...float64) {
+		for _, price := range prices {
+			if price != 0 {
+				minimum, maximum = math.Min(minimum, price), math.Max(maximum, price)
+			}
+		}
+	}
+	for _, row := range rows {
+		include(row.High, row.Low)
+	}
+	include(trade.EntryPrice, trade.InitialStopPrice, trade.TargetPrice, trade.ClosedAtPrice, trade.Context.SweptBoundaryValue)
+	for _, move := range trade.StopMoves {
+		include(move.Price)
+	}
+	if minimum == maximum {
+		minimum, maximum = minimum-1, maximum+1
+	}
+
+	step := (chart.Right - chart.Left) / float64(len(rows))
+	x := func(index int) float64 {
+		return chart.Left + (float64(index)+0.5)*step
+	}
+	xAt := func(at time.Time) float64 {
+		index := sort.Search(len(rows), func(index int) bool {
+			return !rows[index].Time.Before(at)
+		})
+		return x(min(index, len(rows)-1))
+	}
+	y := func(price float64) float64 {
+		return scale(price, minimum, maximum, chart.Bottom, chart.Top)
+	}
+	inRange := func(price float64) bool {
+		return price >= minimum && price <= maximum
+	}
+
+	for index, row := range rows {
+		fill := negativeFill
+		if row.Close >= row.Open {
+			fill = positiveFill
+		}
+		chart.Candles = append(chart.Candles, candle{
+			X:          x(index),
+			HighY:      y(row.High),
+			LowY:       y(row.Low),
+			BodyX:      x(index) - step*0.35,
+			BodyY:      y(math.Max(row.Open, row.Close)),
+			BodyWidth:  step * 0.7,
+			BodyHeight: math.Max(y(math.Min(row.Open, row.Close))-y(math.Max(row.Open, row.Close)), 1),
+			Fill:       fill,
+		})
+
+		// Only draw the moving averages once they have been calculated
+		if inRange(row.SmallSMA) {
+			chart.SmallSMA += fmt.Sprintf("%.1f,%.1f ", x(index), y(row.SmallSMA))
+		}
+		if inRange(row.LargeSMA) {
+			chart.LargeSMA += fmt.Sprintf("%.1f,%.1f ", x(index), y(row.LargeSMA))
+		}
+	}
+
+	// Draw the boundaries that were unbroken on the entry candle from where they were defined
+	for _, boundaries := range []struct {
+		boundaries backtestData.Boundaries
+		stroke     string
+	}{{entry.HighBoundaries, highBoundaryStroke}, {entry.LowBoundaries, lowBoundaryStroke}} {
+		for _, boundary := range boundaries.boundaries {
+			if boundary.Broken || !inRange(boundary.Value) {
+				continue
+			}
+			chart.Boundaries = append(chart.Boundaries, level{
+				X1:     xAt(boundary.Time),
+				X2:     chart.Right,
+				Y:      y(boundary.Value),
+				Stroke: boundaries.stroke,
+				Label:  label{X: chart.Right, Y: y(boundary.Value) - 3, Text: fmt.Sprint(boundary.Value)},
+			})
+		}
+	}
+
+	entryX, exitX := xAt(trade.TakenAt), xAt(trade.ClosedAtTime)
+	if trade.Context.SweptBoundaryValue != 0 {
+		chart.Swept = &level{
+			X1:     xAt(trade.Context.SweptBoundaryTime),
+			X2:     entryX,
+			Y:      y(trade.Context.SweptBoundaryValue),
+			Stroke: sweptStroke,
+			Label:  label{X: entryX, Y: y(trade.Context.SweptBoundaryValue) - 3, Text: "Swept"},
+		}
+	}
+	chart.Target = level{
+		X1:     entryX,
+		X2:     exitX,
+		Y:      y(trade.TargetPrice),
+		Stroke: targetStroke,
+		Label:  label{X: exitX, Y: y(trade.TargetPrice) - 3, Text: fmt.Sprintf("Target %v", trade.TargetPrice)},
+	}
+
+	// Step the stop from its initial price to every price it was moved to
+	chart.Stop = fmt.Sprintf("M%.1f %.1f ", entryX, y(trade.InitialStopPrice))
+	for _, move := range trade.StopMoves {
+		chart.Stop += fmt.Sprintf("H%.1f V%.1f ", xAt(move.Time), y(move.Price))
+	}
+	chart.Stop += fmt.Sprintf("H%.1f", exitX)
+
+	exitFill := negativeFill
+	if trade.ProfitR() > 0 {
+		exitFill = positiveFill
+	}
+	chart.Markers = []marker{
+		{
+			X:     entryX,
+			Y:     y(trade.EntryPrice),
+			Fill:  entryFill,
+			Label: label{X: entryX, Y: y(trade.EntryPrice) + 16, Text: fmt.Sprintf("Entry %v", trade.EntryPrice)},
+		},
+		{
+			X:     exitX,
+			Y:     y(trade.ClosedAtPrice),
+			Fill:  exitFill,
+			Label: label{X: exitX, Y: y(trade.ClosedAtPrice) + 16, Text: fmt.Sprintf("Exit %v", trade.ClosedAtPrice)},
+		},
+	}
+
+	// Label the time axis at most every 8 candles so the labels do not overlap
+	every := max(len(rows)/8, 1)
+	for index := 0; index < len(rows); index += every {
+		chart.XLabels = append(chart.XLabels, label{X: x(index), Y: chart.Bottom + 20, Text: rows[index].Time.Format("01-02 15:04")})
+	}
+	chart.YLabels = chart.yLabels(minimum, maximum, "%.2f")
+
+	return chart, nil
+}
+
+// Render writes the chart as an SVG document.
+func (c *TradeChart) Render(w io.Writer) error {
+	return reportTemplates.ExecuteTemplate(w, "trade.svg", c)
+}
+
+// WriteSVG takes a file path and writes the chart to an SVG file on disk, creating or overwriting it.
+func (c *TradeChart) WriteSVG(filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return c.Render(file)
+}
@@ -0,0 +1,119 @@
+package report
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/backtestData"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/tradeConfig"
+	"github.com/stretchr/testify/require"
+)
+
+// mockTradeStart is the time of the first mock candle.
+var mockTradeStart = time.Date(2023, 10, 20, 9, 0, 0, 0, time.UTC)
+
+// mockTradeData returns ten five minute candles that rise by one point each, with two unbroken highs on the
+// entry candle, one far above the chart, and the broken low that was swept.
+func mockTradeData() backtestData.Data {
+	var data backtestData.Data
+	for index := 0; index < 10; index++ {
+		price := 100 + float64(index)
+		data = append(data, &backtestData.Row{
+			Time:     mockTradeStart.Add(time.Duration(index) * 5 * time.Minute),
+			Open:     price,
+			High:     price + 1.5,
+			Low:      price - 1.5,
+			Close:    price + 1,
+			SmallSMA: price,
+		})
+	}
+	data[3].HighBoundaries = backtestData.Boundaries{
+		{Time: mockTradeStart, Value: 110},
+		{Time: mockTradeStart, Value: 500},
+	}
+	data[3].LowBoundaries = backtestData.Boundaries{
+		{Time: mockTradeStart.Add(5 * time.Minute), Value: 98, Broken: true},
+	}
+
+	return data
+}
+
+// mockTrade returns a LONG trade taken on the fourth candle that moved its stop once and hit its target.
+func mockTrade() *tradeConfig.Trade {
+	return &tradeConfig.Trade{
+		Instrument:       "ES",
+		Direction:        "LONG",
+		TakenAt:          mockTradeStart.Add(15 * time.Minute),
+		EntryPrice:       104,
+		StopPrice:        104,
+		InitialStopPrice: 98,
+		TargetPrice:      110,
+		ClosedAtPrice:    110,
+		ClosedAtTime:     mockTradeStart.Add(35 * time.Minute),
+		ExitReason:       tradeConfig.ExitReason.TARGET,
+		StopMoves:        []tradeConfig.StopMove{{Time: mockTradeStart.Add(25 * time.Minute), Price: 104}},
+		Context:          tradeConfig.Context{SweptBoundaryTime: mockTradeStart.Add(5 * time.Minute), SweptBoundaryValue: 98},
+	}
+}
+
+// TestNewTradeChart tests the window of candles and every level of the trade is drawn
+func TestNewTradeChart(t *testing.T) {
+	chart, err := NewTradeChart(mockTrade(), mockTradeData(), 2, 1)
+	require.NoError(t, err)
+
+	// Two candles before the entry on the fourth candle, and one after the exit on the eighth candle
+	require.Len(t, chart.Candles, 8)
+	require.Equal(t, "ES LONG 2023-10-20 09:15, 1.00R TARGET", chart.Title)
+
+	// Only the unbroken high in the price range of the chart is drawn
+	require.Len(t, chart.Boundaries, 1)
+	require.Equal(t, "110", chart.Boundaries[0].Label.Text)
+
+	// The swept low is drawn from the candle that defined it to the entry, which is the third candle of the window
+	entryX, exitX := chart.Candles[2].X, chart.Candles[6].X
+	require.NotNil(t, chart.Swept)
+	require.Equal(t, chart.Candles[0].X, chart.Swept.X1)
+	require.Equal(t, entryX, chart.Swept.X2)
+
+	// The exit is on the seventh candle of the window and the stop steps up between them
+	require.Equal(t, entryX, chart.Markers[0].X)
+	require.Equal(t, exitX, chart.Markers[1].X)
+	require.Equal(t, positiveFill, chart.Markers[1].Fill)
+	require.Contains(t, chart.Stop, "V")
+	require.Equal(t, "Target 110", chart.Target.Label.Text)
+	require.NotEmpty(t, chart.SmallSMA)
+	require.Empty(t, chart.LargeSMA)
+}
+
+// TestNewTradeChartMissingEntry tests a trade whose entry is not in the data cannot be charted
+func TestNewTradeChartMissingEntry(t *testing.T) {
+	trade := mockTrade()
+	trade.TakenAt = trade.TakenAt.Add(time.Minute)
+
+	_, err := NewTradeChart(trade, mockTradeData(), 2, 1)
+	require.Error(t, err)
+}
+
+// TestTradeChartWriteSVG tests the chart is rendered as an SVG document and written to disk
+func TestTradeChartWriteSVG(t *testing.T) {
+	chart, err := NewTradeChart(mockTrade(), mockTradeData(), 2, 1)
+	require.NoError(t, err)
+
+	var svg bytes.Buffer
+	require.NoError(t, chart.Render(&svg))
+	require.Contains(t, svg.String(), `<svg width="960"`)
+	require.Contains(t, svg.String(), `<polyline class="small-sma"`)
+	require.Contains(t, svg.String(), "Entry 104")
+
+	trade := mockTrade()
+	require.Equal(t, "ES-2023-10-20-09_15_00-LONG.svg", TradeChartFileName(trade))
+	filePath := filepath.Join(t.TempDir(), TradeChartFileName(trade))
+	require.NoError(t, chart.WriteSVG(filePath))
+
+	contents, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	require.Equal(t, svg.String(), string(contents))
+}
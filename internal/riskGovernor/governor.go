@@ -0,0 +1,184 @@
+package riskGovernor
+
+import (
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/utils"
+)
+
+var (
+	// Scope is an equivalent to an enum for which level of governor suppressed a trade.
+	Scope = scope{INSTRUMENT: "INSTRUMENT", ACCOUNT: "ACCOUNT"}
+
+	// Reason is an equivalent to an enum for why a governor suppressed a trade.
+	Reason = reason{
+		MaxTrades:         "MAX_TRADES_PER_SESSION",
+		MaxDailyLoss:      "MAX_DAILY_LOSS",
+		ConsecutiveLosses: "MAX_CONSECUTIVE_LOSSES",
+		ProfitTarget:      "DAILY_PROFIT_TARGET",
+	}
+)
+
+type scope struct {
+	INSTRUMENT string
+	ACCOUNT    string
+}
+
+type reason struct {
+	MaxTrades         string
+	MaxDailyLoss      string
+	ConsecutiveLosses string
+	ProfitTarget      string
+}
+
+// dailyState is the running tally for one trading day that the governors are checked against, and the amount of
+// trades entered in the current session, which can start on one day and end on the next.
+type dailyState struct {
+	// day is the date this state applies to in the format 2006-01-02.
+	day string
+
+	// session is the key of the session the trades are counted in.
+	session string
+
+	// trades is the amount of trades entered in the session.
+	trades int
+
+	// profitR is the realised profit or loss in R on this day.
+	profitR float64
+
+	// profitValue is the realised profit or loss in currency on this day.
+	profitValue float64
+
+	// consecutiveLosses is the amount of losing trades in a row on this day.
+	consecutiveLosses int
+}
+
+// rollover resets the daily tallies if the given time is on a different day to the one being tracked, the trades of
+// the session are kept.
+func (s *dailyState) rollover(at time.Time) {
+	day := at.Format("2006-01-02")
+	if s.day != day {
+		*s = dailyState{day: day, session: s.session, trades: s.trades}
+	}
+}
+
+// enterSession resets the trades if the given session is not the one being tracked.
+func (s *dailyState) enterSession(session string) {
+	if s.session != session {
+		s.session, s.trades = session, 0
+	}
+}
+
+// breached returns the reason the configuration has been breached by the state, or an empty string if it has not.
+func breached(config *utils.RiskGovernorConfiguration, s *dailyState) string {
+	if config == nil {
+		return ""
+	}
+
+	switch {
+	case config.MaxTradesPerSession > 0 && s.trades >= config.MaxTradesPerSession:
+		return Reason.MaxTrades
+	case config.MaxDailyLossR > 0 && -s.profitR >= config.MaxDailyLossR:
+		return Reason.MaxDailyLoss
+	case config.MaxDailyLossValue > 0 && -s.profitValue >= config.MaxDailyLossValue:
+		return Reason.MaxDailyLoss
+	case config.MaxConsecutiveLosses > 0 && s.consecutiveLosses >= config.MaxConsecutiveLosses:
+		return Reason.ConsecutiveLosses
+	case config.DailyProfitTargetR > 0 && s.profitR >= config.DailyProfitTargetR:
+		return Reason.ProfitTarget
+	case config.DailyProfitTargetValue > 0 && s.profitValue >= config.DailyProfitTargetValue:
+		return Reason.ProfitTarget
+	}
+
+	return ""
+}
+
+// Governor tracks the daily state of each instrument and the whole account
+// and decides if a new trade is allowed to be entered.
+type Governor struct {
+	// accountConfig is the set of guardrails applied across all instruments.
+	accountConfig *utils.RiskGovernorConfiguration
+
+	// instrumentConfigs is a mapping of instrument name to its own guardrails.
+	instrumentConfigs map[string]*utils.RiskGovernorConfiguration
+
+	// accountState is the daily tally across all instruments.
+	accountState *dailyState
+
+	// instrumentStates is a mapping of instrument name to its daily tally.
+	instrumentStates map[string]*dailyState
+}
+
+// New creates a Governor from the account wide configuration and the configuration of every instrument.
+func New(
+	accountConfig *utils.RiskGovernorConfiguration,
+	instruments map[string]*utils.InstrumentConfiguration,
+) *Governor {
+	instrumentConfigs := make(map[string]*utils.RiskGovernorConfiguration, len(instruments))
+	for instrument, instrumentConfig := range instruments {
+		instrumentConfigs[instrument] = instrumentConfig.RiskGovernor
+	}
+
+	return &Governor{
+		accountConfig:     accountConfig,
+		instrumentConfigs: instrumentConfigs,
+		accountState:      &dailyState{},
+		instrumentStates:  make(map[string]*dailyState),
+	}
+}
+
+// instrumentState returns the daily state for an instrument, creating it if it does not exist yet.
+func (g *Governor) instrumentState(instrument string) *dailyState {
+	state, ok := g.instrumentStates[instrument]
+	if !ok {
+		state = &dailyState{}
+		g.instrumentStates[instrument] = state
+	}
+
+	return state
+}
+
+// CanEnter checks every governor for an instrument in a session at a given time. The session is any key that is the
+// same for every entry in one session of a region, so the account counts the trades of every instrument in it.
+// It returns the scope and reason of the first governor breached, or two empty strings if the trade is allowed.
+func (g *Governor) CanEnter(instrument, session string, at time.Time) (string, string) {
+	instrumentState := g.instrumentState(instrument)
+	instrumentState.rollover(at)
+	instrumentState.enterSession(session)
+	if breachedReason := breached(g.instrumentConfigs[instrument], instrumentState); breachedReason != "" {
+		return Scope.INSTRUMENT, breachedReason
+	}
+
+	g.accountState.rollover(at)
+	g.accountState.enterSession(session)
+	if breachedReason := breached(g.accountConfig, g.accountState); breachedReason != "" {
+		return Scope.ACCOUNT, breachedReason
+	}
+
+	return "", ""
+}
+
+// RecordEntry adds a trade entered in a session at the given time to the tallies.
+func (g *Governor) RecordEntry(instrument, session string, at time.Time) {
+	for _, state := range []*dailyState{g.instrumentState(instrument), g.accountState} {
+		state.rollover(at)
+		state.enterSession(session)
+		state.trades++
+	}
+}
+
+// RecordExit adds the result of a trade closed at the given time to the daily tallies.
+func (g *Governor) RecordExit(instrument string, at time.Time, profitR, profitValue float64) {
+	for _, state := range []*dailyState{g.instrumentState(instrument), g.accountState} {
+		state.rollover(at)
+		state.profitR += profitR
+		state.profitValue += profitValue
+
+		// Only a losing trade continues the streak, anything else resets it
+		if profitR < 0 {
+			state.consecutiveLosses++
+		} else {
+			state.consecutiveLosses = 0
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package riskGovernor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBreached tests every governor in the breached function
+func TestBreached(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *utils.RiskGovernorConfiguration
+		state  *dailyState
+		want   string
+	}{
+		{"nil config", nil, &dailyState{trades: 100}, ""},
+		{"nothing configured", &utils.RiskGovernorConfiguration{}, &dailyState{trades: 100, profitR: -10}, ""},
+		{"max trades", &utils.RiskGovernorConfiguration{MaxTradesPerSession: 2}, &dailyState{trades: 2}, Reason.MaxTrades},
+		{"under max trades", &utils.RiskGovernorConfiguration{MaxTradesPerSession: 2}, &dailyState{trades: 1}, ""},
+		{"max loss R", &utils.RiskGovernorConfiguration{MaxDailyLossR: 2}, &dailyState{profitR: -2}, Reason.MaxDailyLoss},
+		{"max loss value", &utils.RiskGovernorConfiguration{MaxDailyLossValue: 500}, &dailyState{profitValue: -600}, Reason.MaxDailyLoss},
+		{"consecutive losses", &utils.RiskGovernorConfiguration{MaxConsecutiveLosses: 3}, &dailyState{consecutiveLosses: 3}, Reason.ConsecutiveLosses},
+		{"profit target R", &utils.RiskGovernorConfiguration{DailyProfitTargetR: 3}, &dailyState{profitR: 3.5}, Reason.ProfitTarget},
+		{"profit target value", &utils.RiskGovernorConfiguration{DailyProfitTargetValue: 1000}, &dailyState{profitValue: 1000}, Reason.ProfitTarget},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, breached(tt.config, tt.state))
+		})
+	}
+}
+
+// TestDailyStateRollover tests that the state is only reset when the day changes
+func TestDailyStateRollover(t *testing.T) {
+	day := time.Date(2023, 10, 20, 9, 0, 0, 0, time.UTC)
+	state := &dailyState{}
+
+	state.rollover(day)
+	state.trades = 2
+	state.rollover(day.Add(time.Hour))
+	require.Equal(t, 2, state.trades, "state should persist within the same day")
+
+	state.profitR = -2
+	state.rollover(day.Add(24 * time.Hour))
+	require.Zero(t, state.profitR, "state should reset on a new day")
+	require.Equal(t, 2, state.trades, "trades should persist within the same session")
+	require.Equal(t, "2023-10-21", state.day)
+}
+
+// TestDailyStateEnterSession tests that the trades are only reset when the session changes
+func TestDailyStateEnterSession(t *testing.T) {
+	state := &dailyState{}
+
+	state.enterSession("Asia|2023-10-20")
+	state.trades = 2
+	state.enterSession("Asia|2023-10-20")
+	require.Equal(t, 2, state.trades, "trades should persist within the same session")
+
+	state.enterSession("London|2023-10-21")
+	require.Zero(t, state.trades, "trades should reset in a new session")
+}
+
+// TestGovernorMaxTradesPerSession tests that the trades of a session held overnight count towards it the next day
+func TestGovernorMaxTradesPerSession(t *testing.T) {
+	day := time.Date(2023, 10, 20, 23, 0, 0, 0, time.UTC)
+	governor := New(&utils.RiskGovernorConfiguration{MaxTradesPerSession: 1}, map[string]*utils.InstrumentConfiguration{
+		"ES": {},
+		"NQ": {},
+	})
+
+	governor.RecordEntry("ES", "Asia|2023-10-20", day)
+	governorScope, governorReason := governor.CanEnter("NQ", "Asia|2023-10-20", day.Add(2*time.Hour))
+	require.Equal(t, Scope.ACCOUNT, governorScope)
+	require.Equal(t, Reason.MaxTrades, governorReason)
+
+	governorScope, governorReason = governor.CanEnter("NQ", "London|2023-10-21", day.Add(9*time.Hour))
+	require.Empty(t, governorScope)
+	require.Empty(t, governorReason)
+}
+
+// TestGovernor tests that the instrument governor only stops its own instrument
+// and the account governor stops every instrument
+func TestGovernor(t *testing.T) {
+	day := time.Date(2023, 10, 20, 9, 0, 0, 0, time.UTC)
+	governor := New(
+		&utils.RiskGovernorConfiguration{MaxDailyLossR: 3},
+		map[string]*utils.InstrumentConfiguration{
+			"ES": {RiskGovernor: &utils.RiskGovernorConfiguration{MaxConsecutiveLosses: 2}},
+			"NQ": {},
+		},
+	)
+
+	// Two ES losses breach the ES governor only
+	for i := 0; i < 2; i++ {
+		governor.RecordEntry("ES", "New York|2023-10-20", day)
+		governor.RecordExit("ES", day.Add(time.Minute), -1, -100)
+	}
+	governorScope, governorReason := governor.CanEnter("ES", "New York|2023-10-20", day.Add(time.Hour))
+	require.Equal(t, Scope.INSTRUMENT, governorScope)
+	require.Equal(t, Reason.ConsecutiveLosses, governorReason)
+	governorScope, governorReason = governor.CanEnter("NQ", "New York|2023-10-20", day.Add(time.Hour))
+	require.Empty(t, governorScope)
+	require.Empty(t, governorReason)
+
+	// A third loss on NQ breaches the account governor
+	governor.RecordEntry("NQ", "New York|2023-10-20", day.Add(time.Hour))
+	governor.RecordExit("NQ", day.Add(2*time.Hour), -1, -100)
+	governorScope, governorReason = governor.CanEnter("NQ", "New York|2023-10-20", day.Add(3*time.Hour))
+	require.Equal(t, Scope.ACCOUNT, governorScope)
+	require.Equal(t, Reason.MaxDailyLoss, governorReason)
+
+	// Everything is reset the next day
+	governorScope, governorReason = governor.CanEnter("ES", "New York|2023-10-21", day.Add(24*time.Hour))
+	require.Empty(t, governorScope)
+	require.Empty(t, governorReason)
+}
@@ -0,0 +1,118 @@
+package stats
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/portfolio"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/tradeConfig"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/tradeLog"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/utils"
+)
+
+// Baseline is the result of trading random entries with the same stops and targets as the trades of a log.
+type Baseline struct {
+	// Simulations is the amount of times every trade was replaced with a random entry.
+	Simulations int `json:"Simulations"`
+
+	// ExpectancyR is the average profit of a trade in R of the trades being tested.
+	ExpectancyR float64 `json:"ExpectancyR"`
+
+	// RandomExpectancyR is the average profit of a random entry in R across every simulation.
+	RandomExpectancyR float64 `json:"RandomExpectancyR"`
+
+	// PValue is the probability of random entries averaging at least ExpectancyR.
+	PValue float64 `json:"PValue"`
+}
+
+// RandomEntryBaseline replaces every trade of a log with an entry on a random candle of a random session of
+// the same instrument and region, in the same direction with the same stop and target distances, and simulates it
+// through tradeConfig.Trade.ValidateTradeWithWindow. This is repeated for the given amount of simulations to find how
+// often random entries do at least as well as the trades. Trades without a session, contract specification or
+// instrument configuration are left out. The same seed always picks the same entries.
+func RandomEntryBaseline(
+	l *tradeLog.Log,
+	sessions []*portfolio.Session,
+	instruments map[string]*utils.InstrumentConfiguration,
+	specs utils.ContractSpecRegistry,
+	simulations int,
+	seed int64,
+) *Baseline {
+	baseline := &Baseline{Simulations: simulations, PValue: 1}
+
+	// Group the sessions that can be entered by instrument and region
+	windows := make(map[string][]*portfolio.Session)
+	for _, session := range sessions {
+		if len(session.Data) > 1 {
+			key := session.Instrument + "|" + session.Region
+			windows[key] = append(windows[key], session)
+		}
+	}
+
+	var rows []*tradeLog.Row
+	for _, row := range *l {
+		_, hasSpec := specs[row.Instrument]
+		if len(windows[row.Instrument+"|"+row.Region]) > 0 && hasSpec && instruments[row.Instrument] != nil {
+			rows = append(rows, row)
+			baseline.ExpectancyR += float64(row.Profit)
+		}
+	}
+	if len(rows) == 0 || simulations <= 0 {
+		return baseline
+	}
+	baseline.ExpectancyR /= float64(len(rows))
+
+	random := rand.New(rand.NewSource(seed))
+	atLeastAsGood := 0
+	for simulation := 0; simulation < simulations; simulation++ {
+		var total float64
+		for _, row := range rows {
+			total += randomEntry(random, row, windows[row.Instrument+"|"+row.Region], instruments[row.Instrument], specs[row.Instrument].TickSize)
+		}
+
+		expectancy := total / float64(len(rows))
+		baseline.RandomExpectancyR += expectancy / float64(simulations)
+		if expectancy >= baseline.ExpectancyR {
+			atLeastAsGood++
+		}
+	}
+
+	// Count the trades as one of the simulations so the p-value is never 0
+	baseline.PValue = float64(atLeastAsGood+1) / float64(simulations+1)
+
+	return baseline
+}
+
+// randomEntry enters a trade in the direction of row on the close of a random candle of a random session,
+// with the same stop and target distances as row, and returns its profit in R.
+func randomEntry(
+	random *rand.Rand,
+	row *tradeLog.Row,
+	sessions []*portfolio.Session,
+	instrumentConfig *utils.InstrumentConfiguration,
+	tickSize float64,
+) float64 {
+	session := sessions[random.Intn(len(sessions))]
+
+	// Never enter on the last candle so there is always a candle to manage the trade with
+	entry := session.Data[random.Intn(len(session.Data)-1)]
+
+	stopDistance := math.Abs(row.EntryPrice - row.InitialStopPrice)
+	targetDistance := math.Abs(row.TargetPrice - row.EntryPrice)
+	if row.Direction == utils.TradeDirection.SHORT {
+		stopDistance, targetDistance = -stopDistance, -targetDistance
+	}
+
+	trade := &tradeConfig.Trade{
+		Instrument:       row.Instrument,
+		TakenAt:          entry.Time,
+		Direction:        row.Direction,
+		EntryPrice:       entry.Close,
+		StopPrice:        entry.Close - stopDistance,
+		InitialStopPrice: entry.Close - stopDistance,
+		TargetPrice:      entry.Close + targetDistance,
+	}
+	trade.ValidateTradeWithWindow(session.Data, instrumentConfig, tickSize)
+
+	return trade.ProfitR()
+}
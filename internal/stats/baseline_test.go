@@ -0,0 +1,57 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/backtestData"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/portfolio"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/tradeLog"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// mockFallingSession returns a London ES session that falls one point every candle, so every LONG entry with a
+// two point stop is stopped out on the next candle.
+func mockFallingSession() *portfolio.Session {
+	session := &portfolio.Session{Instrument: "ES", Region: "London"}
+	for index := 0; index < 20; index++ {
+		price := 100 - float64(index)
+		session.Data = append(session.Data, &backtestData.Row{
+			Time:  mockMonday.Add(time.Duration(index) * 5 * time.Minute),
+			Open:  price + 1,
+			High:  price + 0.5,
+			Low:   price - 1,
+			Close: price,
+		})
+	}
+
+	return session
+}
+
+// TestRandomEntryBaseline tests random entries are simulated with the same stop and target as the trades
+func TestRandomEntryBaseline(t *testing.T) {
+	l := &tradeLog.Log{
+		{Instrument: "ES", Region: "London", Direction: utils.TradeDirection.LONG, EntryPrice: 100, InitialStopPrice: 98, TargetPrice: 102, Profit: 1},
+		// There is no New York session to enter randomly in, so this trade is left out
+		{Instrument: "ES", Region: "New York", Direction: utils.TradeDirection.LONG, EntryPrice: 100, InitialStopPrice: 98, TargetPrice: 102, Profit: -1},
+	}
+	instruments := map[string]*utils.InstrumentConfiguration{"ES": {}}
+	specs := utils.ContractSpecRegistry{"ES": {TickSize: 0.25}}
+
+	baseline := RandomEntryBaseline(l, []*portfolio.Session{mockFallingSession()}, instruments, specs, 99, 1)
+
+	require.Equal(t, 99, baseline.Simulations)
+	require.Equal(t, 1.0, baseline.ExpectancyR)
+	require.InDelta(t, -1, baseline.RandomExpectancyR, 1e-9)
+	require.Equal(t, 0.01, baseline.PValue)
+
+	// A SHORT in a falling market always hits its target, so random entries do as well as the trade
+	(*l)[0].Direction, (*l)[0].InitialStopPrice, (*l)[0].TargetPrice = utils.TradeDirection.SHORT, 102, 98
+	baseline = RandomEntryBaseline(l, []*portfolio.Session{mockFallingSession()}, instruments, specs, 99, 1)
+	require.InDelta(t, 1, baseline.RandomExpectancyR, 1e-9)
+	require.Equal(t, 1.0, baseline.PValue)
+
+	// Without a session nothing can be tested
+	require.Equal(t, 1.0, RandomEntryBaseline(l, nil, instruments, specs, 99, 1).PValue)
+}
@@ -0,0 +1,181 @@
+package stats
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/tradeLog"
+	"github.com/gocarina/gocsv"
+)
+
+// Dimension is a way of grouping the trades of a log, such as by instrument or by the weekday they were entered.
+type Dimension struct {
+	// Name is the name of the dimension written in the Dimension column of the breakdown.
+	Name string
+
+	// Key returns the group a trade belongs to.
+	Key func(row *tradeLog.Row) string
+
+	// Order is the natural order of the groups, such as Monday to Sunday, any group not in it is sorted
+	// alphabetically after the ordered groups (optional)
+	Order []string
+}
+
+// weekdays is the natural order of the weekday groups.
+var weekdays = []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"}
+
+// months is the natural order of the month groups.
+var months = []string{
+	"January", "February", "March", "April", "May", "June",
+	"July", "August", "September", "October", "November", "December",
+}
+
+// StandardDimensions is every dimension the breakdown report is always grouped by.
+var StandardDimensions = []Dimension{
+	{Name: "Instrument", Key: func(row *tradeLog.Row) string { return row.Instrument }},
+	{Name: "Direction", Key: func(row *tradeLog.Row) string { return row.Direction }},
+	{Name: "Weekday", Key: func(row *tradeLog.Row) string { return row.TakenAt.Weekday().String() }, Order: weekdays},
+	{Name: "Hour", Key: func(row *tradeLog.Row) string { return fmt.Sprintf("%02d", row.TakenAt.Hour()) }},
+	{Name: "Month", Key: func(row *tradeLog.Row) string { return row.TakenAt.Month().String() }, Order: months},
+	{Name: "Year", Key: func(row *tradeLog.Row) string { return fmt.Sprint(row.TakenAt.Year()) }},
+	{Name: "Region", Key: func(row *tradeLog.Row) string { return row.Region }},
+}
+
+// FieldDimension returns a dimension that groups trades by the value of a field of tradeLog.Row,
+// found by its field name or its CSV column name, ignoring case.
+func FieldDimension(field string) (Dimension, error) {
+	rowType := reflect.TypeOf(tradeLog.Row{})
+	for index := 0; index < rowType.NumField(); index++ {
+		structField := rowType.Field(index)
+		if !strings.EqualFold(structField.Name, field) && !strings.EqualFold(structField.Tag.Get("csv"), field) {
+			continue
+		}
+
+		return Dimension{
+			Name: structField.Name,
+			Key: func(row *tradeLog.Row) string {
+				value := reflect.ValueOf(row).Elem().Field(index).Interface()
+				if at, ok := value.(time.Time); ok {
+					return at.Format(time.DateTime)
+				}
+				return fmt.Sprint(value)
+			},
+		}, nil
+	}
+
+	return Dimension{}, fmt.Errorf("cannot group trades by %s as it is not a column of the trade log", field)
+}
+
+// Group is a struct representing one row of the breakdown output CSV, the statistics of the trades in one group.
+type Group struct {
+	// Dimension is the name of the dimension the trades were grouped by.
+	Dimension string `csv:"Dimension"`
+
+	// Group is the value of the dimension every trade in the group shares.
+	Group string `csv:"Group"`
+
+	// Trades is the amount of trades in the group.
+	Trades int `csv:"Trades"`
+
+	// Wins is the amount of winning trades in the group.
+	Wins int `csv:"Wins"`
+
+	// WinRate is the percentage of trades in the group that won.
+	WinRate float64 `csv:"WinRate"`
+
+	// AverageR is the average profit of a trade in the group in R.
+	AverageR float64 `csv:"AverageR"`
+
+	// TotalR is the sum of the profit of every trade in the group in R.
+	TotalR float64 `csv:"TotalR"`
+
+	// ProfitFactor is the gross profit in R divided by the gross loss in R, 0 if there are no losses.
+	ProfitFactor float64 `csv:"ProfitFactor"`
+}
+
+// Breakdown is a slice of Group pointers.
+type Breakdown []*Group
+
+// BreakdownBy returns the statistics of the trades of a log grouped by every dimension, in the order of the
+// dimensions and then the order of the groups.
+func BreakdownBy(l *tradeLog.Log, dimensions ...Dimension) *Breakdown {
+	breakdown := new(Breakdown)
+	for _, dimension := range dimensions {
+		*breakdown = append(*breakdown, groupBy(l, dimension)...)
+	}
+
+	return breakdown
+}
+
+// groupBy returns the statistics of the trades of a log in every group of one dimension.
+func groupBy(l *tradeLog.Log, dimension Dimension) []*Group {
+	var (
+		groups      = make(map[string]*Group)
+		grossProfit = make(map[string]float64)
+		grossLoss   = make(map[string]float64)
+	)
+	for _, row := range *l {
+		key := dimension.Key(row)
+		group, ok := groups[key]
+		if !ok {
+			group = &Group{Dimension: dimension.Name, Group: key}
+			groups[key] = group
+		}
+
+		profitR := float64(row.Profit)
+		group.Trades++
+		group.TotalR += profitR
+		if row.Win {
+			group.Wins++
+		}
+		if profitR > 0 {
+			grossProfit[key] += profitR
+		} else {
+			grossLoss[key] -= profitR
+		}
+	}
+
+	ordered := make([]*Group, 0, len(groups))
+	for key, group := range groups {
+		group.WinRate = float64(group.Wins) / float64(group.Trades) * 100
+		group.AverageR = group.TotalR / float64(group.Trades)
+		if grossLoss[key] > 0 {
+			group.ProfitFactor = grossProfit[key] / grossLoss[key]
+		}
+		ordered = append(ordered, group)
+	}
+
+	// Groups in the natural order of the dimension come first, the rest are sorted alphabetically
+	rank := func(key string) int {
+		for index, value := range dimension.Order {
+			if value == key {
+				return index
+			}
+		}
+		return len(dimension.Order)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		rankI, rankJ := rank(ordered[i].Group), rank(ordered[j].Group)
+		if rankI != rankJ {
+			return rankI < rankJ
+		}
+		return ordered[i].Group < ordered[j].Group
+	})
+
+	return ordered
+}
+
+// WriteCSV takes a file path and writes the breakdown to a CSV on disk, creating or overwriting it.
+func (b *Breakdown) WriteCSV(filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return gocsv.MarshalFile(b, file)
+}
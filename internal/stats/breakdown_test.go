@@ -0,0 +1,78 @@
+package stats
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestBreakdownBy tests the trades are grouped, ordered and their statistics calculated
+func TestBreakdownBy(t *testing.T) {
+	l := mockLog()
+	for index, row := range *l {
+		row.Instrument = []string{"NQ", "ES", "ES", "ES"}[index]
+	}
+
+	breakdown := *BreakdownBy(l, StandardDimensions[0], StandardDimensions[2])
+	require.Len(t, breakdown, 6)
+
+	// ES wins 1R then loses 2R and 1R
+	es := breakdown[0]
+	require.Equal(t, "Instrument", es.Dimension)
+	require.Equal(t, "ES", es.Group)
+	require.Equal(t, 3, es.Trades)
+	require.Equal(t, 1, es.Wins)
+	require.InDelta(t, 100.0/3, es.WinRate, 1e-9)
+	require.Equal(t, -2.0, es.TotalR)
+	require.InDelta(t, -2.0/3, es.AverageR, 1e-9)
+	require.InDelta(t, 1.0/3, es.ProfitFactor, 1e-9)
+
+	// NQ has no losses so has no profit factor
+	require.Equal(t, "NQ", breakdown[1].Group)
+	require.Zero(t, breakdown[1].ProfitFactor)
+
+	// Weekdays are in calendar order rather than alphabetical
+	var weekdayGroups []string
+	for _, group := range breakdown[2:] {
+		require.Equal(t, "Weekday", group.Dimension)
+		weekdayGroups = append(weekdayGroups, group.Group)
+	}
+	require.Equal(t, []string{"Monday", "Tuesday", "Wednesday", "Thursday"}, weekdayGroups)
+}
+
+// TestFieldDimension tests trades can be grouped by any column of the trade log
+func TestFieldDimension(t *testing.T) {
+	l := mockLog()
+	(*l)[0].Contracts = 2
+
+	// Columns can be found by field name or CSV column name in any case
+	dimension, err := FieldDimension("contracts")
+	require.NoError(t, err)
+	require.Equal(t, "Contracts", dimension.Name)
+
+	breakdown := *BreakdownBy(l, dimension)
+	require.Len(t, breakdown, 2)
+	require.Equal(t, "0", breakdown[0].Group)
+	require.Equal(t, 3, breakdown[0].Trades)
+	require.Equal(t, "2", breakdown[1].Group)
+
+	// Times are grouped by their full timestamp
+	dimension, err = FieldDimension("TakenAt")
+	require.NoError(t, err)
+	require.Equal(t, "2023-10-16 10:00:00", (*BreakdownBy(l, dimension))[0].Group)
+
+	_, err = FieldDimension("Unknown")
+	require.Error(t, err)
+}
+
+// TestBreakdownWriteCSV tests the breakdown can be written to disk
+func TestBreakdownWriteCSV(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "breakdown.csv")
+	require.NoError(t, BreakdownBy(mockLog(), StandardDimensions...).WriteCSV(filePath))
+
+	contents, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	require.Contains(t, string(contents), "Dimension,Group,Trades,Wins,WinRate,AverageR,TotalR,ProfitFactor")
+}
@@ -0,0 +1,88 @@
+package stats
+
+import (
+	"os"
+
+	"github.com/gocarina/gocsv"
+)
+
+// StatisticChange is a struct representing one row of the comparison summary output CSV,
+// one statistic of a base and a candidate run.
+type StatisticChange struct {
+	// Statistic is the name of the statistic, the same as its Summary field.
+	Statistic string `csv:"Statistic"`
+
+	// Base is the value of the statistic in the base run.
+	Base float64 `csv:"Base"`
+
+	// Candidate is the value of the statistic in the candidate run.
+	Candidate float64 `csv:"Candidate"`
+
+	// Change is Candidate minus Base.
+	Change float64 `csv:"Change"`
+}
+
+// SummaryComparison is the change in every numeric statistic between two summaries, in the order of Summary.
+type SummaryComparison []*StatisticChange
+
+// statistic is the name and value of one numeric statistic of a summary.
+type statistic struct {
+	name  string
+	value float64
+}
+
+// statistics returns every numeric statistic of a summary, in the order of Summary.
+func (s *Summary) statistics() []statistic {
+	return []statistic{
+		{"EndingBalance", s.EndingBalance},
+		{"NetProfit", s.NetProfit},
+		{"NetProfitR", s.NetProfitR},
+		{"Trades", float64(s.Trades)},
+		{"Wins", float64(s.Wins)},
+		{"Losses", float64(s.Losses)},
+		{"WinRate", s.WinRate},
+		{"ProfitFactor", s.ProfitFactor},
+		{"ExpectancyR", s.ExpectancyR},
+		{"Expectancy", s.Expectancy},
+		{"AverageWinR", s.AverageWinR},
+		{"AverageLossR", s.AverageLossR},
+		{"MaxConsecutiveWins", float64(s.MaxConsecutiveWins)},
+		{"MaxConsecutiveLosses", float64(s.MaxConsecutiveLosses)},
+		{"DrawdownDepth", s.Drawdown.Depth},
+		{"DrawdownDepthPercent", s.Drawdown.DepthPercent},
+		{"DrawdownDurationDays", s.Drawdown.DurationDays},
+		{"Sharpe", s.Sharpe},
+		{"Sortino", s.Sortino},
+		{"CAGR", s.CAGR},
+		{"MAR", s.MAR},
+		{"TimeInMarket", s.TimeInMarket},
+		{"TradesPerDay", s.TradesPerDay},
+	}
+}
+
+// CompareSummaries returns the change in every numeric statistic from the base summary to the candidate summary.
+func CompareSummaries(base, candidate *Summary) *SummaryComparison {
+	comparison := new(SummaryComparison)
+	candidateStatistics := candidate.statistics()
+	for index, baseStatistic := range base.statistics() {
+		*comparison = append(*comparison, &StatisticChange{
+			Statistic: baseStatistic.name,
+			Base:      baseStatistic.value,
+			Candidate: candidateStatistics[index].value,
+			Change:    candidateStatistics[index].value - baseStatistic.value,
+		})
+	}
+
+	return comparison
+}
+
+// WriteCSV takes a file path and writes the comparison to a CSV on disk, creating or overwriting it.
+func (c *SummaryComparison) WriteCSV(filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return gocsv.MarshalFile(c, file)
+}
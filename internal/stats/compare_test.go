@@ -0,0 +1,32 @@
+package stats
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestCompareSummaries tests the change in every statistic is the candidate minus the base
+func TestCompareSummaries(t *testing.T) {
+	base := Calculate(mockLog(), 1000)
+	candidate := Calculate(mockLog(), 2000)
+
+	comparison := CompareSummaries(base, candidate)
+
+	require.Len(t, *comparison, len(base.statistics()))
+	require.Equal(t, &StatisticChange{Statistic: "EndingBalance", Base: base.EndingBalance, Candidate: candidate.EndingBalance, Change: 1000}, (*comparison)[0])
+	for _, change := range *comparison {
+		require.Equal(t, change.Candidate-change.Base, change.Change, change.Statistic)
+		if change.Statistic == "Trades" || change.Statistic == "NetProfitR" {
+			require.Zero(t, change.Change)
+		}
+	}
+
+	filePath := filepath.Join(t.TempDir(), "compare-summary.csv")
+	require.NoError(t, comparison.WriteCSV(filePath))
+	contents, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	require.Contains(t, string(contents), "Statistic,Base,Candidate,Change")
+}
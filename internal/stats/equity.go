@@ -0,0 +1,152 @@
+package stats
+
+import (
+	"os"
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/portfolio"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/tradeLog"
+	"github.com/gocarina/gocsv"
+)
+
+// EquityPoint is a struct representing one row of an equity curve output CSV,
+// the equity of the account at a point in time and how far it is below the highest equity before it.
+type EquityPoint struct {
+	// Time is the timestamp the equity was recorded at.
+	Time time.Time `csv:"Time"`
+
+	// Equity is the equity of the account.
+	Equity float64 `csv:"Equity"`
+
+	// Peak is the highest equity up to and including this point.
+	Peak float64 `csv:"Peak"`
+
+	// Drawdown is the amount the equity is below the Peak.
+	Drawdown float64 `csv:"Drawdown"`
+
+	// DrawdownPercent is Drawdown as a percentage of the Peak.
+	DrawdownPercent float64 `csv:"DrawdownPercent"`
+}
+
+// EquityCurve is a slice of EquityPoint pointers in time order.
+type EquityCurve []*EquityPoint
+
+// Add appends the equity at a point in time to the curve, calculating its drawdown from the previous peak.
+func (c *EquityCurve) Add(at time.Time, equity float64) {
+	point := &EquityPoint{Time: at, Equity: equity, Peak: equity}
+	if len(*c) > 0 {
+		previous := (*c)[len(*c)-1]
+		point.Peak = max(previous.Peak, equity)
+	}
+
+	point.Drawdown = point.Peak - equity
+	if point.Peak > 0 {
+		point.DrawdownPercent = point.Drawdown / point.Peak * 100
+	}
+
+	*c = append(*c, point)
+}
+
+// MaxDrawdown returns the point with the largest drawdown percentage, or nil if the curve is empty.
+func (c *EquityCurve) MaxDrawdown() *EquityPoint {
+	var deepest *EquityPoint
+	for _, point := range *c {
+		if deepest == nil || point.DrawdownPercent > deepest.DrawdownPercent {
+			deepest = point
+		}
+	}
+
+	return deepest
+}
+
+// WriteCSV takes a file path and writes the curve to a CSV on disk, creating or overwriting it.
+func (c *EquityCurve) WriteCSV(filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return gocsv.MarshalFile(c, file)
+}
+
+// TradeEquityCurve returns the equity after every trade closed, starting with the starting balance when
+// the first trade was entered.
+func TradeEquityCurve(l *tradeLog.Log, startingBalance float64) *EquityCurve {
+	curve := new(EquityCurve)
+	if len(*l) == 0 {
+		return curve
+	}
+
+	rows := sortedByClose(l)
+	curve.Add(firstTaken(rows), startingBalance)
+
+	equity := startingBalance
+	for _, row := range rows {
+		equity += row.ProfitValue
+		curve.Add(row.ClosedAtTime, equity)
+	}
+
+	return curve
+}
+
+// DailyEquityCurve returns the equity at the end of every weekday, and any weekend day a trade closed on,
+// from the day of the first entry until the day of the last exit.
+func DailyEquityCurve(l *tradeLog.Log, startingBalance float64) *EquityCurve {
+	curve := new(EquityCurve)
+	if len(*l) == 0 {
+		return curve
+	}
+
+	rows := sortedByClose(l)
+	profitByDay := make(map[string]float64)
+	for _, row := range rows {
+		profitByDay[row.ClosedAtTime.Format("2006-01-02")] += row.ProfitValue
+	}
+
+	var (
+		first   = firstTaken(rows)
+		last    = rows[len(rows)-1].ClosedAtTime
+		lastDay = time.Date(last.Year(), last.Month(), last.Day(), 0, 0, 0, 0, time.UTC)
+		equity  = startingBalance
+	)
+	for day := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, time.UTC); !day.After(lastDay); day = day.AddDate(0, 0, 1) {
+		profit, closed := profitByDay[day.Format("2006-01-02")]
+		if !closed && (day.Weekday() == time.Saturday || day.Weekday() == time.Sunday) {
+			continue
+		}
+
+		equity += profit
+		curve.Add(day, equity)
+	}
+
+	return curve
+}
+
+// MarkedToMarketCurve returns the equity on every bar a position was open, including the unrealised profit of
+// every open position, so the drawdown while a trade is open is captured.
+func MarkedToMarketCurve(points []*portfolio.MarginPoint, startingBalance float64) *EquityCurve {
+	curve := new(EquityCurve)
+	if len(points) == 0 {
+		return curve
+	}
+
+	curve.Add(points[0].Time, startingBalance)
+	for _, point := range points {
+		curve.Add(point.Time, point.Equity)
+	}
+
+	return curve
+}
+
+// firstTaken returns the earliest time a trade was entered.
+func firstTaken(rows []*tradeLog.Row) time.Time {
+	first := rows[0].TakenAt
+	for _, row := range rows {
+		if row.TakenAt.Before(first) {
+			first = row.TakenAt
+		}
+	}
+
+	return first
+}
@@ -0,0 +1,66 @@
+package stats
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/portfolio"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/tradeLog"
+	"github.com/stretchr/testify/require"
+)
+
+// equities returns the equity of every point in a curve.
+func equities(curve *EquityCurve) []float64 {
+	values := make([]float64, len(*curve))
+	for index, point := range *curve {
+		values[index] = point.Equity
+	}
+	return values
+}
+
+// TestTradeEquityCurve tests the equity and drawdown after every trade
+func TestTradeEquityCurve(t *testing.T) {
+	curve := TradeEquityCurve(mockLog(), 1000)
+
+	require.Equal(t, []float64{1000, 1100, 900, 800, 1200}, equities(curve))
+	require.Equal(t, mockMonday, (*curve)[0].Time)
+
+	trough := (*curve)[3]
+	require.Equal(t, 1100.0, trough.Peak)
+	require.Equal(t, 300.0, trough.Drawdown)
+	require.InDelta(t, 300.0/1100.0*100, trough.DrawdownPercent, 1e-9)
+	require.Equal(t, trough, curve.MaxDrawdown())
+	require.Zero(t, (*curve)[4].Drawdown)
+
+	require.Empty(t, *TradeEquityCurve(tradeLog.NewLog(), 1000))
+}
+
+// TestDailyEquityCurve tests the equity at the end of each day, skipping weekends without trades
+func TestDailyEquityCurve(t *testing.T) {
+	log := mockLog()
+	// A trade closed on the Saturday and none on the Friday
+	*log = append(*log, mockRow(5, 50, 0.5))
+
+	curve := DailyEquityCurve(log, 1000)
+
+	require.Equal(t, []float64{1100, 900, 800, 1200, 1200, 1250}, equities(curve))
+	require.Equal(t, time.Saturday, (*curve)[5].Time.Weekday())
+}
+
+// TestMarkedToMarketCurve tests the equity including open positions on every bar
+func TestMarkedToMarketCurve(t *testing.T) {
+	curve := MarkedToMarketCurve([]*portfolio.MarginPoint{
+		{Time: mockMonday, Equity: 990},
+		{Time: mockMonday.Add(5 * time.Minute), Equity: 950},
+		{Time: mockMonday.Add(10 * time.Minute), Equity: 1020},
+	}, 1000)
+
+	require.Equal(t, []float64{1000, 990, 950, 1020}, equities(curve))
+	require.Equal(t, 50.0, curve.MaxDrawdown().Drawdown)
+
+	// Check it can be written to disk
+	filePath := filepath.Join(t.TempDir(), "equity.csv")
+	require.NoError(t, curve.WriteCSV(filePath))
+	require.FileExists(t, filePath)
+}
@@ -0,0 +1,99 @@
+package stats
+
+import (
+	"math"
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/tradeLog"
+)
+
+// MonthlyReturn is the profit of the account in one calendar month.
+type MonthlyReturn struct {
+	// Year is the year of the month.
+	Year int
+
+	// Month is the month of the year.
+	Month time.Month
+
+	// Profit is the sum of the profit of every trade that closed in the month.
+	Profit float64
+
+	// ReturnPercent is Profit as a percentage of the equity at the start of the month.
+	ReturnPercent float64
+}
+
+// MonthlyReturns returns the return of the account in every month from the first entry to the last exit,
+// months without a trade closing have a return of 0.
+func MonthlyReturns(l *tradeLog.Log, startingBalance float64) []*MonthlyReturn {
+	if len(*l) == 0 {
+		return nil
+	}
+
+	rows := sortedByClose(l)
+	profitByMonth := make(map[string]float64)
+	for _, row := range rows {
+		profitByMonth[row.ClosedAtTime.Format("2006-01")] += row.ProfitValue
+	}
+
+	var (
+		returns   []*MonthlyReturn
+		first     = firstTaken(rows)
+		last      = rows[len(rows)-1].ClosedAtTime
+		lastMonth = time.Date(last.Year(), last.Month(), 1, 0, 0, 0, 0, time.UTC)
+		equity    = startingBalance
+	)
+	for month := time.Date(first.Year(), first.Month(), 1, 0, 0, 0, 0, time.UTC); !month.After(lastMonth); month = month.AddDate(0, 1, 0) {
+		monthly := &MonthlyReturn{
+			Year:   month.Year(),
+			Month:  month.Month(),
+			Profit: profitByMonth[month.Format("2006-01")],
+		}
+		if equity != 0 {
+			monthly.ReturnPercent = monthly.Profit / equity * 100
+		}
+
+		returns = append(returns, monthly)
+		equity += monthly.Profit
+	}
+
+	return returns
+}
+
+// Bin is the amount of trades with a profit in R from From up to but not including To.
+type Bin struct {
+	// From is the lowest profit in R of the bin.
+	From float64
+
+	// To is the profit in R the next bin starts from.
+	To float64
+
+	// Trades is the amount of trades in the bin.
+	Trades int
+}
+
+// RHistogram returns the distribution of the profit in R of every trade, in bins of the given width aligned to 0,
+// with every bin from the largest loss to the largest win.
+func RHistogram(l *tradeLog.Log, width float64) []*Bin {
+	if len(*l) == 0 || width <= 0 {
+		return nil
+	}
+
+	counts := make(map[int]int)
+	lowest, highest := math.MaxInt, math.MinInt
+	for _, row := range *l {
+		index := int(math.Floor(float64(row.Profit) / width))
+		counts[index]++
+		lowest, highest = min(lowest, index), max(highest, index)
+	}
+
+	bins := make([]*Bin, 0, highest-lowest+1)
+	for index := lowest; index <= highest; index++ {
+		bins = append(bins, &Bin{
+			From:   float64(index) * width,
+			To:     float64(index+1) * width,
+			Trades: counts[index],
+		})
+	}
+
+	return bins
+}
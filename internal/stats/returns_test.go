@@ -0,0 +1,51 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/tradeLog"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMonthlyReturns tests every month from the first entry is returned with its return on the equity at its start
+func TestMonthlyReturns(t *testing.T) {
+	l := &tradeLog.Log{
+		mockRow(0, 100, 1),
+		mockRow(1, -50, -0.5),
+		// December has no trades
+		mockRow(70, 210, 2),
+	}
+
+	returns := MonthlyReturns(l, 1000)
+
+	require.Len(t, returns, 3)
+	require.Equal(t, 2023, returns[0].Year)
+	require.Equal(t, time.October, returns[0].Month)
+	require.Equal(t, 50.0, returns[0].Profit)
+	require.Equal(t, 5.0, returns[0].ReturnPercent)
+	require.Equal(t, time.November, returns[1].Month)
+	require.Zero(t, returns[1].Profit)
+	require.Equal(t, time.December, returns[2].Month)
+	require.Equal(t, 20.0, returns[2].ReturnPercent)
+
+	require.Nil(t, MonthlyReturns(tradeLog.NewLog(), 1000))
+}
+
+// TestRHistogram tests the profit of every trade is counted in bins aligned to 0 with no gaps
+func TestRHistogram(t *testing.T) {
+	bins := RHistogram(mockLog(), 1)
+
+	// The losses of -2R and -1R up to the win of 4R
+	require.Len(t, bins, 7)
+	require.Equal(t, -2.0, bins[0].From)
+	require.Equal(t, -1.0, bins[0].To)
+	require.Equal(t, 1, bins[0].Trades)
+	require.Equal(t, 1, bins[1].Trades)
+	require.Zero(t, bins[2].Trades)
+	require.Equal(t, 1, bins[3].Trades)
+	require.Equal(t, 4.0, bins[6].From)
+	require.Equal(t, 1, bins[6].Trades)
+
+	require.Nil(t, RHistogram(mockLog(), 0))
+}
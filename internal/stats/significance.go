@@ -0,0 +1,262 @@
+package stats
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/tradeLog"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/utils"
+)
+
+// SignificanceInvalid is returned when the significance configuration cannot be tested.
+var SignificanceInvalid = errors.New("significance configuration is invalid")
+
+// ValidateSignificance returns a SignificanceInvalid error if the confidence level is not a percentage between 0 and
+// 100 exclusive, there are no resamples or the amount of baseline simulations is negative.
+func ValidateSignificance(config *utils.SignificanceConfiguration) error {
+	if config.ConfidenceLevel <= 0 || config.ConfidenceLevel >= 100 {
+		return fmt.Errorf(
+			"ConfidenceLevel must be a percentage between 0 and 100, got %v: %w",
+			config.ConfidenceLevel,
+			SignificanceInvalid,
+		)
+	}
+	if config.Resamples <= 0 {
+		return fmt.Errorf("Resamples must be greater than 0: %w", SignificanceInvalid)
+	}
+	if config.BaselineSimulations < 0 {
+		return fmt.Errorf("BaselineSimulations cannot be negative, set SkipBaseline instead: %w", SignificanceInvalid)
+	}
+
+	return nil
+}
+
+// ConfidenceInterval is an estimate of a statistic and the range its true value is likely to be in.
+type ConfidenceInterval struct {
+	// Estimate is the value of the statistic for the trades.
+	Estimate float64 `json:"Estimate"`
+
+	// Lower is the lower bound of the interval.
+	Lower float64 `json:"Lower"`
+
+	// Upper is the upper bound of the interval.
+	Upper float64 `json:"Upper"`
+}
+
+// Significance is how likely it is the edge of the trades is real rather than noise.
+type Significance struct {
+	// Trades is the amount of trades tested.
+	Trades int `json:"Trades"`
+
+	// ConfidenceLevel is the percentage of resamples every interval contains.
+	ConfidenceLevel float64 `json:"ConfidenceLevel"`
+
+	// ExpectancyR is the average profit of a trade in R.
+	ExpectancyR ConfidenceInterval `json:"ExpectancyR"`
+
+	// WinRate is the percentage of trades that won.
+	WinRate ConfidenceInterval `json:"WinRate"`
+
+	// ProfitFactor is the gross profit in R divided by the gross loss in R.
+	ProfitFactor ConfidenceInterval `json:"ProfitFactor"`
+
+	// TStatistic is the t statistic of the average R against an average of 0.
+	TStatistic float64 `json:"TStatistic"`
+
+	// PValue is the two sided probability of an average R at least this far from 0 if the true average was 0.
+	PValue float64 `json:"PValue"`
+
+	// Baseline is the result of trading random entries with the same stops and targets (optional)
+	Baseline *Baseline `json:"Baseline,omitempty"`
+}
+
+// Percentile returns the value at a percentile of sorted values, interpolating between the closest two values.
+func Percentile(sorted []float64, percentile float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	position := percentile / 100 * float64(len(sorted)-1)
+	lower, upper := int(math.Floor(position)), int(math.Ceil(position))
+
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(position-float64(lower))
+}
+
+// rStatistics returns the expectancy in R, the win rate and the profit factor in R of trades.
+func rStatistics(profits []float64, wins []bool) (float64, float64, float64) {
+	var total, grossProfit, grossLoss float64
+	won := 0
+	for index, profit := range profits {
+		total += profit
+		if profit > 0 {
+			grossProfit += profit
+		} else {
+			grossLoss -= profit
+		}
+		if wins[index] {
+			won++
+		}
+	}
+
+	profitFactor := 0.0
+	if grossLoss > 0 {
+		profitFactor = grossProfit / grossLoss
+	}
+
+	return total / float64(len(profits)), float64(won) / float64(len(profits)) * 100, profitFactor
+}
+
+// CalculateSignificance returns the bootstrap confidence intervals of the expectancy, win rate and profit factor
+// of a log, from the given amount of resamples with replacement, and a t-test of the average R against 0.
+// The same seed always resamples the same trades.
+func CalculateSignificance(l *tradeLog.Log, resamples int, confidenceLevel float64, seed int64) *Significance {
+	significance := &Significance{Trades: len(*l), ConfidenceLevel: confidenceLevel}
+	if len(*l) == 0 {
+		return significance
+	}
+
+	profits, wins := make([]float64, 0, len(*l)), make([]bool, 0, len(*l))
+	for _, row := range *l {
+		profits, wins = append(profits, float64(row.Profit)), append(wins, row.Win)
+	}
+	expectancy, winRate, profitFactor := rStatistics(profits, wins)
+
+	// Recalculate every statistic on trades drawn at random with replacement
+	var (
+		random                                = rand.New(rand.NewSource(seed))
+		resampledProfits                      = make([]float64, len(profits))
+		resampledWins                         = make([]bool, len(wins))
+		expectancies, winRates, profitFactors []float64
+	)
+	for resample := 0; resample < resamples; resample++ {
+		for index := range resampledProfits {
+			drawn := random.Intn(len(profits))
+			resampledProfits[index], resampledWins[index] = profits[drawn], wins[drawn]
+		}
+		resampledExpectancy, resampledWinRate, resampledProfitFactor := rStatistics(resampledProfits, resampledWins)
+		expectancies = append(expectancies, resampledExpectancy)
+		winRates = append(winRates, resampledWinRate)
+		profitFactors = append(profitFactors, resampledProfitFactor)
+	}
+
+	tail := (100 - confidenceLevel) / 2
+	interval := func(estimate float64, resampled []float64) ConfidenceInterval {
+		sort.Float64s(resampled)
+		return ConfidenceInterval{
+			Estimate: estimate,
+			Lower:    Percentile(resampled, tail),
+			Upper:    Percentile(resampled, 100-tail),
+		}
+	}
+	significance.ExpectancyR = interval(expectancy, expectancies)
+	significance.WinRate = interval(winRate, winRates)
+	significance.ProfitFactor = interval(profitFactor, profitFactors)
+	significance.TStatistic, significance.PValue = tTest(profits)
+
+	return significance
+}
+
+// tTest returns the t statistic of the mean of values against 0 and its two sided p-value.
+// The p-value is 1 when there are too few values, or no variance, to test.
+func tTest(values []float64) (float64, float64) {
+	if len(values) < 2 {
+		return 0, 1
+	}
+
+	var mean float64
+	for _, value := range values {
+		mean += value
+	}
+	mean /= float64(len(values))
+
+	var variance float64
+	for _, value := range values {
+		variance += (value - mean) * (value - mean)
+	}
+	standardError := math.Sqrt(variance/float64(len(values)-1)) / math.Sqrt(float64(len(values)))
+	if standardError == 0 {
+		return 0, 1
+	}
+
+	t := mean / standardError
+	degreesOfFreedom := float64(len(values) - 1)
+
+	// The two sided tail probability of the Student's t distribution
+	return t, regularisedIncompleteBeta(degreesOfFreedom/(degreesOfFreedom+t*t), degreesOfFreedom/2, 0.5)
+}
+
+// regularisedIncompleteBeta returns I_x(a, b), evaluated with its continued fraction.
+func regularisedIncompleteBeta(x, a, b float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+
+	// The continued fraction converges quickly below this point, above it use the symmetry I_x(a, b) = 1 - I_1-x(b, a)
+	if x > (a+1)/(a+b+2) {
+		return 1 - regularisedIncompleteBeta(1-x, b, a)
+	}
+
+	lgammaA, _ := math.Lgamma(a)
+	lgammaB, _ := math.Lgamma(b)
+	lgammaAB, _ := math.Lgamma(a + b)
+	front := math.Exp(lgammaAB - lgammaA - lgammaB + a*math.Log(x) + b*math.Log(1-x))
+
+	return front * betaContinuedFraction(x, a, b) / a
+}
+
+// betaContinuedFraction evaluates the continued fraction of the incomplete beta function with Lentz's method.
+func betaContinuedFraction(x, a, b float64) float64 {
+	const (
+		iterations = 200
+		epsilon    = 1e-14
+		tiny       = 1e-300
+	)
+
+	clamp := func(value float64) float64 {
+		if math.Abs(value) < tiny {
+			return tiny
+		}
+		return value
+	}
+
+	c, d := 1.0, 1/clamp(1-(a+b)*x/(a+1))
+	result := d
+	for m := 1.0; m <= iterations; m++ {
+		// Even step
+		numerator := m * (b - m) * x / ((a + 2*m - 1) * (a + 2*m))
+		d = 1 / clamp(1+numerator*d)
+		c = clamp(1 + numerator/c)
+		result *= d * c
+
+		// Odd step
+		numerator = -(a + m) * (a + b + m) * x / ((a + 2*m) * (a + 2*m + 1))
+		d = 1 / clamp(1+numerator*d)
+		c = clamp(1 + numerator/c)
+		delta := d * c
+		result *= delta
+
+		if math.Abs(delta-1) < epsilon {
+			break
+		}
+	}
+
+	return result
+}
+
+// WriteJSON writes the significance to a JSON file on disk, creating or overwriting it.
+func (s *Significance) WriteJSON(filePath string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filePath, data, 0644)
+}
@@ -0,0 +1,100 @@
+package stats
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/tradeLog"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// TestValidateSignificance tests the confidence level must be a percentage and the resamples and baseline counted
+func TestValidateSignificance(t *testing.T) {
+	valid := utils.SignificanceConfiguration{Resamples: 1000, ConfidenceLevel: 95, BaselineSimulations: 200}
+	require.NoError(t, ValidateSignificance(&valid))
+
+	for _, modify := range []func(cfg *utils.SignificanceConfiguration){
+		func(cfg *utils.SignificanceConfiguration) { cfg.ConfidenceLevel = 150 },
+		func(cfg *utils.SignificanceConfiguration) { cfg.ConfidenceLevel = 100 },
+		func(cfg *utils.SignificanceConfiguration) { cfg.ConfidenceLevel = -5 },
+		func(cfg *utils.SignificanceConfiguration) { cfg.Resamples = 0 },
+		func(cfg *utils.SignificanceConfiguration) { cfg.BaselineSimulations = -1 },
+	} {
+		invalid := valid
+		modify(&invalid)
+		require.ErrorIs(t, ValidateSignificance(&invalid), SignificanceInvalid)
+	}
+}
+
+// TestPercentile tests values are interpolated between the closest two values
+func TestPercentile(t *testing.T) {
+	sorted := []float64{1, 2, 3, 4, 5}
+
+	require.Equal(t, 1.0, Percentile(sorted, 0))
+	require.Equal(t, 3.0, Percentile(sorted, 50))
+	require.Equal(t, 4.5, Percentile(sorted, 87.5))
+	require.Equal(t, 5.0, Percentile(sorted, 100))
+	require.Zero(t, Percentile(nil, 50))
+}
+
+// TestCalculateSignificance tests the confidence intervals contain the estimates and the t-test of the average R
+func TestCalculateSignificance(t *testing.T) {
+	l := tradeLog.NewLog()
+	for _, profit := range []float32{1, 2, 3, 4, 5} {
+		*l = append(*l, &tradeLog.Row{Profit: profit, Win: true})
+	}
+
+	significance := CalculateSignificance(l, 500, 95, 1)
+
+	require.Equal(t, 5, significance.Trades)
+	require.Equal(t, 3.0, significance.ExpectancyR.Estimate)
+	require.Less(t, significance.ExpectancyR.Lower, 3.0)
+	require.Greater(t, significance.ExpectancyR.Upper, 3.0)
+	require.GreaterOrEqual(t, significance.ExpectancyR.Lower, 1.0)
+	require.LessOrEqual(t, significance.ExpectancyR.Upper, 5.0)
+	require.Equal(t, ConfidenceInterval{Estimate: 100, Lower: 100, Upper: 100}, significance.WinRate)
+	require.Zero(t, significance.ProfitFactor.Upper)
+
+	// A mean of 3 with a standard error of 1/√2 over 4 degrees of freedom
+	require.InDelta(t, 4.2426, significance.TStatistic, 1e-4)
+	require.InDelta(t, 0.01324, significance.PValue, 1e-5)
+
+	// The same seed resamples the same trades
+	require.Equal(t, significance, CalculateSignificance(l, 500, 95, 1))
+
+	require.Zero(t, CalculateSignificance(tradeLog.NewLog(), 500, 95, 1).Trades)
+}
+
+// TestTTest tests the p-value of the Student's t distribution
+func TestTTest(t *testing.T) {
+	// Values centred on 0 have no edge
+	tStatistic, pValue := tTest([]float64{-1, 1, -2, 2})
+	require.Zero(t, tStatistic)
+	require.InDelta(t, 1, pValue, 1e-9)
+
+	// Too few values, or values without variance, cannot be tested
+	_, pValue = tTest([]float64{1})
+	require.Equal(t, 1.0, pValue)
+	_, pValue = tTest([]float64{1, 1, 1})
+	require.Equal(t, 1.0, pValue)
+
+	require.InDelta(t, 0.5, regularisedIncompleteBeta(0.5, 1, 1), 1e-12)
+	require.InDelta(t, 0.648, regularisedIncompleteBeta(0.6, 2, 2), 1e-12)
+}
+
+// TestSignificanceWriteJSON tests the significance can be written to disk and read back
+func TestSignificanceWriteJSON(t *testing.T) {
+	significance := CalculateSignificance(mockLog(), 100, 90, 1)
+	filePath := filepath.Join(t.TempDir(), "significance.json")
+	require.NoError(t, significance.WriteJSON(filePath))
+
+	data, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+
+	var read Significance
+	require.NoError(t, json.Unmarshal(data, &read))
+	require.Equal(t, *significance, read)
+}
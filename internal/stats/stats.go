@@ -0,0 +1,490 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"reflect"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/tradeLog"
+)
+
+// TradingDaysPerYear is the amount of trading days used to annualise daily returns.
+const TradingDaysPerYear = 252
+
+// minimumAnnualisedDays is the shortest time from the first to the last trade the CAGR is annualised over, any
+// shorter and compounding a few hours of returns over a year overflows.
+const minimumAnnualisedDays = 1
+
+// Summary is the performance statistics of a trade log.
+// Values are in the base currency unless the name ends in R, which is a multiple of the initial risk.
+type Summary struct {
+	// StartingBalance is the equity before the first trade.
+	StartingBalance float64 `json:"StartingBalance"`
+
+	// EndingBalance is the equity after the last trade.
+	EndingBalance float64 `json:"EndingBalance"`
+
+	// NetProfit is the sum of the profit of every trade.
+	NetProfit float64 `json:"NetProfit"`
+
+	// NetProfitR is the sum of the profit of every trade in R.
+	NetProfitR float64 `json:"NetProfitR"`
+
+	// Trades is the amount of trades.
+	Trades int `json:"Trades"`
+
+	// Wins is the amount of winning trades.
+	Wins int `json:"Wins"`
+
+	// Losses is the amount of trades that did not win.
+	Losses int `json:"Losses"`
+
+	// WinRate is the percentage of trades that won.
+	WinRate float64 `json:"WinRate"`
+
+	// ProfitFactor is the gross profit divided by the gross loss, 0 if there are no losses.
+	ProfitFactor float64 `json:"ProfitFactor"`
+
+	// ExpectancyR is the average profit of a trade in R.
+	ExpectancyR float64 `json:"ExpectancyR"`
+
+	// Expectancy is the average profit of a trade.
+	Expectancy float64 `json:"Expectancy"`
+
+	// AverageWin is the average profit of the winning trades.
+	AverageWin float64 `json:"AverageWin"`
+
+	// AverageWinR is the average profit of the winning trades in R.
+	AverageWinR float64 `json:"AverageWinR"`
+
+	// AverageLoss is the average loss of the losing trades, as a negative value.
+	AverageLoss float64 `json:"AverageLoss"`
+
+	// AverageLossR is the average loss of the losing trades in R, as a negative value.
+	AverageLossR float64 `json:"AverageLossR"`
+
+	// LargestWin is the profit of the most profitable trade.
+	LargestWin float64 `json:"LargestWin"`
+
+	// LargestWinR is the profit of the most profitable trade in R.
+	LargestWinR float64 `json:"LargestWinR"`
+
+	// LargestLoss is the loss of the least profitable trade, as a negative value.
+	LargestLoss float64 `json:"LargestLoss"`
+
+	// LargestLossR is the loss of the least profitable trade in R, as a negative value.
+	LargestLossR float64 `json:"LargestLossR"`
+
+	// MaxConsecutiveWins is the longest run of winning trades.
+	MaxConsecutiveWins int `json:"MaxConsecutiveWins"`
+
+	// MaxConsecutiveLosses is the longest run of trades that did not win.
+	MaxConsecutiveLosses int `json:"MaxConsecutiveLosses"`
+
+	// Drawdown is the largest fall in equity from a peak.
+	Drawdown Drawdown `json:"Drawdown"`
+
+	// Sharpe is the annualised Sharpe ratio of the daily returns, with a risk free rate of 0.
+	Sharpe float64 `json:"Sharpe"`
+
+	// Sortino is the annualised Sortino ratio of the daily returns, with a target return of 0.
+	Sortino float64 `json:"Sortino"`
+
+	// CAGR is the compound annual growth rate as a percentage.
+	CAGR float64 `json:"CAGR"`
+
+	// MAR is the CAGR divided by the max drawdown percentage.
+	MAR float64 `json:"MAR"`
+
+	// TimeInMarket is the percentage of the backtest a position was open for.
+	TimeInMarket float64 `json:"TimeInMarket"`
+
+	// TradingDays is the amount of weekdays from the first entry to the last exit.
+	TradingDays int `json:"TradingDays"`
+
+	// TradesPerDay is the average amount of trades on each trading day.
+	TradesPerDay float64 `json:"TradesPerDay"`
+
+	// FirstTrade is the time the first trade was entered.
+	FirstTrade time.Time `json:"FirstTrade"`
+
+	// LastTrade is the time the last trade was closed.
+	LastTrade time.Time `json:"LastTrade"`
+}
+
+// Drawdown is the largest fall in equity from a peak and how long it took to recover.
+type Drawdown struct {
+	// Depth is the fall in equity from the peak to the trough.
+	Depth float64 `json:"Depth"`
+
+	// DepthPercent is Depth as a percentage of the peak.
+	DepthPercent float64 `json:"DepthPercent"`
+
+	// PeakTime is the time of the peak before the drawdown.
+	PeakTime time.Time `json:"PeakTime"`
+
+	// TroughTime is the time of the lowest equity in the drawdown.
+	TroughTime time.Time `json:"TroughTime"`
+
+	// RecoveryTime is the time the equity returned to the peak, zero if it never recovered.
+	RecoveryTime time.Time `json:"RecoveryTime"`
+
+	// DurationDays is the days from the peak until the recovery, or the last trade if it never recovered.
+	DurationDays float64 `json:"DurationDays"`
+
+	// RecoveryDays is the days from the trough until the recovery, or the last trade if it never recovered.
+	RecoveryDays float64 `json:"RecoveryDays"`
+
+	// LongestDurationDays is the most days spent below a previous peak in any drawdown.
+	LongestDurationDays float64 `json:"LongestDurationDays"`
+}
+
+// days returns a duration in days.
+func days(duration time.Duration) float64 {
+	return duration.Hours() / 24
+}
+
+// sortedByClose returns the rows of a trade log in the order they closed, which is the order the equity changed.
+func sortedByClose(l *tradeLog.Log) []*tradeLog.Row {
+	rows := make([]*tradeLog.Row, len(*l))
+	copy(rows, *l)
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].ClosedAtTime.Before(rows[j].ClosedAtTime)
+	})
+
+	return rows
+}
+
+// Calculate returns the performance statistics of a trade log for an account with the given starting balance.
+func Calculate(l *tradeLog.Log, startingBalance float64) *Summary {
+	summary := &Summary{StartingBalance: startingBalance, EndingBalance: startingBalance}
+	if len(*l) == 0 {
+		return summary
+	}
+
+	rows := sortedByClose(l)
+	summary.Trades = len(rows)
+	summary.FirstTrade = firstTaken(rows)
+	summary.LastTrade = rows[len(rows)-1].ClosedAtTime
+
+	var (
+		grossProfit, grossLoss float64
+		winTotal, lossTotal    float64
+		winTotalR, lossTotalR  float64
+		winStreak, lossStreak  int
+	)
+	for index, row := range rows {
+		profitR := float64(row.Profit)
+		summary.NetProfit += row.ProfitValue
+		summary.NetProfitR += profitR
+
+		if row.ProfitValue > 0 {
+			grossProfit += row.ProfitValue
+		} else {
+			grossLoss -= row.ProfitValue
+		}
+
+		if row.Win {
+			summary.Wins++
+			winTotal += row.ProfitValue
+			winTotalR += profitR
+			winStreak, lossStreak = winStreak+1, 0
+		} else {
+			summary.Losses++
+			lossTotal += row.ProfitValue
+			lossTotalR += profitR
+			winStreak, lossStreak = 0, lossStreak+1
+		}
+		summary.MaxConsecutiveWins = max(summary.MaxConsecutiveWins, winStreak)
+		summary.MaxConsecutiveLosses = max(summary.MaxConsecutiveLosses, lossStreak)
+
+		if index == 0 || row.ProfitValue > summary.LargestWin {
+			summary.LargestWin = row.ProfitValue
+		}
+		if index == 0 || row.ProfitValue < summary.LargestLoss {
+			summary.LargestLoss = row.ProfitValue
+		}
+		if index == 0 || profitR > summary.LargestWinR {
+			summary.LargestWinR = profitR
+		}
+		if index == 0 || profitR < summary.LargestLossR {
+			summary.LargestLossR = profitR
+		}
+	}
+
+	summary.EndingBalance = startingBalance + summary.NetProfit
+	summary.WinRate = float64(summary.Wins) / float64(summary.Trades) * 100
+	summary.Expectancy = summary.NetProfit / float64(summary.Trades)
+	summary.ExpectancyR = summary.NetProfitR / float64(summary.Trades)
+	if grossLoss > 0 {
+		summary.ProfitFactor = grossProfit / grossLoss
+	}
+	if summary.Wins > 0 {
+		summary.AverageWin = winTotal / float64(summary.Wins)
+		summary.AverageWinR = winTotalR / float64(summary.Wins)
+	}
+	if summary.Losses > 0 {
+		summary.AverageLoss = lossTotal / float64(summary.Losses)
+		summary.AverageLossR = lossTotalR / float64(summary.Losses)
+	}
+
+	summary.Drawdown = calculateDrawdown(rows, startingBalance, summary.FirstTrade)
+
+	returns := dailyReturns(rows, startingBalance, summary.FirstTrade, summary.LastTrade)
+	summary.TradingDays = len(returns)
+	if summary.TradingDays > 0 {
+		summary.TradesPerDay = float64(summary.Trades) / float64(summary.TradingDays)
+	}
+	summary.Sharpe, summary.Sortino = ratios(returns)
+
+	span := days(summary.LastTrade.Sub(summary.FirstTrade))
+	if span >= minimumAnnualisedDays && startingBalance > 0 && summary.EndingBalance > 0 {
+		summary.CAGR = (math.Pow(summary.EndingBalance/startingBalance, 365.25/span) - 1) * 100
+	}
+	if summary.Drawdown.DepthPercent > 0 {
+		summary.MAR = summary.CAGR / summary.Drawdown.DepthPercent
+	}
+
+	summary.TimeInMarket = timeInMarket(rows, summary.FirstTrade, summary.LastTrade)
+	zeroNonFinite(reflect.ValueOf(summary).Elem())
+
+	return summary
+}
+
+// zeroNonFinite sets every float field of a struct that is infinite or not a number to 0, including those of nested
+// structs, as JSON cannot encode them.
+func zeroNonFinite(value reflect.Value) {
+	for index := 0; index < value.NumField(); index++ {
+		field := value.Field(index)
+		switch field.Kind() {
+		case reflect.Float64, reflect.Float32:
+			if math.IsInf(field.Float(), 0) || math.IsNaN(field.Float()) {
+				field.SetFloat(0)
+			}
+		case reflect.Struct:
+			zeroNonFinite(field)
+		}
+	}
+}
+
+// calculateDrawdown walks the equity after every trade to find the largest fall from a peak.
+func calculateDrawdown(rows []*tradeLog.Row, startingBalance float64, start time.Time) Drawdown {
+	var (
+		drawdown  Drawdown
+		equity    = startingBalance
+		peak      = startingBalance
+		peakTime  = start
+		trough    = startingBalance
+		troughAt  = start
+		inMaxDraw bool
+	)
+
+	for _, row := range rows {
+		equity += row.ProfitValue
+
+		if equity >= peak {
+			// The previous drawdown has recovered
+			if peak > trough {
+				drawdown.LongestDurationDays = math.Max(drawdown.LongestDurationDays, days(row.ClosedAtTime.Sub(peakTime)))
+				if inMaxDraw {
+					drawdown.RecoveryTime = row.ClosedAtTime
+					drawdown.DurationDays = days(row.ClosedAtTime.Sub(drawdown.PeakTime))
+					drawdown.RecoveryDays = days(row.ClosedAtTime.Sub(drawdown.TroughTime))
+					inMaxDraw = false
+				}
+			}
+			peak, peakTime, trough, troughAt = equity, row.ClosedAtTime, equity, row.ClosedAtTime
+			continue
+		}
+
+		if equity < trough {
+			trough, troughAt = equity, row.ClosedAtTime
+		}
+		if peak-trough > drawdown.Depth {
+			drawdown.Depth = peak - trough
+			if peak > 0 {
+				drawdown.DepthPercent = drawdown.Depth / peak * 100
+			}
+			drawdown.PeakTime, drawdown.TroughTime = peakTime, troughAt
+			drawdown.RecoveryTime = time.Time{}
+			inMaxDraw = true
+		}
+	}
+
+	// A drawdown that has not recovered lasts until the last trade
+	if len(rows) > 0 && peak > trough {
+		last := rows[len(rows)-1].ClosedAtTime
+		drawdown.LongestDurationDays = math.Max(drawdown.LongestDurationDays, days(last.Sub(peakTime)))
+		if inMaxDraw {
+			drawdown.DurationDays = days(last.Sub(drawdown.PeakTime))
+			drawdown.RecoveryDays = days(last.Sub(drawdown.TroughTime))
+		}
+	}
+
+	return drawdown
+}
+
+// dailyReturns returns the return of the account on every weekday from the first entry to the last exit,
+// as a fraction of the equity at the start of the day. Days without a trade closing have a return of 0.
+func dailyReturns(rows []*tradeLog.Row, startingBalance float64, first, last time.Time) []float64 {
+	profitByDay := make(map[string]float64)
+	for _, row := range rows {
+		profitByDay[row.ClosedAtTime.Format("2006-01-02")] += row.ProfitValue
+	}
+
+	var (
+		returns []float64
+		equity  = startingBalance
+	)
+	lastDay := time.Date(last.Year(), last.Month(), last.Day(), 0, 0, 0, 0, time.UTC)
+	for day := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, time.UTC); !day.After(lastDay); day = day.AddDate(0, 0, 1) {
+		profit := profitByDay[day.Format("2006-01-02")]
+		if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			// Weekend profit is added to the equity without counting as a trading day
+			equity += profit
+			continue
+		}
+
+		dailyReturn := 0.0
+		if equity != 0 {
+			dailyReturn = profit / equity
+		}
+		returns = append(returns, dailyReturn)
+		equity += profit
+	}
+
+	return returns
+}
+
+// ratios returns the annualised Sharpe and Sortino ratios of daily returns.
+func ratios(returns []float64) (float64, float64) {
+	if len(returns) < 2 {
+		return 0, 0
+	}
+
+	var mean float64
+	for _, dailyReturn := range returns {
+		mean += dailyReturn
+	}
+	mean /= float64(len(returns))
+
+	var variance, downside float64
+	for _, dailyReturn := range returns {
+		variance += (dailyReturn - mean) * (dailyReturn - mean)
+		if dailyReturn < 0 {
+			downside += dailyReturn * dailyReturn
+		}
+	}
+	standardDeviation := math.Sqrt(variance / float64(len(returns)-1))
+	downsideDeviation := math.Sqrt(downside / float64(len(returns)))
+
+	annualise := math.Sqrt(TradingDaysPerYear)
+	var sharpe, sortino float64
+	if standardDeviation > 0 {
+		sharpe = mean / standardDeviation * annualise
+	}
+	if downsideDeviation > 0 {
+		sortino = mean / downsideDeviation * annualise
+	}
+
+	return sharpe, sortino
+}
+
+// timeInMarket returns the percentage of time between first and last that at least one position was open.
+func timeInMarket(rows []*tradeLog.Row, first, last time.Time) float64 {
+	total := last.Sub(first)
+	if total <= 0 {
+		return 0
+	}
+
+	// Merge the overlapping holding periods of every trade
+	periods := make([]*tradeLog.Row, len(rows))
+	copy(periods, rows)
+	sort.SliceStable(periods, func(i, j int) bool {
+		return periods[i].TakenAt.Before(periods[j].TakenAt)
+	})
+
+	var (
+		held     time.Duration
+		openFrom time.Time
+		openTo   time.Time
+	)
+	for index, row := range periods {
+		if index == 0 || row.TakenAt.After(openTo) {
+			held += openTo.Sub(openFrom)
+			openFrom, openTo = row.TakenAt, row.ClosedAtTime
+			continue
+		}
+		if row.ClosedAtTime.After(openTo) {
+			openTo = row.ClosedAtTime
+		}
+	}
+	held += openTo.Sub(openFrom)
+
+	return float64(held) / float64(total) * 100
+}
+
+// Line is one formatted statistic of a summary.
+type Line struct {
+	// Name is the readable name of the statistic.
+	Name string
+
+	// Value is the statistic formatted for display.
+	Value string
+}
+
+// Lines returns the statistics of the summary formatted for display, in the order they are printed.
+func (s *Summary) Lines() []Line {
+	return []Line{
+		{"Starting balance", fmt.Sprintf("%.2f", s.StartingBalance)},
+		{"Ending balance", fmt.Sprintf("%.2f", s.EndingBalance)},
+		{"Net profit", fmt.Sprintf("%.2f (%.2fR)", s.NetProfit, s.NetProfitR)},
+		{"Trades", fmt.Sprintf("%d (%d wins, %d losses)", s.Trades, s.Wins, s.Losses)},
+		{"Win rate", fmt.Sprintf("%.2f%%", s.WinRate)},
+		{"Profit factor", fmt.Sprintf("%.2f", s.ProfitFactor)},
+		{"Expectancy", fmt.Sprintf("%.2f (%.2fR)", s.Expectancy, s.ExpectancyR)},
+		{"Average win", fmt.Sprintf("%.2f (%.2fR)", s.AverageWin, s.AverageWinR)},
+		{"Average loss", fmt.Sprintf("%.2f (%.2fR)", s.AverageLoss, s.AverageLossR)},
+		{"Largest win", fmt.Sprintf("%.2f (%.2fR)", s.LargestWin, s.LargestWinR)},
+		{"Largest loss", fmt.Sprintf("%.2f (%.2fR)", s.LargestLoss, s.LargestLossR)},
+		{"Max consecutive wins", fmt.Sprintf("%d", s.MaxConsecutiveWins)},
+		{"Max consecutive losses", fmt.Sprintf("%d", s.MaxConsecutiveLosses)},
+		{"Max drawdown", fmt.Sprintf("%.2f (%.2f%%)", s.Drawdown.Depth, s.Drawdown.DepthPercent)},
+		{"Max drawdown duration", fmt.Sprintf("%.1f days (recovery %.1f days)", s.Drawdown.DurationDays, s.Drawdown.RecoveryDays)},
+		{"Longest drawdown", fmt.Sprintf("%.1f days", s.Drawdown.LongestDurationDays)},
+		{"Sharpe", fmt.Sprintf("%.2f", s.Sharpe)},
+		{"Sortino", fmt.Sprintf("%.2f", s.Sortino)},
+		{"CAGR", fmt.Sprintf("%.2f%%", s.CAGR)},
+		{"MAR", fmt.Sprintf("%.2f", s.MAR)},
+		{"Time in market", fmt.Sprintf("%.2f%%", s.TimeInMarket)},
+		{"Trades per day", fmt.Sprintf("%.2f over %d trading days", s.TradesPerDay, s.TradingDays)},
+	}
+}
+
+// Print writes the summary as an aligned table, for the console.
+func (s *Summary) Print(w io.Writer) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, line := range s.Lines() {
+		if _, err := fmt.Fprintf(table, "%s\t%s\n", line.Name, line.Value); err != nil {
+			return err
+		}
+	}
+
+	return table.Flush()
+}
+
+// WriteJSON writes the summary to a JSON file on disk, creating or overwriting it.
+func (s *Summary) WriteJSON(filePath string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filePath, data, 0644)
+}
@@ -0,0 +1,133 @@
+package stats
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/tradeLog"
+	"github.com/stretchr/testify/require"
+)
+
+// mockMonday is the Monday the mock trades start on.
+var mockMonday = time.Date(2023, 10, 16, 10, 0, 0, 0, time.UTC)
+
+// mockRow is a helper to create a one hour trade taken on a day after mockMonday.
+func mockRow(day int, profitValue float64, profitR float32) *tradeLog.Row {
+	takenAt := mockMonday.AddDate(0, 0, day)
+	return &tradeLog.Row{
+		TakenAt:      takenAt,
+		ClosedAtTime: takenAt.Add(time.Hour),
+		ProfitValue:  profitValue,
+		Profit:       profitR,
+		Win:          profitValue > 0,
+	}
+}
+
+// mockLog returns a log that wins, draws down 300 over two losses, then recovers to a new high.
+func mockLog() *tradeLog.Log {
+	return &tradeLog.Log{
+		mockRow(3, 400, 4),
+		mockRow(0, 100, 1),
+		mockRow(1, -200, -2),
+		mockRow(2, -100, -1),
+	}
+}
+
+// TestCalculate tests the trade statistics of a log
+func TestCalculate(t *testing.T) {
+	summary := Calculate(mockLog(), 1000)
+
+	require.Equal(t, 1200.0, summary.EndingBalance)
+	require.Equal(t, 200.0, summary.NetProfit)
+	require.Equal(t, 2.0, summary.NetProfitR)
+	require.Equal(t, 4, summary.Trades)
+	require.Equal(t, 50.0, summary.WinRate)
+	require.InDelta(t, 500.0/300.0, summary.ProfitFactor, 1e-9)
+	require.Equal(t, 0.5, summary.ExpectancyR)
+	require.Equal(t, 50.0, summary.Expectancy)
+	require.Equal(t, 250.0, summary.AverageWin)
+	require.Equal(t, -150.0, summary.AverageLoss)
+	require.Equal(t, -1.5, summary.AverageLossR)
+	require.Equal(t, 400.0, summary.LargestWin)
+	require.Equal(t, -2.0, summary.LargestLossR)
+	require.Equal(t, 1, summary.MaxConsecutiveWins)
+	require.Equal(t, 2, summary.MaxConsecutiveLosses)
+	require.Equal(t, mockMonday, summary.FirstTrade)
+	require.Equal(t, 4, summary.TradingDays)
+	require.Equal(t, 1.0, summary.TradesPerDay)
+	require.InDelta(t, 4.0/73.0*100, summary.TimeInMarket, 1e-9)
+	require.Greater(t, summary.Sharpe, 0.0)
+	require.Greater(t, summary.Sortino, 0.0)
+	require.Greater(t, summary.CAGR, 0.0)
+	require.InDelta(t, summary.CAGR/summary.Drawdown.DepthPercent, summary.MAR, 1e-9)
+}
+
+// TestCalculateDrawdown tests the depth and duration of the largest drawdown
+func TestCalculateDrawdown(t *testing.T) {
+	drawdown := Calculate(mockLog(), 1000).Drawdown
+
+	require.Equal(t, 300.0, drawdown.Depth)
+	require.InDelta(t, 300.0/1100.0*100, drawdown.DepthPercent, 1e-9)
+	require.Equal(t, mockMonday.Add(time.Hour), drawdown.PeakTime)
+	require.Equal(t, mockMonday.AddDate(0, 0, 2).Add(time.Hour), drawdown.TroughTime)
+	require.Equal(t, mockMonday.AddDate(0, 0, 3).Add(time.Hour), drawdown.RecoveryTime)
+	require.Equal(t, 3.0, drawdown.DurationDays)
+	require.Equal(t, 1.0, drawdown.RecoveryDays)
+	require.Equal(t, 3.0, drawdown.LongestDurationDays)
+
+	// A drawdown that never recovers lasts until the last trade
+	unrecovered := (*mockLog())[1:]
+	drawdown = Calculate(&unrecovered, 1000).Drawdown
+	require.True(t, drawdown.RecoveryTime.IsZero())
+	require.Equal(t, 2.0, drawdown.DurationDays)
+}
+
+// TestCalculateEmpty tests that an empty log returns the starting balance
+func TestCalculateEmpty(t *testing.T) {
+	summary := Calculate(tradeLog.NewLog(), 1000)
+
+	require.Equal(t, 1000.0, summary.EndingBalance)
+	require.Zero(t, summary.Trades)
+}
+
+// TestCalculateShortSpan tests the CAGR is not annualised over less than a day, so the summary can be written as JSON
+func TestCalculateShortSpan(t *testing.T) {
+	row := mockRow(0, 30, 3)
+	row.ClosedAtTime = row.TakenAt.Add(15 * time.Minute)
+	summary := Calculate(&tradeLog.Log{row}, 1000)
+
+	require.Zero(t, summary.CAGR)
+	require.Zero(t, summary.MAR)
+	_, err := json.Marshal(summary)
+	require.NoError(t, err)
+
+	// A huge return over a day overflows the CAGR, which is zeroed rather than left infinite
+	huge := &tradeLog.Log{mockRow(0, 1e6, 100), mockRow(1, 1e6, 100)}
+	summary = Calculate(huge, 1)
+	require.Zero(t, summary.CAGR)
+	_, err = json.Marshal(summary)
+	require.NoError(t, err)
+}
+
+// TestSummaryOutput tests the console table and the JSON file
+func TestSummaryOutput(t *testing.T) {
+	summary := Calculate(mockLog(), 1000)
+
+	var buffer bytes.Buffer
+	require.NoError(t, summary.Print(&buffer))
+	require.Contains(t, buffer.String(), "Profit factor")
+
+	filePath := filepath.Join(t.TempDir(), "summary.json")
+	require.NoError(t, summary.WriteJSON(filePath))
+	data, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+
+	var loaded Summary
+	require.NoError(t, json.Unmarshal(data, &loaded))
+	require.Equal(t, summary.NetProfit, loaded.NetProfit)
+	require.Equal(t, summary.Drawdown.Depth, loaded.Drawdown.Depth)
+}
@@ -0,0 +1,54 @@
+package tradeConfig
+
+import (
+	"time"
+)
+
+var (
+	// Rejection is an equivalent to an enum for which entry rule rejected a candle.
+	Rejection = rejection{
+		OUTSIDE_SCHEDULE:  "OUTSIDE_SCHEDULE",
+		SMA_INTERSECT:     "SMA_INTERSECT",
+		INVALID_ENTRY:     "INVALID_ENTRY",
+		NO_BOUNDARY:       "NO_BOUNDARY",
+		BELOW_MINIMUM_RR:  "BELOW_MINIMUM_RR",
+		INVALID_DIRECTION: "INVALID_DIRECTION",
+	}
+)
+
+type rejection struct {
+	OUTSIDE_SCHEDULE  string
+	SMA_INTERSECT     string
+	INVALID_ENTRY     string
+	NO_BOUNDARY       string
+	BELOW_MINIMUM_RR  string
+	INVALID_DIRECTION string
+}
+
+// Signal is the result of applying the entry rules to one candle, whether it became a trade or not.
+// The fields are filled in as far as the rules got before the candle was rejected.
+type Signal struct {
+	// Instrument is the instrument the candle is for.
+	Instrument string
+
+	// Time is the time of the candle's close.
+	Time time.Time
+
+	// Direction is the direction from the SMA values, empty if they intersect.
+	Direction string
+
+	// EntryPrice is the close of the candle.
+	EntryPrice float64
+
+	// StopPrice is the stop the trade would use.
+	StopPrice float64
+
+	// TargetPrice is the value of the unbroken boundary the trade would target.
+	TargetPrice float64
+
+	// RR is the risk to reward of the stop and target.
+	RR float64
+
+	// Rejection is the entry rule that rejected the candle, one of Rejection, or empty if it is a valid entry.
+	Rejection string
+}
@@ -6,9 +6,22 @@ import (
 	"github.com/BenHiramTaylor/strongbow-backtester/internal/backtestData"
 	"github.com/BenHiramTaylor/strongbow-backtester/internal/utils"
 	"github.com/rs/zerolog/log"
+	"math"
 	"time"
 )
 
+var (
+	// ExitReason is an equivalent to an enum for why a trade was closed.
+	ExitReason = exitReason{STOP: "STOP", TARGET: "TARGET", SESSION_END: "SESSION_END", MARGIN_CALL: "MARGIN_CALL"}
+)
+
+type exitReason struct {
+	STOP        string
+	TARGET      string
+	SESSION_END string
+	MARGIN_CALL string
+}
+
 // Trade represents one single placed trade
 // Later outputted in the TradeLog
 type Trade struct {
@@ -30,6 +43,9 @@ type Trade struct {
 	// InitialStopPrice is the price value for our initial stop, this does not change
 	InitialStopPrice float64
 
+	// StopMoves is every change made to the stop after the trade was taken, in time order
+	StopMoves []StopMove
+
 	// TargetPrice is the price value for our target
 	TargetPrice float64
 
@@ -38,6 +54,106 @@ type Trade struct {
 
 	// ClosedAtTime is the time value we exited the trade at
 	ClosedAtTime time.Time
+
+	// ExitReason is why the trade was closed, one of ExitReason
+	ExitReason string
+
+	// SessionsHeld is the amount of session closes the trade was held through, 0 for an intraday trade
+	SessionsHeld int
+
+	// HeldOverWeekend is true if the trade was held through a weekend
+	HeldOverWeekend bool
+
+	// GapExit is true if the trade was closed on the open of a session because price gapped through its stop or target
+	GapExit bool
+
+	// MAE is the maximum adverse excursion, the furthest price moved against the trade from the entry
+	MAE float64
+
+	// MAETicks is MAE in ticks
+	MAETicks int
+
+	// MAETime is the time of the candle the MAE occurred on
+	MAETime time.Time
+
+	// MFE is the maximum favourable excursion, the furthest price moved in favour of the trade from the entry
+	MFE float64
+
+	// MFETicks is MFE in ticks
+	MFETicks int
+
+	// MFETime is the time of the candle the MFE occurred on
+	MFETime time.Time
+
+	// BarsHeld is the amount of candles applied to the trade after it was entered
+	BarsHeld int
+
+	// Contracts is the amount of contracts traded, set when the trade is sized by the portfolio simulation
+	Contracts int
+
+	// ProfitValue is the profit or loss in the base currency after commission, set when the trade is closed
+	// by the portfolio simulation
+	ProfitValue float64
+
+	// Currency is the currency of the contract, which NativeProfitValue is in
+	Currency string
+
+	// NativeProfitValue is the profit or loss in Currency after commission
+	NativeProfitValue float64
+
+	// FxRate is the amount of base currency one unit of Currency was worth when the trade closed
+	FxRate float64
+
+	// Equity is the account equity after this trade was closed, set by the portfolio simulation
+	Equity float64
+
+	// Context is a snapshot of why the trade was taken, from the candle it was entered on
+	Context Context
+}
+
+// Context is the state of the entry candle and the levels the entry rules used when a trade was taken.
+type Context struct {
+	// Region is the name of the region the trade was entered in, set by the portfolio simulation
+	Region string
+
+	// SmallSMA is the smaller simple moving average of the entry candle
+	SmallSMA float64
+
+	// LargeSMA is the larger simple moving average of the entry candle
+	LargeSMA float64
+
+	// StochasticK is the %K of the entry candle
+	StochasticK float64
+
+	// StochasticD is the %D of the entry candle
+	StochasticD float64
+
+	// SweptBoundaryTime is the time of the broken boundary the entry candle wicked through
+	SweptBoundaryTime time.Time
+
+	// SweptBoundaryValue is the value of the broken boundary the entry candle wicked through
+	SweptBoundaryValue float64
+
+	// TargetBoundaryTime is the time of the unbroken boundary used as the target
+	TargetBoundaryTime time.Time
+
+	// TargetBoundaryValue is the value of the unbroken boundary used as the target
+	TargetBoundaryValue float64
+
+	// RR is the risk to reward of the stop and target when the trade was taken
+	RR float64
+
+	// StopTicks is the distance between the entry and the initial stop in ticks
+	StopTicks int
+}
+
+// StopMove is a change made to the stop of an open trade.
+type StopMove struct {
+	// Time is the time of the candle the stop was moved on
+	Time time.Time
+
+	// Price is the price the stop was moved to
+	Price float64
 }
 
 // String is a stringer method for Trade
@@ -57,6 +173,66 @@ func (t Trade) String() string {
 	)
 }
 
+// ProfitR returns the gain or loss of a closed trade as a multiple of its initial risk.
+// If the entry price is equal to the initial stop then there is no risk, so this returns 0.
+func (t *Trade) ProfitR() float64 {
+	// Avoid division by zero if the EntryPrice is equal to the InitialStopPrice.
+	if t.EntryPrice == t.InitialStopPrice {
+		return 0
+	}
+
+	return (t.ClosedAtPrice - t.EntryPrice) / (t.EntryPrice - t.InitialStopPrice)
+}
+
+// risk returns the distance between the entry and the initial stop.
+func (t *Trade) risk() float64 {
+	return math.Abs(t.EntryPrice - t.InitialStopPrice)
+}
+
+// MAER returns the MAE as a multiple of the initial risk, or 0 if there is no risk.
+func (t *Trade) MAER() float64 {
+	if t.risk() == 0 {
+		return 0
+	}
+
+	return t.MAE / t.risk()
+}
+
+// MFER returns the MFE as a multiple of the initial risk, or 0 if there is no risk.
+func (t *Trade) MFER() float64 {
+	if t.risk() == 0 {
+		return 0
+	}
+
+	return t.MFE / t.risk()
+}
+
+// trackExcursion records one candle applied to the trade, updating the MAE and MFE with
+// the most favourable and most adverse prices the trade could have seen on it.
+func (t *Trade) trackExcursion(favourablePrice, adversePrice float64, at time.Time, tickSize float64) {
+	t.BarsHeld++
+
+	favourable, adverse := favourablePrice-t.EntryPrice, t.EntryPrice-adversePrice
+	if t.Direction == utils.TradeDirection.SHORT {
+		favourable, adverse = -favourable, -adverse
+	}
+
+	if adverse > t.MAE {
+		t.MAE = adverse
+		t.MAETime = at
+		if tickSize > 0 {
+			t.MAETicks = int(math.Round(adverse / tickSize))
+		}
+	}
+	if favourable > t.MFE {
+		t.MFE = favourable
+		t.MFETime = at
+		if tickSize > 0 {
+			t.MFETicks = int(math.Round(favourable / tickSize))
+		}
+	}
+}
+
 // Trades is a slice of Trade pointers.
 type Trades []*Trade
 
@@ -126,146 +302,206 @@ func GenerateTradesInWindow(
 			log.Debug().Msgf("We are in a trade, not considering this row %v.", tradeRow.Time)
 			continue
 		}
-		log.Debug().Str("rowTime", tradeRow.Time.Format("15:04")).Msg("Looking for trade at interval")
 
-		// Get the tradeDirection based on the SMA values
-		tradeDirection, err := tradeRow.TradeDirection()
-		if err != nil {
-			if errors.Is(err, backtestData.SMAValuesIntersect) {
-				// Do nothing as this is not a valid time to trade.
-				continue
-			}
+		trade := EvaluateEntry(tradeRow, instrumentConfig, instrument, tickSize)
+		if trade == nil {
+			continue
 		}
 
-		// Using the trade direction and levels, check if this is a valid candle to trade on.
-		// If it is not a valid entry then skip
-		if !tradeRow.IsValidEntry(tradeDirection) {
-			log.Debug().Str("rowTime", tradeRow.Time.Format("15:04")).Msg("Not a valid entry.")
-			continue
+		// Set the flag that we are in a trade
+		inTrade = true
+
+		// Validate the trade
+		trade.ValidateTradeWithWindow(tradeWindow, instrumentConfig, tickSize)
+
+		// Add the trade to the results
+		trades = append(trades, trade)
+	}
+	// If we have iterated through and not returned, then return with custom NoTradeFound error
+	return trades
+}
+
+// EvaluateEntry applies every entry rule to a single candle and returns the Trade to enter on its close,
+// or nil if the candle is not a valid entry.
+func EvaluateEntry(
+	tradeRow *backtestData.Row,
+	instrumentConfig *utils.InstrumentConfiguration,
+	instrument string,
+	tickSize float64,
+) *Trade {
+	trade, _ := EvaluateSignal(tradeRow, instrumentConfig, instrument, tickSize)
+	return trade
+}
+
+// EvaluateSignal applies every entry rule to a single candle and returns the Trade to enter on its close,
+// or nil if the candle is not a valid entry, along with the Signal recording why.
+func EvaluateSignal(
+	tradeRow *backtestData.Row,
+	instrumentConfig *utils.InstrumentConfiguration,
+	instrument string,
+	tickSize float64,
+) (*Trade, *Signal) {
+	log.Debug().Str("rowTime", tradeRow.Time.Format("15:04")).Msg("Looking for trade at interval")
+
+	signal := &Signal{Instrument: instrument, Time: tradeRow.Time, EntryPrice: tradeRow.Close}
+
+	// Skip rows outside the entry schedule, open trades are still managed until the end of the window
+	if !instrumentConfig.EntrySchedule.AllowsEntry(tradeRow.Time) {
+		log.Debug().Str("rowTime", tradeRow.Time.Format("15:04")).Msg("Outside of the entry schedule.")
+		signal.Rejection = Rejection.OUTSIDE_SCHEDULE
+		return nil, signal
+	}
+
+	// Get the tradeDirection based on the SMA values
+	tradeDirection, err := tradeRow.TradeDirection()
+	if err != nil {
+		if errors.Is(err, backtestData.SMAValuesIntersect) {
+			// Do nothing as this is not a valid time to trade.
+			signal.Rejection = Rejection.SMA_INTERSECT
+			return nil, signal
 		}
+	}
+	signal.Direction = tradeDirection
+
+	// Using the trade direction and levels, check if this is a valid candle to trade on.
+	// If it is not a valid entry then skip
+	swept := tradeRow.SweptBoundary(tradeDirection)
+	if swept == nil {
+		log.Debug().Str("rowTime", tradeRow.Time.Format("15:04")).Msg("Not a valid entry.")
+		signal.Rejection = Rejection.INVALID_ENTRY
+		return nil, signal
+	}
 
-		var (
-			oneRisk    float64
-			targetSize float64
-			stopPrice  float64
-			actualRR   float64
-			target     *backtestData.Boundary
-		)
+	var (
+		oneRisk    float64
+		targetSize float64
+		stopPrice  float64
+		actualRR   float64
+		target     *backtestData.Boundary
+	)
 
-		// Calculate Stop and risk sizes
-		switch tradeDirection {
-		case utils.TradeDirection.SHORT:
-			// Get the stop by adding two ticks onto the high of the candle
-			stopPrice = tradeRow.High
-			stopPrice += tickSize * float64(instrumentConfig.StopSizeAddition)
-			stopPrice = utils.RoundToDecimalLength(stopPrice, tickSize)
-
-			// Get the first target, sort by descending as we want the highest low
-			sortedBoundaries, err := tradeRow.LowBoundaries.GetSortedUnbrokenBoundary(false)
-			if err != nil {
-				if errors.Is(err, backtestData.NoBoundaryFound) {
-					log.Debug().Msgf("No unbroken boundaries found in LowBoundaries for %v", tradeRow.Time)
-					continue
-				} else {
-					log.Error().Msg(err.Error())
-					return nil
-				}
+	// Calculate Stop and risk sizes
+	switch tradeDirection {
+	case utils.TradeDirection.SHORT:
+		// Get the stop by adding two ticks onto the high of the candle
+		stopPrice = tradeRow.High
+		stopPrice += tickSize * float64(instrumentConfig.StopSizeAddition)
+		stopPrice = utils.RoundToDecimalLength(stopPrice, tickSize)
+		signal.StopPrice = stopPrice
+
+		// Get the first target, sort by descending as we want the highest low
+		sortedBoundaries, err := tradeRow.LowBoundaries.GetSortedUnbrokenBoundary(false)
+		if err != nil {
+			if !errors.Is(err, backtestData.NoBoundaryFound) {
+				log.Error().Msg(err.Error())
 			}
+			log.Debug().Msgf("No unbroken boundaries found in LowBoundaries for %v", tradeRow.Time)
+			signal.Rejection = Rejection.NO_BOUNDARY
+			return nil, signal
+		}
 
-			// Get the first target using the index
-			target = (*sortedBoundaries)[0]
-
-			// Get risk and target values
-			oneRisk = stopPrice - tradeRow.Close
-			targetSize = tradeRow.Close - target.Value
-
-			// Calculate RR and check it against the minimum
-			actualRR = calculateRR(oneRisk, targetSize)
-
-		case utils.TradeDirection.LONG:
-			// Get the stop by subtracting two ticks onto the low of the candle
-			stopPrice = tradeRow.Low
-			stopPrice -= tickSize * float64(instrumentConfig.StopSizeAddition)
-			stopPrice = utils.RoundToDecimalLength(stopPrice, tickSize)
-
-			// Get the first target sort by ascending as we want the lowest high
-			sortedBoundaries, err := tradeRow.HighBoundaries.GetSortedUnbrokenBoundary(true)
-			if err != nil {
-				if errors.Is(err, backtestData.NoBoundaryFound) {
-					log.Debug().Msgf("No unbroken boundaries found in HighBoundaries for %v", tradeRow.Time)
-					continue
-				} else {
-					log.Error().Msg(err.Error())
-					return nil
-				}
-			}
+		// Get the first target using the index
+		target = (*sortedBoundaries)[0]
 
-			// Get the first target using the index
-			target = (*sortedBoundaries)[0]
+		// Get risk and target values
+		oneRisk = stopPrice - tradeRow.Close
+		targetSize = tradeRow.Close - target.Value
 
-			// Get risk and target values
-			oneRisk = tradeRow.Close - stopPrice
-			targetSize = target.Value - tradeRow.Close
+		// Calculate RR and check it against the minimum
+		actualRR = calculateRR(oneRisk, targetSize)
 
-			// Calculate RR and check it against the minimum
-			actualRR = calculateRR(oneRisk, targetSize)
+	case utils.TradeDirection.LONG:
+		// Get the stop by subtracting two ticks onto the low of the candle
+		stopPrice = tradeRow.Low
+		stopPrice -= tickSize * float64(instrumentConfig.StopSizeAddition)
+		stopPrice = utils.RoundToDecimalLength(stopPrice, tickSize)
+		signal.StopPrice = stopPrice
 
-		default:
-			// Return invalid error if not SHORT OR LONG
-			log.Error().Msgf("Got invalid direction %s", tradeDirection)
-			continue
+		// Get the first target sort by ascending as we want the lowest high
+		sortedBoundaries, err := tradeRow.HighBoundaries.GetSortedUnbrokenBoundary(true)
+		if err != nil {
+			if !errors.Is(err, backtestData.NoBoundaryFound) {
+				log.Error().Msg(err.Error())
+			}
+			log.Debug().Msgf("No unbroken boundaries found in HighBoundaries for %v", tradeRow.Time)
+			signal.Rejection = Rejection.NO_BOUNDARY
+			return nil, signal
 		}
 
-		// Skip this trade if RR is not met
-		if actualRR < instrumentConfig.MinimumRR {
-			log.Info().Msgf(
-				"not taking trade at %v direction %s as it does not meet the minimum RR "+
-					"specified by the user. Minimum: %f, Trade: %f Entry: %f Stop: %f Target: %f",
-				tradeRow.Time,
-				tradeDirection,
-				instrumentConfig.MinimumRR,
-				actualRR,
-				tradeRow.Close,
-				stopPrice,
-				target.Value,
-			)
-			continue
-		}
+		// Get the first target using the index
+		target = (*sortedBoundaries)[0]
+
+		// Get risk and target values
+		oneRisk = tradeRow.Close - stopPrice
+		targetSize = target.Value - tradeRow.Close
 
-		// Info log that we are taking the trade
+		// Calculate RR and check it against the minimum
+		actualRR = calculateRR(oneRisk, targetSize)
+
+	default:
+		// Return invalid error if not SHORT OR LONG
+		log.Error().Msgf("Got invalid direction %s", tradeDirection)
+		signal.Rejection = Rejection.INVALID_DIRECTION
+		return nil, signal
+	}
+	signal.TargetPrice = target.Value
+	signal.RR = actualRR
+
+	// Skip this trade if RR is not met
+	if actualRR < instrumentConfig.MinimumRR {
 		log.Info().Msgf(
-			"%v Taking trade at %f with a "+
-				"target of %f and a stop of %f as it meets the users minimum RR of %f "+
-				"with an RR of %f",
+			"not taking trade at %v direction %s as it does not meet the minimum RR "+
+				"specified by the user. Minimum: %f, Trade: %f Entry: %f Stop: %f Target: %f",
 			tradeRow.Time,
-			tradeRow.Close,
-			target.Value,
-			stopPrice,
+			tradeDirection,
 			instrumentConfig.MinimumRR,
 			actualRR,
-		)
-
-		// Create the new trade
-		trade := newTrade(
-			instrument,
-			tradeRow.Time,
-			tradeDirection,
 			tradeRow.Close,
 			stopPrice,
 			target.Value,
 		)
+		signal.Rejection = Rejection.BELOW_MINIMUM_RR
+		return nil, signal
+	}
 
-		// Set the flag that we are in a trade
-		inTrade = true
-
-		// Validate the trade
-		trade.ValidateTradeWithWindow(tradeWindow, instrumentConfig, tickSize)
+	// Info log that we are taking the trade
+	log.Info().Msgf(
+		"%v Taking trade at %f with a "+
+			"target of %f and a stop of %f as it meets the users minimum RR of %f "+
+			"with an RR of %f",
+		tradeRow.Time,
+		tradeRow.Close,
+		target.Value,
+		stopPrice,
+		instrumentConfig.MinimumRR,
+		actualRR,
+	)
 
-		// Add the trade to the results
-		trades = append(trades, trade)
+	// Create the new trade with a snapshot of why it was taken
+	trade := newTrade(
+		instrument,
+		tradeRow.Time,
+		tradeDirection,
+		tradeRow.Close,
+		stopPrice,
+		target.Value,
+	)
+	trade.Context = Context{
+		SmallSMA:            tradeRow.SmallSMA,
+		LargeSMA:            tradeRow.LargeSMA,
+		StochasticK:         tradeRow.StochasticK,
+		StochasticD:         tradeRow.StochasticD,
+		SweptBoundaryTime:   swept.Time,
+		SweptBoundaryValue:  swept.Value,
+		TargetBoundaryTime:  target.Time,
+		TargetBoundaryValue: target.Value,
+		RR:                  actualRR,
 	}
-	// If we have iterated through and not returned, then return with custom NoTradeFound error
-	return trades
+	if tickSize > 0 {
+		trade.Context.StopTicks = int(math.Round(oneRisk / tickSize))
+	}
+
+	return trade, signal
 }
 
 // ValidateTradeWithWindow iterates over a trade window and a trade configuration object.
@@ -282,119 +518,188 @@ func (t *Trade) ValidateTradeWithWindow(
 			continue
 		}
 
-		// Determine the trade outcome based on the trade direction and price conditions
-		switch {
-		// If the trade is a LONG and the current row's low is less than or equal to the stop
-		case t.Direction == utils.TradeDirection.LONG && row.Low <= t.StopPrice:
-			// Stop condition met for a LONG trade
-			log.Debug().Msgf("Stop condition met for a LONG trade at %v as Stop: %f Low: %f",
-				row.Time,
-				t.StopPrice,
-				row.Low,
-			)
-
-			t.ClosedAtPrice = t.StopPrice
-			t.ClosedAtTime = row.Time
-			return // Exiting the loop as the trade is closed
-
-		// If the trade is a SHORT and the current row's high is greater than or equal to the stop
-		case t.Direction == utils.TradeDirection.SHORT && row.High >= t.StopPrice:
-			// Stop condition met for a SHORT trade
-			log.Debug().Msgf("Stop condition met for a SHORT trade at %v as Stop: %f High: %f",
-				row.Time,
-				t.StopPrice,
-				row.High,
-			)
-
-			t.ClosedAtPrice = t.StopPrice
-			t.ClosedAtTime = row.Time
-			return // Exiting the loop as the trade is closed
-
-		// If the trade is a LONG and the current row's high is greater than or equal to the target
-		case t.Direction == utils.TradeDirection.LONG && row.High >= t.TargetPrice:
-			// Target condition met for a LONG trade
-			log.Debug().Msgf("Target condition met for a LONG trade at %v as Target: %f High: %f",
-				row.Time,
-				t.TargetPrice,
-				row.High,
-			)
-
-			t.ClosedAtPrice = t.TargetPrice
-			t.ClosedAtTime = row.Time
-			return // Exiting the loop as the trade is closed
-
-		// If the trade is a SHORT and the current row's low is less than or equal to the target
-		case t.Direction == utils.TradeDirection.SHORT && row.Low <= t.TargetPrice:
-			// Target condition met for a SHORT trade
-			log.Debug().Msgf("Target condition met for a SHORT trade at %v as Target: %f Low: %f",
-				row.Time,
-				t.TargetPrice,
-				row.Low,
-			)
-			t.ClosedAtPrice = t.TargetPrice
-			t.ClosedAtTime = row.Time
-			return // Exiting the loop as the trade is closed
-
-		// If the TrailingStop is boolean
-		case instrumentConfig.TrailingStop:
-			// Dynamic Trailing Stop Logic
-			if t.Direction == utils.TradeDirection.LONG {
-				// Calculate the potential new stop price
-				if row.High > t.EntryPrice {
-					potentialNewStop := t.StopPrice + (row.High - t.EntryPrice)
-					potentialNewStop = utils.RoundToDecimalLength(potentialNewStop, tickSize)
-					// Update the stop price if the potential new stop is greater than the current stop price
-					if potentialNewStop > t.StopPrice {
-						t.StopPrice = potentialNewStop
-					}
+		// Exit the loop once the trade is closed
+		if t.Update(row, instrumentConfig, tickSize) {
+			return
+		}
+	}
+
+	// If the trade does not hit the stop or target by the end of the window,
+	// close the trade at the final price in the window.
+	lastRow := tradeWindow[len(tradeWindow)-1]
+	log.Debug().Msgf("Trade did not hit stop or target, closing at %v with value of %f",
+		lastRow.Time,
+		lastRow.Close,
+	)
+	t.close(lastRow.Close, lastRow.Time, ExitReason.SESSION_END)
+}
+
+// close sets the exit values of the trade.
+func (t *Trade) close(price float64, at time.Time, reason string) {
+	t.ClosedAtPrice = price
+	t.ClosedAtTime = at
+	t.ExitReason = reason
+}
+
+// CloseAtEndOfSession closes the trade on the close of the given row, this is used when a session ends.
+func (t *Trade) CloseAtEndOfSession(row *backtestData.Row) {
+	t.close(row.Close, row.Time, ExitReason.SESSION_END)
+}
+
+// IsOvernight returns true if the trade was held past the end of the session it was entered in.
+func (t *Trade) IsOvernight() bool {
+	return t.SessionsHeld > 0
+}
+
+// GapThrough checks the open of the first candle of a session for a trade held while the market was closed.
+// If price opened through the stop or target the trade is closed at the open, as neither could have been filled
+// at its price, and true is returned.
+func (t *Trade) GapThrough(row *backtestData.Row, tickSize float64) bool {
+	switch {
+	case t.Direction == utils.TradeDirection.LONG && row.Open <= t.StopPrice,
+		t.Direction == utils.TradeDirection.SHORT && row.Open >= t.StopPrice:
+		log.Debug().Msgf("Gapped through the stop at %v as Stop: %f Open: %f", row.Time, t.StopPrice, row.Open)
+		t.close(row.Open, row.Time, ExitReason.STOP)
+
+	case t.Direction == utils.TradeDirection.LONG && row.Open >= t.TargetPrice,
+		t.Direction == utils.TradeDirection.SHORT && row.Open <= t.TargetPrice:
+		log.Debug().Msgf("Gapped through the target at %v as Target: %f Open: %f", row.Time, t.TargetPrice, row.Open)
+		t.close(row.Open, row.Time, ExitReason.TARGET)
+
+	default:
+		return false
+	}
+
+	t.trackExcursion(row.Open, row.Open, row.Time, tickSize)
+	t.GapExit = true
+	return true
+}
+
+// Liquidate closes the trade at the given price and time, this is used when the account is margin called.
+func (t *Trade) Liquidate(price float64, at time.Time) {
+	t.close(price, at, ExitReason.MARGIN_CALL)
+}
+
+// Update applies one candle that occurred after the trade was taken to the trade.
+// It returns true if the candle hit the Stop/Target and closed the trade, otherwise it manages the stop.
+func (t *Trade) Update(
+	row *backtestData.Row,
+	instrumentConfig *utils.InstrumentConfiguration,
+	tickSize float64,
+) bool {
+	// Track the excursion of the candle, a candle beyond the stop or target closes the trade at that price
+	// so the excursion is limited to them
+	if t.Direction == utils.TradeDirection.LONG {
+		t.trackExcursion(math.Min(row.High, t.TargetPrice), math.Max(row.Low, t.StopPrice), row.Time, tickSize)
+	} else {
+		t.trackExcursion(math.Max(row.Low, t.TargetPrice), math.Min(row.High, t.StopPrice), row.Time, tickSize)
+	}
+
+	// Remember the stop so any move made by the stop management below is recorded
+	stopPrice := t.StopPrice
+
+	// Determine the trade outcome based on the trade direction and price conditions
+	switch {
+	// If the trade is a LONG and the current row's low is less than or equal to the stop
+	case t.Direction == utils.TradeDirection.LONG && row.Low <= t.StopPrice:
+		// Stop condition met for a LONG trade
+		log.Debug().Msgf("Stop condition met for a LONG trade at %v as Stop: %f Low: %f",
+			row.Time,
+			t.StopPrice,
+			row.Low,
+		)
+
+		t.close(t.StopPrice, row.Time, ExitReason.STOP)
+		return true // Exiting as the trade is closed
+
+	// If the trade is a SHORT and the current row's high is greater than or equal to the stop
+	case t.Direction == utils.TradeDirection.SHORT && row.High >= t.StopPrice:
+		// Stop condition met for a SHORT trade
+		log.Debug().Msgf("Stop condition met for a SHORT trade at %v as Stop: %f High: %f",
+			row.Time,
+			t.StopPrice,
+			row.High,
+		)
+
+		t.close(t.StopPrice, row.Time, ExitReason.STOP)
+		return true // Exiting as the trade is closed
+
+	// If the trade is a LONG and the current row's high is greater than or equal to the target
+	case t.Direction == utils.TradeDirection.LONG && row.High >= t.TargetPrice:
+		// Target condition met for a LONG trade
+		log.Debug().Msgf("Target condition met for a LONG trade at %v as Target: %f High: %f",
+			row.Time,
+			t.TargetPrice,
+			row.High,
+		)
+
+		t.close(t.TargetPrice, row.Time, ExitReason.TARGET)
+		return true // Exiting as the trade is closed
+
+	// If the trade is a SHORT and the current row's low is less than or equal to the target
+	case t.Direction == utils.TradeDirection.SHORT && row.Low <= t.TargetPrice:
+		// Target condition met for a SHORT trade
+		log.Debug().Msgf("Target condition met for a SHORT trade at %v as Target: %f Low: %f",
+			row.Time,
+			t.TargetPrice,
+			row.Low,
+		)
+
+		t.close(t.TargetPrice, row.Time, ExitReason.TARGET)
+		return true // Exiting as the trade is closed
+
+	// If the TrailingStop is boolean
+	case instrumentConfig.TrailingStop:
+		// Dynamic Trailing Stop Logic
+		if t.Direction == utils.TradeDirection.LONG {
+			// Calculate the potential new stop price
+			if row.High > t.EntryPrice {
+				potentialNewStop := t.StopPrice + (row.High - t.EntryPrice)
+				potentialNewStop = utils.RoundToDecimalLength(potentialNewStop, tickSize)
+				// Update the stop price if the potential new stop is greater than the current stop price
+				if potentialNewStop > t.StopPrice {
+					t.StopPrice = potentialNewStop
 				}
-			} else if t.Direction == utils.TradeDirection.SHORT {
-				// Calculate the potential new stop price
-				if row.Low < t.EntryPrice {
-					potentialNewStop := t.StopPrice - (t.EntryPrice - row.Low)
-					potentialNewStop = utils.RoundToDecimalLength(potentialNewStop, tickSize)
-					// Update the stop price if the potential new stop is less than the current stop price
-					if potentialNewStop < t.StopPrice {
-						t.StopPrice = potentialNewStop
-					}
+			}
+		} else if t.Direction == utils.TradeDirection.SHORT {
+			// Calculate the potential new stop price
+			if row.Low < t.EntryPrice {
+				potentialNewStop := t.StopPrice - (t.EntryPrice - row.Low)
+				potentialNewStop = utils.RoundToDecimalLength(potentialNewStop, tickSize)
+				// Update the stop price if the potential new stop is less than the current stop price
+				if potentialNewStop < t.StopPrice {
+					t.StopPrice = potentialNewStop
 				}
 			}
+		}
 
-			log.Debug().Msgf("Dynamic trailing stop adjusted to %f", t.StopPrice)
-
-		// If the MoveToBreakEvenAt is set to a value greater than 0 and the stop price is not equal to the entry price (we have not changed it yet)
-		case instrumentConfig.MoveToBreakEvenAt > 0 && t.StopPrice != t.EntryPrice:
-			// Calculate the profit target to move to break even, based on the percentage specified in the configuration
-			profitTarget := t.EntryPrice * (1 + instrumentConfig.MoveToBreakEvenAt/100)
-
-			// Determine if the profit target is reached for LONG or SHORT trades
-			profitTargetReached := false
-			if t.Direction == utils.TradeDirection.LONG {
-				// For a LONG trade, the profit target is reached when the high price exceeds the profit target
-				profitTargetReached = row.High >= profitTarget
-			} else if t.Direction == utils.TradeDirection.SHORT {
-				// For a SHORT trade, the profit target is reached when the low price is less than or equal to the profit target
-				profitTargetReached = row.Low <= profitTarget
-			}
+		log.Debug().Msgf("Dynamic trailing stop adjusted to %f", t.StopPrice)
+
+	// If the MoveToBreakEvenAt is set to a value greater than 0 and the stop price is not equal to the entry price (we have not changed it yet)
+	case instrumentConfig.MoveToBreakEvenAt > 0 && t.StopPrice != t.EntryPrice:
+		// Calculate the profit target to move to break even, based on the percentage specified in the configuration
+		profitTarget := t.EntryPrice * (1 + instrumentConfig.MoveToBreakEvenAt/100)
+
+		// Determine if the profit target is reached for LONG or SHORT trades
+		profitTargetReached := false
+		if t.Direction == utils.TradeDirection.LONG {
+			// For a LONG trade, the profit target is reached when the high price exceeds the profit target
+			profitTargetReached = row.High >= profitTarget
+		} else if t.Direction == utils.TradeDirection.SHORT {
+			// For a SHORT trade, the profit target is reached when the low price is less than or equal to the profit target
+			profitTargetReached = row.Low <= profitTarget
+		}
 
-			// If the profit target is reached, move the stop price to the entry price
-			if profitTargetReached {
-				t.StopPrice = t.EntryPrice
-				log.Debug().Msgf("Moved stop to break-even (entry price) at %f", t.StopPrice)
-			}
+		// If the profit target is reached, move the stop price to the entry price
+		if profitTargetReached {
+			t.StopPrice = t.EntryPrice
+			log.Debug().Msgf("Moved stop to break-even (entry price) at %f", t.StopPrice)
 		}
 	}
 
-	// If the trade does not hit the stop or target by the end of the window,
-	// close the trade at the final price in the window.
-	if t.ClosedAtPrice == 0 {
-		lastRow := tradeWindow[len(tradeWindow)-1]
-		log.Debug().Msgf("Trade did not hit stop or target, closing at %v with value of %f",
-			lastRow.Time,
-			lastRow.Close,
-		)
-
-		t.ClosedAtPrice = lastRow.Close
-		t.ClosedAtTime = lastRow.Time
+	if t.StopPrice != stopPrice {
+		t.StopMoves = append(t.StopMoves, StopMove{Time: row.Time, Price: t.StopPrice})
 	}
+
+	return false
 }
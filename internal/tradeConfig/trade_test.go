@@ -1,6 +1,7 @@
 package tradeConfig
 
 import (
+	"encoding/json"
 	"github.com/BenHiramTaylor/strongbow-backtester/internal/backtestData"
 	"github.com/BenHiramTaylor/strongbow-backtester/internal/utils"
 	"reflect"
@@ -296,3 +297,277 @@ func TestValidateTradeWithWindow(t *testing.T) {
 		})
 	}
 }
+
+// TestProfitR tests the ProfitR method of the Trade object
+func TestProfitR(t *testing.T) {
+	tests := []struct {
+		name  string
+		trade *Trade
+		want  float64
+	}{
+		{"LONG win", &Trade{EntryPrice: 100, InitialStopPrice: 95, ClosedAtPrice: 110}, 2},
+		{"LONG loss", &Trade{EntryPrice: 100, InitialStopPrice: 95, ClosedAtPrice: 95}, -1},
+		{"SHORT win", &Trade{EntryPrice: 100, InitialStopPrice: 105, ClosedAtPrice: 85}, 3},
+		{"SHORT loss", &Trade{EntryPrice: 100, InitialStopPrice: 105, ClosedAtPrice: 105}, -1},
+		{"no risk", &Trade{EntryPrice: 100, InitialStopPrice: 100, ClosedAtPrice: 105}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.trade.ProfitR(); got != tt.want {
+				t.Errorf("Trade.ProfitR() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestGapThrough tests that a trade is closed on the open when price gaps through the stop or target
+func TestGapThrough(t *testing.T) {
+	at := time.Date(2023, 10, 23, 2, 5, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		direction string
+		open      float64
+		closed    bool
+		reason    string
+	}{
+		{"LONG gap through stop", utils.TradeDirection.LONG, 90, true, ExitReason.STOP},
+		{"LONG gap through target", utils.TradeDirection.LONG, 125, true, ExitReason.TARGET},
+		{"LONG opens inside", utils.TradeDirection.LONG, 101, false, ""},
+		{"SHORT gap through stop", utils.TradeDirection.SHORT, 110, true, ExitReason.STOP},
+		{"SHORT gap through target", utils.TradeDirection.SHORT, 75, true, ExitReason.TARGET},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trade := &Trade{Direction: tt.direction, EntryPrice: 100, StopPrice: 95, TargetPrice: 120}
+			if tt.direction == utils.TradeDirection.SHORT {
+				trade.StopPrice, trade.TargetPrice = 105, 80
+			}
+
+			if got := trade.GapThrough(&backtestData.Row{Time: at, Open: tt.open}, 1); got != tt.closed {
+				t.Errorf("Trade.GapThrough() = %v, want %v", got, tt.closed)
+			}
+			if trade.GapExit != tt.closed || trade.ExitReason != tt.reason {
+				t.Errorf("GapExit = %v ExitReason = %s, want %v %s", trade.GapExit, trade.ExitReason, tt.closed, tt.reason)
+			}
+			// The trade is filled at the open
+			if tt.closed && (trade.ClosedAtPrice != tt.open || !trade.ClosedAtTime.Equal(at)) {
+				t.Errorf("closed at %f %v, want %f %v", trade.ClosedAtPrice, trade.ClosedAtTime, tt.open, at)
+			}
+		})
+	}
+}
+
+// mockEntryWindow returns a trade window with one valid LONG entry at 09:40 that hits its target at 09:50.
+func mockEntryWindow() backtestData.Data {
+	day := time.Date(2023, 10, 20, 9, 35, 0, 0, time.UTC)
+	brokenLow := backtestData.Boundaries{{Time: day.Add(-time.Hour), Value: 100, Broken: true}}
+	unbrokenHigh := backtestData.Boundaries{{Time: day.Add(-time.Hour), Value: 110}}
+
+	return backtestData.Data{
+		{Time: day, Open: 102, High: 103, Low: 101, Close: 102, SmallSMA: 101, LargeSMA: 100},
+		{
+			Time: day.Add(5 * time.Minute), Open: 102, High: 102, Low: 99, Close: 101, SmallSMA: 101, LargeSMA: 100,
+			LowBoundaries: brokenLow, HighBoundaries: unbrokenHigh,
+		},
+		{Time: day.Add(10 * time.Minute), Open: 101, High: 105, Low: 101, Close: 104, SmallSMA: 101, LargeSMA: 100},
+		{Time: day.Add(15 * time.Minute), Open: 104, High: 111, Low: 104, Close: 110, SmallSMA: 101, LargeSMA: 100},
+	}
+}
+
+// TestGenerateTradesInWindow tests that a valid entry is taken, and that the entry schedule can prevent it
+func TestGenerateTradesInWindow(t *testing.T) {
+	var excludingSchedule utils.EntrySchedule
+	if err := json.Unmarshal([]byte(`{"TimeRanges": [{"Start": "09:45", "End": "10:30"}]}`), &excludingSchedule); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name             string
+		instrumentConfig *utils.InstrumentConfiguration
+		wantTrades       int
+	}{
+		{"valid entry", &utils.InstrumentConfiguration{MinimumRR: 2, StopSizeAddition: 2}, 1},
+		{"RR below minimum", &utils.InstrumentConfiguration{MinimumRR: 5, StopSizeAddition: 2}, 0},
+		{
+			"outside entry schedule",
+			&utils.InstrumentConfiguration{MinimumRR: 2, StopSizeAddition: 2, EntrySchedule: &excludingSchedule},
+			0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trades := GenerateTradesInWindow(mockEntryWindow(), tt.instrumentConfig, "ES", 0.25)
+			if len(trades) != tt.wantTrades {
+				t.Fatalf("expected %d trades, got %d", tt.wantTrades, len(trades))
+			}
+			if tt.wantTrades == 0 {
+				return
+			}
+
+			trade := trades[0]
+			if trade.EntryPrice != 101 || trade.StopPrice != 98.5 || trade.TargetPrice != 110 {
+				t.Errorf("unexpected trade levels %v", trade)
+			}
+			if trade.ClosedAtPrice != 110 {
+				t.Errorf("expected trade to close at the target, got %f", trade.ClosedAtPrice)
+			}
+		})
+	}
+}
+
+// TestEvaluateSignal tests that every candle is given a Signal with the rule that rejected it
+func TestEvaluateSignal(t *testing.T) {
+	window := mockEntryWindow()
+	intersect := *window[0]
+	intersect.LargeSMA = intersect.SmallSMA
+
+	tests := []struct {
+		name             string
+		row              *backtestData.Row
+		instrumentConfig *utils.InstrumentConfiguration
+		wantTrade        bool
+		wantRejection    string
+	}{
+		{"valid entry", window[1], &utils.InstrumentConfiguration{MinimumRR: 2, StopSizeAddition: 2}, true, ""},
+		{"SMA intersect", &intersect, &utils.InstrumentConfiguration{}, false, Rejection.SMA_INTERSECT},
+		{"invalid entry", window[0], &utils.InstrumentConfiguration{}, false, Rejection.INVALID_ENTRY},
+		{
+			"RR below minimum",
+			window[1],
+			&utils.InstrumentConfiguration{MinimumRR: 5, StopSizeAddition: 2},
+			false,
+			Rejection.BELOW_MINIMUM_RR,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trade, signal := EvaluateSignal(tt.row, tt.instrumentConfig, "ES", 0.25)
+			if (trade != nil) != tt.wantTrade {
+				t.Fatalf("expected a trade %v, got %v", tt.wantTrade, trade)
+			}
+			if signal.Rejection != tt.wantRejection {
+				t.Errorf("expected rejection %q, got %q", tt.wantRejection, signal.Rejection)
+			}
+			if signal.Instrument != "ES" || !signal.Time.Equal(tt.row.Time) {
+				t.Errorf("unexpected signal %+v", signal)
+			}
+		})
+	}
+
+	// A taken trade has a snapshot of the entry candle and its levels
+	trade, _ := EvaluateSignal(window[1], &utils.InstrumentConfiguration{MinimumRR: 2, StopSizeAddition: 2}, "ES", 0.25)
+	want := Context{
+		SmallSMA:            101,
+		LargeSMA:            100,
+		SweptBoundaryTime:   window[1].LowBoundaries[0].Time,
+		SweptBoundaryValue:  100,
+		TargetBoundaryTime:  window[1].HighBoundaries[0].Time,
+		TargetBoundaryValue: 110,
+		RR:                  3.6,
+		StopTicks:           10,
+	}
+	if !reflect.DeepEqual(trade.Context, want) {
+		t.Errorf("unexpected context %+v, want %+v", trade.Context, want)
+	}
+
+	// A rejected candle still records the levels that were calculated
+	_, signal := EvaluateSignal(window[1], &utils.InstrumentConfiguration{MinimumRR: 5, StopSizeAddition: 2}, "ES", 0.25)
+	if signal.StopPrice != 98.5 || signal.TargetPrice != 110 || signal.RR != 3.6 {
+		t.Errorf("unexpected signal levels %+v", signal)
+	}
+}
+
+// TestExcursion tests that the MAE and MFE are tracked on every candle and limited to the stop and target
+func TestExcursion(t *testing.T) {
+	at := time.Date(2023, 10, 20, 9, 35, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		trade   *Trade
+		window  backtestData.Data
+		wantMAE float64
+		wantMFE float64
+	}{
+		{
+			"LONG reaching target",
+			&Trade{Direction: utils.TradeDirection.LONG, EntryPrice: 100, StopPrice: 95, InitialStopPrice: 95, TargetPrice: 110},
+			backtestData.Data{
+				{Time: at.Add(5 * time.Minute), High: 103, Low: 97},
+				{Time: at.Add(10 * time.Minute), High: 108, Low: 96},
+				{Time: at.Add(15 * time.Minute), High: 112, Low: 107},
+			},
+			4,
+			10,
+		},
+		{
+			"SHORT hitting stop",
+			&Trade{Direction: utils.TradeDirection.SHORT, EntryPrice: 100, StopPrice: 105, InitialStopPrice: 105, TargetPrice: 90},
+			backtestData.Data{
+				{Time: at.Add(5 * time.Minute), High: 101, Low: 98},
+				{Time: at.Add(10 * time.Minute), High: 102, Low: 97},
+				{Time: at.Add(15 * time.Minute), High: 107, Low: 99},
+			},
+			5,
+			3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.trade.TakenAt = at
+			tt.trade.ValidateTradeWithWindow(tt.window, &utils.InstrumentConfiguration{}, 0.5)
+
+			if tt.trade.MAE != tt.wantMAE || tt.trade.MFE != tt.wantMFE {
+				t.Errorf("MAE = %f MFE = %f, want %f %f", tt.trade.MAE, tt.trade.MFE, tt.wantMAE, tt.wantMFE)
+			}
+			if tt.trade.MAETicks != int(tt.wantMAE*2) || tt.trade.MFETicks != int(tt.wantMFE*2) {
+				t.Errorf("MAETicks = %d MFETicks = %d", tt.trade.MAETicks, tt.trade.MFETicks)
+			}
+			if tt.trade.BarsHeld != 3 {
+				t.Errorf("BarsHeld = %d, want 3", tt.trade.BarsHeld)
+			}
+		})
+	}
+
+	// The times and R multiples of the LONG trade
+	trade := tests[0].trade
+	if !trade.MAETime.Equal(at.Add(10*time.Minute)) || !trade.MFETime.Equal(at.Add(15*time.Minute)) {
+		t.Errorf("MAETime = %v MFETime = %v", trade.MAETime, trade.MFETime)
+	}
+	if trade.MAER() != 0.8 || trade.MFER() != 2 {
+		t.Errorf("MAER = %f MFER = %f, want 0.8 2", trade.MAER(), trade.MFER())
+	}
+}
+
+// TestStopMoves tests every move of the stop is recorded with the candle it was moved on
+func TestStopMoves(t *testing.T) {
+	time1 := time.Date(2023, 10, 20, 9, 0, 0, 0, time.UTC)
+	time2 := time1.Add(time.Hour)
+	time3 := time2.Add(time.Hour)
+	trade := &Trade{
+		TakenAt:          time1,
+		Direction:        "LONG",
+		EntryPrice:       100,
+		StopPrice:        50,
+		InitialStopPrice: 50,
+		TargetPrice:      300,
+	}
+	data := backtestData.Data{
+		&backtestData.Row{Time: time1, High: 111, Low: 90, Open: 105, Close: 100},
+		&backtestData.Row{Time: time2, High: 215, Low: 110, Open: 120, Close: 150},
+		&backtestData.Row{Time: time3, High: 250, Low: 130, Open: 180, Close: 150},
+	}
+
+	trade.ValidateTradeWithWindow(data, &utils.InstrumentConfiguration{MoveToBreakEvenAt: 50}, 0.5)
+
+	// The stop moves to break even once, and is left there on the next candle
+	if len(trade.StopMoves) != 1 {
+		t.Fatalf("expected 1 stop move, got: %d", len(trade.StopMoves))
+	}
+	if !trade.StopMoves[0].Time.Equal(time2) || trade.StopMoves[0].Price != 100 {
+		t.Errorf("expected stop move to 100 at %v, got: %+v", time2, trade.StopMoves[0])
+	}
+}
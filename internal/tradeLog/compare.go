@@ -0,0 +1,186 @@
+package tradeLog
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Change is an equivalent to an enum for how a trade differs between two runs.
+var Change = change{ADDED: "ADDED", REMOVED: "REMOVED", CHANGED: "CHANGED", UNCHANGED: "UNCHANGED"}
+
+type change struct {
+	ADDED     string
+	REMOVED   string
+	CHANGED   string
+	UNCHANGED string
+}
+
+// ComparisonRow is a struct representing one row of the comparison output CSV, one trade matched between a base
+// run and a candidate run by its instrument, entry time and direction.
+type ComparisonRow struct {
+	// Change is how the trade differs, ADDED if it is only in the candidate, REMOVED if it is only in the base,
+	// CHANGED if its outcome differs and UNCHANGED otherwise.
+	Change string `csv:"Change"`
+
+	// Differences is the outcome fields that differ for a CHANGED trade, separated by semicolons.
+	Differences string `csv:"Differences"`
+
+	// Instrument is the instrument symbol we traded.
+	Instrument string `csv:"Instrument"`
+
+	// TakenAt is the timestamp in which we entered the trade.
+	TakenAt time.Time `csv:"TakenAt"`
+
+	// Direction is the direction of the trade, either LONG or SHORT.
+	Direction string `csv:"Direction"`
+
+	// BaseExitReason is why the trade was closed in the base run.
+	BaseExitReason string `csv:"BaseExitReason"`
+
+	// CandidateExitReason is why the trade was closed in the candidate run.
+	CandidateExitReason string `csv:"CandidateExitReason"`
+
+	// BaseClosedAtTime is when the trade was closed in the base run.
+	BaseClosedAtTime time.Time `csv:"BaseClosedAtTime"`
+
+	// CandidateClosedAtTime is when the trade was closed in the candidate run.
+	CandidateClosedAtTime time.Time `csv:"CandidateClosedAtTime"`
+
+	// BaseWin is true if the trade won in the base run.
+	BaseWin bool `csv:"BaseWin"`
+
+	// CandidateWin is true if the trade won in the candidate run.
+	CandidateWin bool `csv:"CandidateWin"`
+
+	// BaseR is the profit of the trade in R in the base run, 0 if it was not taken.
+	BaseR float64 `csv:"BaseR"`
+
+	// CandidateR is the profit of the trade in R in the candidate run, 0 if it was not taken.
+	CandidateR float64 `csv:"CandidateR"`
+
+	// ChangeR is CandidateR minus BaseR, the effect of the change on the net profit in R.
+	ChangeR float64 `csv:"ChangeR"`
+
+	// BaseProfitValue is the profit of the trade in the base currency in the base run, 0 if it was not taken.
+	BaseProfitValue float64 `csv:"BaseProfitValue"`
+
+	// CandidateProfitValue is the profit of the trade in the base currency in the candidate run,
+	// 0 if it was not taken.
+	CandidateProfitValue float64 `csv:"CandidateProfitValue"`
+
+	// ChangeProfitValue is CandidateProfitValue minus BaseProfitValue.
+	ChangeProfitValue float64 `csv:"ChangeProfitValue"`
+}
+
+// ComparisonLog is a slice of ComparisonRow pointers.
+type ComparisonLog []*ComparisonRow
+
+// Compare matches the trades of a base and a candidate log by their Key and classifies every trade as added,
+// removed, changed or unchanged, in the order they were taken. A trade has changed if its exit reason, exit time,
+// win or profit in R differ.
+func Compare(base, candidate *Log) *ComparisonLog {
+	candidates := make(map[string]*Row, len(*candidate))
+	for _, row := range *candidate {
+		candidates[row.Key()] = row
+	}
+
+	comparison := new(ComparisonLog)
+	matched := make(map[string]bool, len(*base))
+	for _, baseRow := range *base {
+		candidateRow := candidates[baseRow.Key()]
+		matched[baseRow.Key()] = candidateRow != nil
+		*comparison = append(*comparison, compareRows(baseRow, candidateRow))
+	}
+	for _, candidateRow := range *candidate {
+		if !matched[candidateRow.Key()] {
+			*comparison = append(*comparison, compareRows(nil, candidateRow))
+		}
+	}
+
+	sort.SliceStable(*comparison, func(i, j int) bool {
+		return (*comparison)[i].TakenAt.Before((*comparison)[j].TakenAt)
+	})
+
+	return comparison
+}
+
+// compareRows returns the comparison of a trade in the base and candidate runs, either can be nil if the trade
+// was not taken in that run.
+func compareRows(base, candidate *Row) *ComparisonRow {
+	trade := base
+	if trade == nil {
+		trade = candidate
+	}
+	row := &ComparisonRow{Instrument: trade.Instrument, TakenAt: trade.TakenAt, Direction: trade.Direction}
+
+	if base != nil {
+		row.BaseExitReason, row.BaseClosedAtTime, row.BaseWin = base.ExitReason, base.ClosedAtTime, base.Win
+		row.BaseR, row.BaseProfitValue = float64(base.Profit), base.ProfitValue
+	}
+	if candidate != nil {
+		row.CandidateExitReason, row.CandidateClosedAtTime, row.CandidateWin = candidate.ExitReason, candidate.ClosedAtTime, candidate.Win
+		row.CandidateR, row.CandidateProfitValue = float64(candidate.Profit), candidate.ProfitValue
+	}
+	row.ChangeR = row.CandidateR - row.BaseR
+	row.ChangeProfitValue = row.CandidateProfitValue - row.BaseProfitValue
+
+	switch {
+	case base == nil:
+		row.Change = Change.ADDED
+	case candidate == nil:
+		row.Change = Change.REMOVED
+	default:
+		var differences []string
+		if base.ExitReason != candidate.ExitReason {
+			differences = append(differences, "ExitReason")
+		}
+		if !base.ClosedAtTime.Equal(candidate.ClosedAtTime) {
+			differences = append(differences, "ClosedAtTime")
+		}
+		if base.Win != candidate.Win {
+			differences = append(differences, "Win")
+		}
+		if base.Profit != candidate.Profit {
+			differences = append(differences, "R")
+		}
+
+		row.Change, row.Differences = Change.UNCHANGED, strings.Join(differences, ";")
+		if len(differences) > 0 {
+			row.Change = Change.CHANGED
+		}
+	}
+
+	return row
+}
+
+// ComparisonSummary is the amount of trades and the net effect on the profit of each kind of change.
+type ComparisonSummary struct {
+	// Trades is the amount of trades with the change.
+	Trades int
+
+	// ChangeR is the sum of the change in profit in R of the trades.
+	ChangeR float64
+
+	// ChangeProfitValue is the sum of the change in profit in the base currency of the trades.
+	ChangeProfitValue float64
+}
+
+// Summarise returns the ComparisonSummary of every kind of change, keyed by the change.
+func (l *ComparisonLog) Summarise() map[string]ComparisonSummary {
+	summaries := make(map[string]ComparisonSummary)
+	for _, row := range *l {
+		summary := summaries[row.Change]
+		summary.Trades++
+		summary.ChangeR += row.ChangeR
+		summary.ChangeProfitValue += row.ChangeProfitValue
+		summaries[row.Change] = summary
+	}
+
+	return summaries
+}
+
+// WriteComparison takes a ComparisonLog pointer and a file path as parameters and writes it to a CSV on disk
+func WriteComparison(l *ComparisonLog, filePath string) error {
+	return writeCSV(l, filePath)
+}
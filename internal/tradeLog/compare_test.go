@@ -0,0 +1,72 @@
+package tradeLog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestCompare tests trades are matched by instrument, entry time and direction and classified by how they differ
+func TestCompare(t *testing.T) {
+	takenAt := time.Date(2023, 10, 16, 9, 0, 0, 0, time.UTC)
+	trade := func(hour int, direction, exitReason string, profit float32) *Row {
+		return &Row{
+			Instrument:   "ES",
+			TakenAt:      takenAt.Add(time.Duration(hour) * time.Hour),
+			Direction:    direction,
+			ClosedAtTime: takenAt.Add(time.Duration(hour)*time.Hour + 30*time.Minute),
+			ExitReason:   exitReason,
+			Win:          profit > 0,
+			Profit:       profit,
+			ProfitValue:  float64(profit) * 100,
+		}
+	}
+
+	base := &Log{
+		trade(0, "LONG", "TARGET", 2),
+		trade(1, "LONG", "STOP", -1),
+		trade(2, "SHORT", "TARGET", 3),
+	}
+	candidate := &Log{
+		trade(0, "LONG", "TARGET", 2),
+		// Same entry in the other direction is a different trade
+		trade(1, "SHORT", "TARGET", 1.5),
+		trade(2, "SHORT", "SESSION_END", -0.5),
+	}
+
+	comparison := Compare(base, candidate)
+
+	require.Len(t, *comparison, 4)
+	require.Equal(t, Change.UNCHANGED, (*comparison)[0].Change)
+	require.Empty(t, (*comparison)[0].Differences)
+
+	// Trades taken at the same time keep the base trade first
+	require.Equal(t, Change.REMOVED, (*comparison)[1].Change)
+	require.Equal(t, -1.0, (*comparison)[1].BaseR)
+	require.Equal(t, 1.0, (*comparison)[1].ChangeR)
+	require.Equal(t, Change.ADDED, (*comparison)[2].Change)
+	require.Equal(t, "SHORT", (*comparison)[2].Direction)
+	require.Equal(t, 1.5, (*comparison)[2].ChangeR)
+
+	changed := (*comparison)[3]
+	require.Equal(t, Change.CHANGED, changed.Change)
+	require.Equal(t, "ExitReason;Win;R", changed.Differences)
+	require.Equal(t, "TARGET", changed.BaseExitReason)
+	require.Equal(t, "SESSION_END", changed.CandidateExitReason)
+	require.Equal(t, -3.5, changed.ChangeR)
+	require.Equal(t, -350.0, changed.ChangeProfitValue)
+
+	summaries := comparison.Summarise()
+	require.Equal(t, ComparisonSummary{Trades: 1, ChangeR: 1.5, ChangeProfitValue: 150}, summaries[Change.ADDED])
+	require.Equal(t, ComparisonSummary{Trades: 1, ChangeR: 1, ChangeProfitValue: 100}, summaries[Change.REMOVED])
+	require.Equal(t, 1, summaries[Change.UNCHANGED].Trades)
+
+	filePath := filepath.Join(t.TempDir(), "compare.csv")
+	require.NoError(t, WriteComparison(comparison, filePath))
+	contents, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	require.Contains(t, string(contents), "Change,Differences,Instrument,TakenAt,Direction")
+}
@@ -0,0 +1,142 @@
+package tradeLog
+
+// ExcursionRow is a struct representing one threshold of the MAE/MFE analysis output CSV.
+// Each row shows how many trades a stop or target placed at ThresholdR would have changed.
+type ExcursionRow struct {
+	// ThresholdR is the distance from the entry as a multiple of the initial risk.
+	ThresholdR float64 `csv:"ThresholdR"`
+
+	// WinnersMAEPercent is the percentage of winning trades whose MAE reached the threshold,
+	// these would have been stopped out by a stop at this distance.
+	WinnersMAEPercent float64 `csv:"WinnersMAEPercent"`
+
+	// LosersMAEPercent is the percentage of losing trades whose MAE reached the threshold,
+	// these would have lost no more than this with a stop at this distance.
+	LosersMAEPercent float64 `csv:"LosersMAEPercent"`
+
+	// MFEPercent is the percentage of all trades whose MFE reached the threshold,
+	// these would have hit a target at this distance.
+	MFEPercent float64 `csv:"MFEPercent"`
+
+	// LosersMFEPercent is the percentage of losing trades whose MFE reached the threshold before they lost.
+	LosersMFEPercent float64 `csv:"LosersMFEPercent"`
+}
+
+// ExcursionLog is a slice of ExcursionRow pointers.
+type ExcursionLog []*ExcursionRow
+
+// ExcursionSummary is the average excursions of the winning and losing trades in a Log.
+type ExcursionSummary struct {
+	// Winners is the amount of winning trades.
+	Winners int
+
+	// Losers is the amount of losing trades.
+	Losers int
+
+	// AverageWinnerMAER is the average MAE of winning trades in R.
+	AverageWinnerMAER float64
+
+	// AverageLoserMAER is the average MAE of losing trades in R.
+	AverageLoserMAER float64
+
+	// AverageWinnerMFER is the average MFE of winning trades in R.
+	AverageWinnerMFER float64
+
+	// AverageLoserMFER is the average MFE of losing trades in R.
+	AverageLoserMFER float64
+
+	// MFECapturePercent is the percentage of the MFE of winning trades that was kept as profit.
+	MFECapturePercent float64
+}
+
+// percentage returns count as a percentage of total, or 0 if there is no total.
+func percentage(count, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return float64(count) / float64(total) * 100
+}
+
+// ExcursionReport returns one ExcursionRow for every threshold from step up to and including maximum, in R.
+func (l *Log) ExcursionReport(step, maximum float64) *ExcursionLog {
+	var report ExcursionLog
+	if step <= 0 {
+		return &report
+	}
+
+	summary := l.SummariseExcursions()
+	for index := 1; float64(index)*step <= maximum+1e-9; index++ {
+		threshold := float64(index) * step
+
+		var winnersMAE, losersMAE, reachedMFE, losersMFE int
+		for _, row := range *l {
+			if row.MFER >= threshold {
+				reachedMFE++
+			}
+			switch {
+			case row.Win && row.MAER >= threshold:
+				winnersMAE++
+			case !row.Win && row.MAER >= threshold:
+				losersMAE++
+			}
+			if !row.Win && row.MFER >= threshold {
+				losersMFE++
+			}
+		}
+
+		report = append(report, &ExcursionRow{
+			ThresholdR:        threshold,
+			WinnersMAEPercent: percentage(winnersMAE, summary.Winners),
+			LosersMAEPercent:  percentage(losersMAE, summary.Losers),
+			MFEPercent:        percentage(reachedMFE, len(*l)),
+			LosersMFEPercent:  percentage(losersMFE, summary.Losers),
+		})
+	}
+
+	return &report
+}
+
+// SummariseExcursions returns the average excursions of the winning and losing trades in a Log.
+func (l *Log) SummariseExcursions() ExcursionSummary {
+	var (
+		summary         ExcursionSummary
+		winnerProfitR   float64
+		winnerMFERTotal float64
+		winnerMAERTotal float64
+		loserMAERTotal  float64
+		loserMFERTotal  float64
+	)
+
+	for _, row := range *l {
+		if row.Win {
+			summary.Winners++
+			winnerMAERTotal += row.MAER
+			winnerMFERTotal += row.MFER
+			winnerProfitR += float64(row.Profit)
+		} else {
+			summary.Losers++
+			loserMAERTotal += row.MAER
+			loserMFERTotal += row.MFER
+		}
+	}
+
+	if summary.Winners > 0 {
+		summary.AverageWinnerMAER = winnerMAERTotal / float64(summary.Winners)
+		summary.AverageWinnerMFER = winnerMFERTotal / float64(summary.Winners)
+	}
+	if summary.Losers > 0 {
+		summary.AverageLoserMAER = loserMAERTotal / float64(summary.Losers)
+		summary.AverageLoserMFER = loserMFERTotal / float64(summary.Losers)
+	}
+	if winnerMFERTotal > 0 {
+		summary.MFECapturePercent = winnerProfitR / winnerMFERTotal * 100
+	}
+
+	return summary
+}
+
+// WriteExcursions takes an ExcursionLog pointer and a file path as parameters and writes it to a CSV on disk
+func WriteExcursions(l *ExcursionLog, filePath string) error {
+	return writeCSV(l, filePath)
+}
@@ -0,0 +1,59 @@
+package tradeLog
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// mockExcursionLog returns a log of two winners and two losers with their excursions in R.
+func mockExcursionLog() *Log {
+	return &Log{
+		&Row{Win: true, Profit: 2, MAER: 0.25, MFER: 2},
+		&Row{Win: true, Profit: 1, MAER: 0.75, MFER: 2},
+		&Row{Win: false, Profit: -1, MAER: 1, MFER: 0.5},
+		&Row{Win: false, Profit: -1, MAER: 1, MFER: 1.5},
+	}
+}
+
+// TestSummariseExcursions tests the averages of the winning and losing trades
+func TestSummariseExcursions(t *testing.T) {
+	summary := mockExcursionLog().SummariseExcursions()
+
+	require.Equal(t, 2, summary.Winners)
+	require.Equal(t, 2, summary.Losers)
+	require.Equal(t, 0.5, summary.AverageWinnerMAER)
+	require.Equal(t, 2.0, summary.AverageWinnerMFER)
+	require.Equal(t, 1.0, summary.AverageLoserMAER)
+	require.Equal(t, 1.0, summary.AverageLoserMFER)
+	require.Equal(t, 75.0, summary.MFECapturePercent)
+}
+
+// TestExcursionReport tests the percentage of trades reaching each threshold
+func TestExcursionReport(t *testing.T) {
+	report := mockExcursionLog().ExcursionReport(0.5, 2)
+
+	require.Len(t, *report, 4)
+	require.Equal(t, []float64{0.5, 1, 1.5, 2}, []float64{
+		(*report)[0].ThresholdR, (*report)[1].ThresholdR, (*report)[2].ThresholdR, (*report)[3].ThresholdR,
+	})
+
+	half := (*report)[0]
+	require.Equal(t, 50.0, half.WinnersMAEPercent)
+	require.Equal(t, 100.0, half.LosersMAEPercent)
+	require.Equal(t, 100.0, half.MFEPercent)
+	require.Equal(t, 100.0, half.LosersMFEPercent)
+
+	two := (*report)[3]
+	require.Zero(t, two.WinnersMAEPercent)
+	require.Equal(t, 50.0, two.MFEPercent)
+	require.Zero(t, two.LosersMFEPercent)
+
+	require.Empty(t, *mockExcursionLog().ExcursionReport(0, 2))
+
+	// Check it can be written to disk
+	filePath := filepath.Join(t.TempDir(), "excursion.csv")
+	require.NoError(t, WriteExcursions(report, filePath))
+	require.FileExists(t, filePath)
+}
@@ -0,0 +1,78 @@
+package tradeLog
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+)
+
+// Key returns the instrument, entry time and direction of the trade, which is unique to a trade across runs.
+func (r *Row) Key() string {
+	return fmt.Sprintf("%s|%s|%s", r.Instrument, r.TakenAt.UTC().Format(time.RFC3339), r.Direction)
+}
+
+// Filter is the set of conditions a trade must meet to be kept, an empty condition keeps every trade.
+type Filter struct {
+	// Instruments is the instruments to keep.
+	Instruments []string
+
+	// Directions is the directions to keep, LONG or SHORT.
+	Directions []string
+
+	// Regions is the regions to keep.
+	Regions []string
+
+	// From is the earliest entry time to keep.
+	From time.Time
+
+	// To is the entry time to keep trades before.
+	To time.Time
+}
+
+// Includes returns true if the row meets every condition of the filter.
+func (f Filter) Includes(row *Row) bool {
+	matches := func(values []string, value string) bool {
+		return len(values) == 0 || slices.Contains(values, value)
+	}
+
+	return matches(f.Instruments, row.Instrument) &&
+		matches(f.Directions, row.Direction) &&
+		matches(f.Regions, row.Region) &&
+		(f.From.IsZero() || !row.TakenAt.Before(f.From)) &&
+		(f.To.IsZero() || row.TakenAt.Before(f.To))
+}
+
+// Filter returns a new Log of the rows that meet every condition of the filter.
+func (l *Log) Filter(f Filter) *Log {
+	filtered := NewLog()
+	for _, row := range *l {
+		if f.Includes(row) {
+			*filtered = append(*filtered, row)
+		}
+	}
+
+	return filtered
+}
+
+// Merge returns a new Log of the rows of every log in the order they were taken. A trade in more than one log,
+// such as from runs with overlapping dates, is only kept from the first log it is in.
+func Merge(logs ...*Log) *Log {
+	merged := NewLog()
+	seen := make(map[string]bool)
+	for _, l := range logs {
+		for _, row := range *l {
+			if seen[row.Key()] {
+				continue
+			}
+			seen[row.Key()] = true
+			*merged = append(*merged, row)
+		}
+	}
+
+	sort.SliceStable(*merged, func(i, j int) bool {
+		return (*merged)[i].TakenAt.Before((*merged)[j].TakenAt)
+	})
+
+	return merged
+}
@@ -0,0 +1,48 @@
+package tradeLog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// mockFilterLog returns a LONG ES trade in London and a SHORT NQ trade in New York a day later.
+func mockFilterLog() *Log {
+	takenAt := time.Date(2023, 10, 16, 9, 0, 0, 0, time.UTC)
+	return &Log{
+		{Instrument: "ES", Direction: "LONG", Region: "London", TakenAt: takenAt},
+		{Instrument: "NQ", Direction: "SHORT", Region: "New York", TakenAt: takenAt.AddDate(0, 0, 1)},
+	}
+}
+
+// TestFilter tests only the rows meeting every condition are kept
+func TestFilter(t *testing.T) {
+	l := mockFilterLog()
+
+	require.Len(t, *l.Filter(Filter{}), 2)
+	require.Equal(t, "ES", (*l.Filter(Filter{Instruments: []string{"ES"}}))[0].Instrument)
+	require.Equal(t, "NQ", (*l.Filter(Filter{Directions: []string{"SHORT"}}))[0].Instrument)
+	require.Empty(t, *l.Filter(Filter{Instruments: []string{"ES"}, Regions: []string{"New York"}}))
+
+	// From is inclusive and To is exclusive
+	from := (*l)[1].TakenAt
+	require.Equal(t, "NQ", (*l.Filter(Filter{From: from}))[0].Instrument)
+	require.Equal(t, "ES", (*l.Filter(Filter{To: from}))[0].Instrument)
+	require.Len(t, *l.Filter(Filter{To: from}), 1)
+}
+
+// TestMerge tests logs are merged in the order the trades were taken without duplicates
+func TestMerge(t *testing.T) {
+	first, second := mockFilterLog(), mockFilterLog()
+	(*second)[0].ProfitValue = 100
+	*second = append(Log{{Instrument: "ES", Direction: "LONG", TakenAt: (*first)[0].TakenAt.Add(-time.Hour)}}, *second...)
+
+	merged := Merge(second, first)
+
+	require.Len(t, *merged, 3)
+	require.True(t, (*merged)[0].TakenAt.Before((*merged)[1].TakenAt))
+	require.True(t, (*merged)[1].TakenAt.Before((*merged)[2].TakenAt))
+	// The duplicate is kept from the first log
+	require.Equal(t, 100.0, (*merged)[1].ProfitValue)
+}
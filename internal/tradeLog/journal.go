@@ -0,0 +1,90 @@
+package tradeLog
+
+import (
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/portfolio"
+)
+
+// JournalRow is a struct representing one row of the signal journal output CSV,
+// every candle the entry rules were applied to and what happened to it.
+type JournalRow struct {
+	// Instrument is the instrument symbol of the candle.
+	Instrument string `csv:"Instrument"`
+
+	// Region is the name of the region the candle's session is for.
+	Region string `csv:"Region"`
+
+	// Time is the timestamp of the candle's close.
+	Time time.Time `csv:"Time"`
+
+	// Direction is the direction from the SMA values, empty if they intersect.
+	Direction string `csv:"Direction"`
+
+	// EntryPrice is the close of the candle.
+	EntryPrice float64 `csv:"EntryPrice"`
+
+	// StopPrice is the stop the trade would use, 0 if the candle was rejected before it was calculated.
+	StopPrice float64 `csv:"StopPrice"`
+
+	// TargetPrice is the target the trade would use, 0 if the candle was rejected before it was calculated.
+	TargetPrice float64 `csv:"TargetPrice"`
+
+	// RR is the risk to reward of the stop and target.
+	RR float64 `csv:"RR"`
+
+	// MinimumRR is the minimum RR of the instrument.
+	MinimumRR float64 `csv:"MinimumRR"`
+
+	// Outcome is either TAKEN, REJECTED or SUPPRESSED.
+	Outcome string `csv:"Outcome"`
+
+	// Scope is what suppressed a SUPPRESSED trade.
+	Scope string `csv:"Scope"`
+
+	// Reason is the entry rule that rejected the candle, or why a SUPPRESSED trade was not taken.
+	Reason string `csv:"Reason"`
+}
+
+// JournalLog is a slice of JournalRow pointers.
+type JournalLog []*JournalRow
+
+// AddJournalRow adds a journaled signal to a JournalLog.
+func AddJournalRow(l *JournalLog, entry *portfolio.JournalEntry) *JournalLog {
+	row := &JournalRow{
+		Instrument:  entry.Signal.Instrument,
+		Region:      entry.Region,
+		Time:        entry.Signal.Time,
+		Direction:   entry.Signal.Direction,
+		EntryPrice:  entry.Signal.EntryPrice,
+		StopPrice:   entry.Signal.StopPrice,
+		TargetPrice: entry.Signal.TargetPrice,
+		RR:          entry.Signal.RR,
+		MinimumRR:   entry.MinimumRR,
+		Outcome:     entry.Outcome,
+		Scope:       entry.Scope,
+		Reason:      entry.Reason,
+	}
+
+	newLog := append(*l, row)
+	return &newLog
+}
+
+// CountByReason returns a mapping of Outcome to Reason to the amount of rows with them, TAKEN rows have no Reason.
+func (l *JournalLog) CountByReason() map[string]map[string]int {
+	counts := make(map[string]map[string]int)
+
+	for _, row := range *l {
+		if _, ok := counts[row.Outcome]; !ok {
+			counts[row.Outcome] = make(map[string]int)
+		}
+		counts[row.Outcome][row.Reason]++
+	}
+
+	return counts
+}
+
+// WriteJournal takes a JournalLog pointer and a file path as parameters and writes it to a CSV on disk
+func WriteJournal(l *JournalLog, filePath string) error {
+	return writeCSV(l, filePath)
+}
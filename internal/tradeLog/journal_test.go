@@ -0,0 +1,65 @@
+package tradeLog
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/portfolio"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/tradeConfig"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAddJournalRow tests the AddJournalRow function copies the signal and its outcome, and counts the reasons
+func TestAddJournalRow(t *testing.T) {
+	journalLog := new(JournalLog)
+	at := time.Date(2023, 10, 20, 9, 0, 0, 0, time.UTC)
+
+	for _, entry := range []*portfolio.JournalEntry{
+		{
+			Signal: &tradeConfig.Signal{
+				Instrument:  "ES",
+				Time:        at,
+				Direction:   "LONG",
+				EntryPrice:  101,
+				StopPrice:   98.5,
+				TargetPrice: 110,
+				RR:          3.6,
+				Rejection:   tradeConfig.Rejection.BELOW_MINIMUM_RR,
+			},
+			Region:    "New York",
+			MinimumRR: 5,
+			Outcome:   portfolio.Outcome.REJECTED,
+			Reason:    tradeConfig.Rejection.BELOW_MINIMUM_RR,
+		},
+		{
+			Signal:  &tradeConfig.Signal{Instrument: "ES", Time: at.Add(5 * time.Minute)},
+			Outcome: portfolio.Outcome.REJECTED,
+			Reason:  tradeConfig.Rejection.SMA_INTERSECT,
+		},
+		{
+			Signal:  &tradeConfig.Signal{Instrument: "ES", Time: at.Add(10 * time.Minute)},
+			Outcome: portfolio.Outcome.REJECTED,
+			Reason:  tradeConfig.Rejection.SMA_INTERSECT,
+		},
+		{Signal: &tradeConfig.Signal{Instrument: "NQ", Time: at}, Outcome: portfolio.Outcome.TAKEN},
+	} {
+		journalLog = AddJournalRow(journalLog, entry)
+	}
+
+	require.Len(t, *journalLog, 4)
+	row := (*journalLog)[0]
+	require.Equal(t, "New York", row.Region)
+	require.Equal(t, 3.6, row.RR)
+	require.Equal(t, 5.0, row.MinimumRR)
+	require.Equal(t, tradeConfig.Rejection.BELOW_MINIMUM_RR, row.Reason)
+
+	counts := journalLog.CountByReason()
+	require.Equal(t, 2, counts[portfolio.Outcome.REJECTED][tradeConfig.Rejection.SMA_INTERSECT])
+	require.Equal(t, 1, counts[portfolio.Outcome.TAKEN][""])
+
+	// Check it can be written to disk
+	filePath := filepath.Join(t.TempDir(), "journal.csv")
+	require.NoError(t, WriteJournal(journalLog, filePath))
+	require.FileExists(t, filePath)
+}
@@ -0,0 +1,68 @@
+package tradeLog
+
+import (
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/portfolio"
+)
+
+// MarginRow is a struct representing one row of the margin utilisation output CSV.
+type MarginRow struct {
+	// Time is the timestamp of the bar the margin was recorded on.
+	Time time.Time `csv:"Time"`
+
+	// Equity is the account equity including the unrealised profit of every open position.
+	Equity float64 `csv:"Equity"`
+
+	// OpenPositions is the amount of positions open.
+	OpenPositions int `csv:"OpenPositions"`
+
+	// InitialMargin is the initial margin held by every open position in the base currency.
+	InitialMargin float64 `csv:"InitialMargin"`
+
+	// MaintenanceMargin is the maintenance margin held by every open position in the base currency.
+	MaintenanceMargin float64 `csv:"MaintenanceMargin"`
+
+	// BuyingPower is the equity not held as initial margin.
+	BuyingPower float64 `csv:"BuyingPower"`
+
+	// Utilisation is the percentage of equity held as initial margin.
+	Utilisation float64 `csv:"Utilisation"`
+}
+
+// MarginLog is a slice of MarginRow pointers.
+type MarginLog []*MarginRow
+
+// AddMarginRow adds the margin held at a point in time to a MarginLog.
+func AddMarginRow(l *MarginLog, point *portfolio.MarginPoint) *MarginLog {
+	row := &MarginRow{
+		Time:              point.Time,
+		Equity:            point.Equity,
+		OpenPositions:     point.OpenPositions,
+		InitialMargin:     point.InitialMargin,
+		MaintenanceMargin: point.MaintenanceMargin,
+		BuyingPower:       point.BuyingPower(),
+		Utilisation:       point.Utilisation,
+	}
+
+	newLog := append(*l, row)
+	return &newLog
+}
+
+// MaxUtilisation returns the highest margin utilisation in a MarginLog.
+func (l *MarginLog) MaxUtilisation() float64 {
+	var maxUtilisation float64
+
+	for _, row := range *l {
+		if row.Utilisation > maxUtilisation {
+			maxUtilisation = row.Utilisation
+		}
+	}
+
+	return maxUtilisation
+}
+
+// WriteMargin takes a MarginLog pointer and a file path as parameters and writes it to a CSV on disk
+func WriteMargin(l *MarginLog, filePath string) error {
+	return writeCSV(l, filePath)
+}
@@ -0,0 +1,36 @@
+package tradeLog
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/portfolio"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAddMarginRow tests the AddMarginRow function copies the margin point and its buying power
+func TestAddMarginRow(t *testing.T) {
+	marginLog := new(MarginLog)
+	at := time.Date(2023, 10, 20, 9, 0, 0, 0, time.UTC)
+
+	marginLog = AddMarginRow(marginLog, &portfolio.MarginPoint{
+		Time:              at,
+		Equity:            10000,
+		OpenPositions:     1,
+		InitialMargin:     6000,
+		MaintenanceMargin: 5000,
+		Utilisation:       60,
+	})
+	marginLog = AddMarginRow(marginLog, &portfolio.MarginPoint{Time: at.Add(5 * time.Minute), Equity: 10200})
+
+	require.Len(t, *marginLog, 2)
+	require.Equal(t, 4000.0, (*marginLog)[0].BuyingPower)
+	require.Equal(t, 10200.0, (*marginLog)[1].BuyingPower)
+	require.Equal(t, 60.0, marginLog.MaxUtilisation())
+
+	// Check it can be written to disk
+	filePath := filepath.Join(t.TempDir(), "margin.csv")
+	require.NoError(t, WriteMargin(marginLog, filePath))
+	require.FileExists(t, filePath)
+}
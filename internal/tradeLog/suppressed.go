@@ -0,0 +1,61 @@
+package tradeLog
+
+import (
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/tradeConfig"
+)
+
+// SuppressedRow is a struct representing one row of the suppressed trades output CSV,
+// these are trades that met every entry rule but were stopped by a risk governor, a portfolio constraint
+// or could not be sized.
+type SuppressedRow struct {
+	// Instrument is the instrument symbol the trade would have been placed on.
+	Instrument string `csv:"Instrument"`
+
+	// TakenAt is the timestamp in which we would have entered the trade.
+	TakenAt time.Time `csv:"TakenAt"`
+
+	// Direction is the direction of the trade, either LONG or SHORT.
+	Direction string `csv:"Direction"`
+
+	// EntryPrice is the price value in which we would have entered the trade.
+	EntryPrice float64 `csv:"EntryPrice"`
+
+	// InitialStopPrice is the price value our initial stop would have been placed at.
+	InitialStopPrice float64 `csv:"InitialStopPrice"`
+
+	// TargetPrice is the price value for our target.
+	TargetPrice float64 `csv:"TargetPrice"`
+
+	// Scope is what suppressed the trade, the INSTRUMENT or ACCOUNT governor, POSITION_SIZING or PORTFOLIO.
+	Scope string `csv:"Scope"`
+
+	// Reason is why the trade was suppressed.
+	Reason string `csv:"Reason"`
+}
+
+// SuppressedLog is a slice of SuppressedRow pointers.
+type SuppressedLog []*SuppressedRow
+
+// AddSuppressedRow adds a trade that was suppressed to a SuppressedLog.
+func AddSuppressedRow(l *SuppressedLog, trade *tradeConfig.Trade, scope, reason string) *SuppressedLog {
+	row := &SuppressedRow{
+		Instrument:       trade.Instrument,
+		TakenAt:          trade.TakenAt,
+		Direction:        trade.Direction,
+		EntryPrice:       trade.EntryPrice,
+		InitialStopPrice: trade.InitialStopPrice,
+		TargetPrice:      trade.TargetPrice,
+		Scope:            scope,
+		Reason:           reason,
+	}
+
+	newLog := append(*l, row)
+	return &newLog
+}
+
+// WriteSuppressed takes a SuppressedLog pointer and a file path as parameters and writes it to a CSV on disk
+func WriteSuppressed(l *SuppressedLog, filePath string) error {
+	return writeCSV(l, filePath)
+}
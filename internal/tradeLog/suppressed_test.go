@@ -0,0 +1,38 @@
+package tradeLog
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/tradeConfig"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAddSuppressedRow tests the AddSuppressedRow function copies the trade and the governor that stopped it
+func TestAddSuppressedRow(t *testing.T) {
+	suppressedLog := new(SuppressedLog)
+	mockTrade := &tradeConfig.Trade{
+		Instrument:       "ES",
+		TakenAt:          time.Now(),
+		Direction:        "SHORT",
+		EntryPrice:       100.0,
+		StopPrice:        105.0,
+		InitialStopPrice: 105.0,
+		TargetPrice:      90.0,
+	}
+
+	updatedLog := AddSuppressedRow(suppressedLog, mockTrade, "ACCOUNT", "MAX_DAILY_LOSS")
+
+	require.Len(t, *updatedLog, 1)
+	row := (*updatedLog)[0]
+	require.Equal(t, mockTrade.Instrument, row.Instrument)
+	require.Equal(t, mockTrade.InitialStopPrice, row.InitialStopPrice)
+	require.Equal(t, "ACCOUNT", row.Scope)
+	require.Equal(t, "MAX_DAILY_LOSS", row.Reason)
+
+	// Check it can be written to disk
+	filePath := filepath.Join(t.TempDir(), "suppressed.csv")
+	require.NoError(t, WriteSuppressed(updatedLog, filePath))
+	require.FileExists(t, filePath)
+}
@@ -41,6 +41,45 @@ type Row struct {
 	// ClosedAtTime is a string representation of the timestamp in which we exited the trade.
 	ClosedAtTime time.Time `csv:"ClosedAtTime"`
 
+	// ExitReason is why the trade was closed, either STOP, TARGET, SESSION_END or MARGIN_CALL.
+	ExitReason string `csv:"ExitReason"`
+
+	// SessionsHeld is the amount of session closes the trade was held through, 0 for an intraday trade.
+	SessionsHeld int `csv:"SessionsHeld"`
+
+	// HeldOverWeekend is true if the trade was held through a weekend.
+	HeldOverWeekend bool `csv:"HeldOverWeekend"`
+
+	// GapExit is true if the trade was closed on a session open because price gapped through its stop or target.
+	GapExit bool `csv:"GapExit"`
+
+	// MAE is the maximum adverse excursion, the furthest price moved against the trade from the entry.
+	MAE float64 `csv:"MAE"`
+
+	// MAETicks is MAE in ticks.
+	MAETicks int `csv:"MAETicks"`
+
+	// MAER is MAE as a multiple of the initial risk.
+	MAER float64 `csv:"MAER"`
+
+	// MAETime is the timestamp of the candle the MAE occurred on.
+	MAETime time.Time `csv:"MAETime"`
+
+	// MFE is the maximum favourable excursion, the furthest price moved in favour of the trade from the entry.
+	MFE float64 `csv:"MFE"`
+
+	// MFETicks is MFE in ticks.
+	MFETicks int `csv:"MFETicks"`
+
+	// MFER is MFE as a multiple of the initial risk.
+	MFER float64 `csv:"MFER"`
+
+	// MFETime is the timestamp of the candle the MFE occurred on.
+	MFETime time.Time `csv:"MFETime"`
+
+	// BarsHeld is the amount of candles the trade was open for after it was entered.
+	BarsHeld int `csv:"BarsHeld"`
+
 	// TakenAtDate is a string representation for the DATE part only of the TakenAt field.
 	TakenAtDate string `csv:"TakenAtDate"`
 
@@ -52,6 +91,57 @@ type Row struct {
 
 	// ProfitPercentage is a float representing the total gain or loss, 1.0 would be no change
 	Profit float32 `csv:"Profit"`
+
+	// Contracts is the amount of contracts traded.
+	Contracts int `csv:"Contracts"`
+
+	// ProfitValue is the profit or loss of the trade in the base currency, after commission.
+	ProfitValue float64 `csv:"ProfitValue"`
+
+	// Currency is the currency of the contract.
+	Currency string `csv:"Currency"`
+
+	// NativeProfitValue is the profit or loss of the trade in Currency, after commission.
+	NativeProfitValue float64 `csv:"NativeProfitValue"`
+
+	// FxRate is the amount of base currency one unit of Currency was worth when the trade closed.
+	FxRate float64 `csv:"FxRate"`
+
+	// Equity is the running account equity after the trade closed.
+	Equity float64 `csv:"Equity"`
+
+	// Region is the name of the region the trade was entered in.
+	Region string `csv:"Region"`
+
+	// SmallSMA is the smaller simple moving average of the entry candle.
+	SmallSMA float64 `csv:"SmallSMA"`
+
+	// LargeSMA is the larger simple moving average of the entry candle.
+	LargeSMA float64 `csv:"LargeSMA"`
+
+	// StochasticK is the %K of the entry candle.
+	StochasticK float64 `csv:"StochasticK"`
+
+	// StochasticD is the %D of the entry candle.
+	StochasticD float64 `csv:"StochasticD"`
+
+	// SweptBoundaryTime is the timestamp of the broken boundary the entry candle wicked through.
+	SweptBoundaryTime time.Time `csv:"SweptBoundaryTime"`
+
+	// SweptBoundaryValue is the value of the broken boundary the entry candle wicked through.
+	SweptBoundaryValue float64 `csv:"SweptBoundaryValue"`
+
+	// TargetBoundaryTime is the timestamp of the unbroken boundary used as the target.
+	TargetBoundaryTime time.Time `csv:"TargetBoundaryTime"`
+
+	// TargetBoundaryValue is the value of the unbroken boundary used as the target.
+	TargetBoundaryValue float64 `csv:"TargetBoundaryValue"`
+
+	// RR is the risk to reward of the stop and target when the trade was taken.
+	RR float64 `csv:"RR"`
+
+	// StopTicks is the distance between the entry and the initial stop in ticks.
+	StopTicks int `csv:"StopTicks"`
 }
 
 // Log is a slice of Row pointers, representing the TradeLog
@@ -67,16 +157,47 @@ func NewLog() *Log {
 // And some extra column calculations as per request from the OMITTED team
 func AddRow(l *Log, trade *tradeConfig.Trade) *Log {
 	row := &Row{
-		Instrument:       trade.Instrument,
-		TakenAt:          trade.TakenAt,
-		Direction:        trade.Direction,
-		EntryPrice:       trade.EntryPrice,
-		StopPrice:        trade.StopPrice,
-		InitialStopPrice: trade.InitialStopPrice,
-		TargetPrice:      trade.TargetPrice,
-		ClosedAtPrice:    trade.ClosedAtPrice,
-		ClosedAtTime:     trade.ClosedAtTime,
-		Profit:           0,
+		Instrument:        trade.Instrument,
+		TakenAt:           trade.TakenAt,
+		Direction:         trade.Direction,
+		EntryPrice:        trade.EntryPrice,
+		StopPrice:         trade.StopPrice,
+		InitialStopPrice:  trade.InitialStopPrice,
+		TargetPrice:       trade.TargetPrice,
+		ClosedAtPrice:     trade.ClosedAtPrice,
+		ClosedAtTime:      trade.ClosedAtTime,
+		ExitReason:        trade.ExitReason,
+		SessionsHeld:      trade.SessionsHeld,
+		HeldOverWeekend:   trade.HeldOverWeekend,
+		GapExit:           trade.GapExit,
+		MAE:               trade.MAE,
+		MAETicks:          trade.MAETicks,
+		MAER:              trade.MAER(),
+		MAETime:           trade.MAETime,
+		MFE:               trade.MFE,
+		MFETicks:          trade.MFETicks,
+		MFER:              trade.MFER(),
+		MFETime:           trade.MFETime,
+		BarsHeld:          trade.BarsHeld,
+		Profit:            0,
+		Contracts:         trade.Contracts,
+		ProfitValue:       trade.ProfitValue,
+		Currency:          trade.Currency,
+		NativeProfitValue: trade.NativeProfitValue,
+		FxRate:            trade.FxRate,
+		Equity:            trade.Equity,
+
+		Region:              trade.Context.Region,
+		SmallSMA:            trade.Context.SmallSMA,
+		LargeSMA:            trade.Context.LargeSMA,
+		StochasticK:         trade.Context.StochasticK,
+		StochasticD:         trade.Context.StochasticD,
+		SweptBoundaryTime:   trade.Context.SweptBoundaryTime,
+		SweptBoundaryValue:  trade.Context.SweptBoundaryValue,
+		TargetBoundaryTime:  trade.Context.TargetBoundaryTime,
+		TargetBoundaryValue: trade.Context.TargetBoundaryValue,
+		RR:                  trade.Context.RR,
+		StopTicks:           trade.Context.StopTicks,
 	}
 
 	// Split taken at date and time as per request from OMITTED team
@@ -105,13 +226,8 @@ func AddRow(l *Log, trade *tradeConfig.Trade) *Log {
 		)
 	}
 
-	if row.EntryPrice == trade.InitialStopPrice {
-		// Avoid division by zero if InitiatedAt is equal to InitialStop.
-		row.Profit = 0
-	} else {
-		// TODO add scaling by R (risk ratio - usually 1%, but sometimes not!)
-		row.Profit = float32((trade.ClosedAtPrice - trade.EntryPrice) / (trade.EntryPrice - trade.InitialStopPrice))
-	}
+	// The profit is in R, the sized currency value is in ProfitValue
+	row.Profit = float32(trade.ProfitR())
 
 	newLog := append(*l, row)
 	return &newLog
@@ -130,6 +246,35 @@ func (l *Log) TotalWins() int {
 	return wins
 }
 
+// SplitOvernight splits a trade log into the trades closed in the session they were entered in,
+// and the trades held past the end of it.
+func (l *Log) SplitOvernight() (*Log, *Log) {
+	intraday, overnight := NewLog(), NewLog()
+
+	for _, row := range *l {
+		if row.SessionsHeld > 0 {
+			*overnight = append(*overnight, row)
+		} else {
+			*intraday = append(*intraday, row)
+		}
+	}
+
+	return intraday, overnight
+}
+
+// TotalGapExits returns the total amount of trades closed on a gap in a trade log.
+func (l *Log) TotalGapExits() int {
+	var gapExits int
+
+	for _, row := range *l {
+		if row.GapExit {
+			gapExits += 1
+		}
+	}
+
+	return gapExits
+}
+
 // CalculateCumulativeProfit TODO FILL THIS IN ROB.
 func (l *Log) CalculateCumulativeProfit() float32 {
 	var cumulativeProfit float32 = 1.0 // Start with a base multiplier of 1.
@@ -152,20 +297,61 @@ func (l *Log) SumTotalProfit() float32 {
 	return totalProfit
 }
 
-// Write takes a Log pointer as a parameter and writes it to a CSV on disk
-func Write(l *Log) error {
+// SumProfitValue is a function that returns the sum of the profit value column.
+func (l *Log) SumProfitValue() float64 {
+	var totalProfitValue float64
+
+	for _, row := range *l {
+		totalProfitValue += row.ProfitValue
+	}
+
+	return totalProfitValue
+}
+
+// Unsized returns true if the log has trades and none of them has a contract size, like a log written before trades
+// were sized, where the Contracts, ProfitValue and Equity columns are missing and read as 0. Only the statistics in R
+// are valid for an unsized log.
+func (l *Log) Unsized() bool {
+	for _, row := range *l {
+		if row.Contracts != 0 {
+			return false
+		}
+	}
+
+	return len(*l) > 0
+}
+
+// ResultsDirectory is the folder every backtest output file is written to.
+const ResultsDirectory = "backtesting_results"
+
+// ResultsFilePath returns the path of an output file in ResultsDirectory named by its prefix and the run time,
+// for example "backtesting_results/results-2006-01-02-15_04_05.csv". The directory is created if it is missing.
+func ResultsFilePath(prefix string, runTime time.Time, extension string) (string, error) {
 	// Ensure the directory exists
-	dir := "backtesting_results"
-	if _, err := os.Stat(dir); os.IsNotExist(err) {
-		err := os.Mkdir(dir, 0755)
+	if _, err := os.Stat(ResultsDirectory); os.IsNotExist(err) {
+		err := os.Mkdir(ResultsDirectory, 0755)
 		if err != nil {
-			return err
+			return "", err
 		} // Create the directory with read/write permissions
 	}
 
-	// Create or overwrite the CSV file in the directory
-	filePath := filepath.Join(dir, "results-"+time.Now().UTC().Format("2006-01-02-15_04_05")+".csv")
+	return filepath.Join(
+		ResultsDirectory,
+		fmt.Sprintf("%s-%s.%s", prefix, runTime.UTC().Format("2006-01-02-15_04_05"), extension),
+	), nil
+}
+
+// ResultsSubdirectory returns the path of a folder in ResultsDirectory named by its prefix and the run time,
+// for example "backtesting_results/charts-2006-01-02-15_04_05", for outputs with a file each. The folder is created
+// if it is missing.
+func ResultsSubdirectory(prefix string, runTime time.Time) (string, error) {
+	directory := filepath.Join(ResultsDirectory, fmt.Sprintf("%s-%s", prefix, runTime.UTC().Format("2006-01-02-15_04_05")))
+
+	return directory, os.MkdirAll(directory, 0755)
+}
 
+// writeCSV marshals any slice of csv tagged structs into a CSV file on disk, creating or overwriting it.
+func writeCSV(data any, filePath string) error {
 	file, err := os.Create(filePath)
 	if err != nil {
 		log.Error().Msg(err.Error())
@@ -179,17 +365,40 @@ func Write(l *Log) error {
 		}
 	}(file)
 
-	// Marshal the log into the CSV file
-	err = gocsv.MarshalFile(l, file)
+	// Marshal the data into the CSV file
+	return gocsv.MarshalFile(data, file)
+}
+
+// Write takes a Log pointer and a file path as parameters and writes it to a CSV on disk
+func Write(l *Log, filePath string) error {
+	return writeCSV(l, filePath)
+}
+
+// Read takes the file path of a CSV written by Write and reads it back into a Log.
+func Read(filePath string) (*Log, error) {
+	file, err := os.Open(filePath)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("error opening trade log: %w", err)
+	}
+	defer func(file *os.File) {
+		err := file.Close()
+		if err != nil {
+			// Handle the error, but don't return it
+			fmt.Printf("Error closing tradelog file: %v\n", err)
+		}
+	}(file)
+
+	l := NewLog()
+	if err := gocsv.UnmarshalFile(file, l); err != nil {
+		return nil, fmt.Errorf("error reading trade log %s: %w", filePath, err)
 	}
 
-	return nil
+	return l, nil
 }
 
-// CalculateProfitValue Simulates true cumulative profit by taking a starting balance and applying the trades profit
+// CalculateProfitValue Simulates cumulative profit by taking a starting balance and applying the trades profit
 // in timestamp order, then returning the final value after all trades.
+// This assumes risking 1% of the balance with fractional contracts, see the Equity column for the sized equity.
 func (l *Log) CalculateProfitValue(startingBalance float64) float64 {
 	// Return the starting balance if no trades in the log
 	if len(*l) == 0 {
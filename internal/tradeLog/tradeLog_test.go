@@ -3,6 +3,8 @@ package tradeLog
 import (
 	"github.com/BenHiramTaylor/strongbow-backtester/internal/tradeConfig"
 	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -29,6 +31,10 @@ func TestAddRow(t *testing.T) {
 		TargetPrice:      110.0,
 		ClosedAtPrice:    105.0,
 		ClosedAtTime:     time.Now().Add(24 * time.Hour),
+		Contracts:        2,
+		ProfitValue:      500,
+		Equity:           10500,
+		Context:          tradeConfig.Context{Region: "New York", SmallSMA: 101, RR: 2, StopTicks: 20},
 	}
 
 	// Execution
@@ -38,9 +44,55 @@ func TestAddRow(t *testing.T) {
 	require.Len(t, *updatedLog, 1, "Log should have one entry")
 	addedRow := (*updatedLog)[0]
 	require.Equal(t, mockTrade.Instrument, addedRow.Instrument, "Instrument should match")
+	require.Equal(t, float32(1), addedRow.Profit, "Profit should be in R")
+	require.Equal(t, mockTrade.Contracts, addedRow.Contracts, "Contracts should match")
+	require.Equal(t, mockTrade.ProfitValue, addedRow.ProfitValue, "ProfitValue should match")
+	require.Equal(t, mockTrade.Equity, addedRow.Equity, "Equity should match")
+	require.Equal(t, "New York", addedRow.Region, "Region should match")
+	require.Equal(t, 101.0, addedRow.SmallSMA, "SmallSMA should match")
+	require.Equal(t, 2.0, addedRow.RR, "RR should match")
+	require.Equal(t, 20, addedRow.StopTicks, "StopTicks should match")
 	// ... more assertions for each field
 }
 
+// TestRead tests a log written to disk is read back unchanged
+func TestRead(t *testing.T) {
+	takenAt := time.Date(2023, 10, 16, 9, 30, 0, 0, time.UTC)
+	l := NewLog()
+	l = AddRow(l, &tradeConfig.Trade{
+		Instrument:       "ES",
+		TakenAt:          takenAt,
+		Direction:        "SHORT",
+		EntryPrice:       4300.25,
+		StopPrice:        4310,
+		InitialStopPrice: 4310,
+		TargetPrice:      4280.75,
+		ClosedAtPrice:    4280.75,
+		ClosedAtTime:     takenAt.Add(time.Hour),
+		Contracts:        3,
+		ProfitValue:      2925,
+		Equity:           102925,
+		Context:          tradeConfig.Context{Region: "London", RR: 2, StopTicks: 39},
+	})
+
+	filePath := filepath.Join(t.TempDir(), "results.csv")
+	require.NoError(t, Write(l, filePath))
+
+	read, err := Read(filePath)
+	require.NoError(t, err)
+	require.Equal(t, l, read)
+
+	_, err = Read(filepath.Join(t.TempDir(), "missing.csv"))
+	require.Error(t, err)
+}
+
+// TestUnsized tests a log is unsized when none of its trades has a contract size
+func TestUnsized(t *testing.T) {
+	require.True(t, (&Log{&Row{Profit: 2}, &Row{Profit: -1}}).Unsized())
+	require.False(t, (&Log{&Row{Profit: 2}, &Row{Profit: -1, Contracts: 1}}).Unsized())
+	require.False(t, NewLog().Unsized())
+}
+
 // TestTotalWins tests the TotalWins method of the Log struct
 func TestTotalWins(t *testing.T) {
 	// Setup
@@ -57,6 +109,33 @@ func TestTotalWins(t *testing.T) {
 	require.Equal(t, 2, wins, "There should be 2 winning trades")
 }
 
+// TestSplitOvernight tests the SplitOvernight and TotalGapExits methods of the Log struct
+func TestSplitOvernight(t *testing.T) {
+	tradeLog := &Log{
+		&Row{Instrument: "ES"},
+		&Row{Instrument: "NQ", SessionsHeld: 1},
+		&Row{Instrument: "CL", SessionsHeld: 3, HeldOverWeekend: true, GapExit: true},
+	}
+
+	intraday, overnight := tradeLog.SplitOvernight()
+
+	require.Len(t, *intraday, 1)
+	require.Equal(t, "ES", (*intraday)[0].Instrument)
+	require.Len(t, *overnight, 2)
+	require.Equal(t, 1, overnight.TotalGapExits())
+	require.Zero(t, intraday.TotalGapExits())
+}
+
+// TestSumProfitValue tests the SumProfitValue method of the Log struct
+func TestSumProfitValue(t *testing.T) {
+	tradeLog := &Log{
+		&Row{ProfitValue: 250},
+		&Row{ProfitValue: -100},
+	}
+
+	require.Equal(t, 150.0, tradeLog.SumProfitValue(), "Sum of profit values should match")
+}
+
 // TestCalculateCumulativeProfit tests the CalculateCumulativeProfit method of the Log struct
 func TestCalculateCumulativeProfit(t *testing.T) {
 	// Setup
@@ -90,3 +169,41 @@ func TestCalculateProfitValue(t *testing.T) {
 	expectedBalance := int64(1210) // Adjust calculation as per logic
 	require.Equal(t, expectedBalance, finalBalance, "Final balance should match expected")
 }
+
+// TestResultsFilePath tests that the results directory is created and the file is named by the run time
+func TestResultsFilePath(t *testing.T) {
+	// Run inside a temporary directory so the results directory is not created in the package
+	workingDirectory, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	defer func() {
+		require.NoError(t, os.Chdir(workingDirectory))
+	}()
+
+	runTime := time.Date(2023, 10, 20, 9, 30, 15, 0, time.UTC)
+	filePath, err := ResultsFilePath("results", runTime, "csv")
+
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(ResultsDirectory, "results-2023-10-20-09_30_15.csv"), filePath)
+	require.DirExists(t, ResultsDirectory)
+
+	// Write a log to the path to make sure it can be written
+	require.NoError(t, Write(&Log{&Row{Instrument: "ES"}}, filePath))
+	require.FileExists(t, filePath)
+}
+
+// TestResultsSubdirectory tests the folder is created inside the results directory and named by the run time
+func TestResultsSubdirectory(t *testing.T) {
+	workingDirectory, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	defer func() {
+		require.NoError(t, os.Chdir(workingDirectory))
+	}()
+
+	directory, err := ResultsSubdirectory("charts", time.Date(2023, 10, 20, 9, 30, 15, 0, time.UTC))
+
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(ResultsDirectory, "charts-2023-10-20-09_30_15"), directory)
+	require.DirExists(t, directory)
+}
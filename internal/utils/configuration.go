@@ -5,10 +5,79 @@ import (
 	"errors"
 	"github.com/rs/zerolog/log"
 	"os"
+	"runtime"
+	"slices"
 	"strings"
 	"time"
 )
 
+var (
+	// PositionSizingModel is an equivalent to an enum for the position sizing models.
+	PositionSizingModel = positionSizingModel{
+		FIXED_CONTRACTS: "FIXED_CONTRACTS",
+		FIXED_RISK:      "FIXED_RISK",
+		PERCENT_RISK:    "PERCENT_RISK",
+	}
+
+	// ResamplingMode is an equivalent to an enum for how the Monte Carlo simulation resamples trades.
+	ResamplingMode = resamplingMode{
+		SHUFFLE:         "SHUFFLE",
+		BOOTSTRAP:       "BOOTSTRAP",
+		BLOCK_BOOTSTRAP: "BLOCK_BOOTSTRAP",
+	}
+
+	// Objective is an equivalent to an enum for the statistic the optimiser ranks parameter combinations by.
+	Objective = objective{
+		NET_R:                "NET_R",
+		PROFIT_FACTOR:        "PROFIT_FACTOR",
+		SHARPE:               "SHARPE",
+		RETURN_OVER_DRAWDOWN: "RETURN_OVER_DRAWDOWN",
+	}
+
+	// SearchMethod is an equivalent to an enum for how the optimiser picks the parameter combinations to evaluate.
+	SearchMethod = searchMethod{
+		GRID:    "GRID",
+		RANDOM:  "RANDOM",
+		GENETIC: "GENETIC",
+	}
+
+	// WalkForwardMode is an equivalent to an enum for how the in-sample window of a walk-forward analysis moves.
+	WalkForwardMode = walkForwardMode{
+		ROLLING:  "ROLLING",
+		ANCHORED: "ANCHORED",
+	}
+)
+
+type positionSizingModel struct {
+	FIXED_CONTRACTS string
+	FIXED_RISK      string
+	PERCENT_RISK    string
+}
+
+type resamplingMode struct {
+	SHUFFLE         string
+	BOOTSTRAP       string
+	BLOCK_BOOTSTRAP string
+}
+
+type objective struct {
+	NET_R                string
+	PROFIT_FACTOR        string
+	SHARPE               string
+	RETURN_OVER_DRAWDOWN string
+}
+
+type searchMethod struct {
+	GRID    string
+	RANDOM  string
+	GENETIC string
+}
+
+type walkForwardMode struct {
+	ROLLING  string
+	ANCHORED string
+}
+
 // JsonDate is a struct specifically to implement custom Unmarshalling on read.
 type JsonDate struct {
 	time.Time
@@ -58,6 +127,264 @@ type InstrumentConfiguration struct {
 
 	// MoveToBreakEvenAt is a float64 representing a percentage of profit to move the stop to break even at.
 	MoveToBreakEvenAt float64 `json:"MoveToBreakEvenAt,omitempty"`
+
+	// HoldOvernight carries trades still open at the end of a session into the next session instead of closing them.
+	HoldOvernight bool `json:"HoldOvernight,omitempty"`
+
+	// MaxSessionsHeld is the most session closes a trade can be held through when HoldOvernight is set,
+	// 0 holds until the stop or target is hit.
+	MaxSessionsHeld int `json:"MaxSessionsHeld,omitempty"`
+
+	// RiskGovernor is the set of daily guardrails applied to this instrument only (optional)
+	RiskGovernor *RiskGovernorConfiguration `json:"RiskGovernor,omitempty"`
+
+	// EntrySchedule restricts when new trades can be opened, separate to the region window (optional)
+	EntrySchedule *EntrySchedule `json:"EntrySchedule,omitempty"`
+
+	// PositionSizing overrides the account position sizing for this instrument only (optional)
+	PositionSizing *PositionSizingConfiguration `json:"PositionSizing,omitempty"`
+}
+
+// PositionSizingConfiguration is a struct representing how many contracts to trade on each entry.
+type PositionSizingConfiguration struct {
+	// Model is the sizing model to use, one of FIXED_CONTRACTS, FIXED_RISK or PERCENT_RISK.
+	Model string `json:"Model"`
+
+	// Contracts is the amount of contracts to trade with the FIXED_CONTRACTS model.
+	Contracts int `json:"Contracts,omitempty"`
+
+	// RiskValue is the amount of currency to risk on each trade with the FIXED_RISK model.
+	RiskValue float64 `json:"RiskValue,omitempty"`
+
+	// RiskPercent is the percentage of equity to risk on each trade with the PERCENT_RISK model.
+	RiskPercent float64 `json:"RiskPercent,omitempty"`
+
+	// MaxContracts is the most contracts that can be traded on one entry, 0 is unlimited (optional)
+	MaxContracts int `json:"MaxContracts,omitempty"`
+}
+
+// PositionSizing returns the position sizing for an instrument, which is its own if set, otherwise the accounts.
+func (c *Configuration) PositionSizing(instrument string) *PositionSizingConfiguration {
+	if instrumentConfig, ok := c.Instruments[instrument]; ok && instrumentConfig.PositionSizing != nil {
+		return instrumentConfig.PositionSizing
+	}
+	return c.AccountPositionSizing
+}
+
+// PortfolioConfiguration is a struct representing the constraints applied across every open position.
+// Every constraint is optional, a value of 0 disables it.
+type PortfolioConfiguration struct {
+	// MaxOpenPositions is the most positions that can be open at the same time across all instruments.
+	MaxOpenPositions int `json:"MaxOpenPositions,omitempty"`
+
+	// MaxOpenRiskPercent is the most risk, as a percentage of equity, that can be open at the same time.
+	MaxOpenRiskPercent float64 `json:"MaxOpenRiskPercent,omitempty"`
+
+	// MaxOpenRiskValue is the most risk, in the base currency, that can be open at the same time.
+	MaxOpenRiskValue float64 `json:"MaxOpenRiskValue,omitempty"`
+}
+
+// MarginConfiguration is a struct representing how the margin of each contract specification is applied to the account.
+type MarginConfiguration struct {
+	// EnforceBuyingPower skips entries whose initial margin is more than the buying power left in the account.
+	EnforceBuyingPower bool `json:"EnforceBuyingPower,omitempty"`
+
+	// LiquidateOnMarginCall closes every open position when equity falls below the maintenance margin held.
+	LiquidateOnMarginCall bool `json:"LiquidateOnMarginCall,omitempty"`
+}
+
+// TradeChartConfiguration is a struct representing which trades to draw a candlestick chart of, and how much of
+// the chart to show either side of the trade. Every filter is optional, an empty filter charts every trade.
+type TradeChartConfiguration struct {
+	// BarsBefore is the amount of candles to show before the entry (optional defaults to 30)
+	BarsBefore int `json:"BarsBefore,omitempty"`
+
+	// BarsAfter is the amount of candles to show after the exit (optional defaults to 10)
+	BarsAfter int `json:"BarsAfter,omitempty"`
+
+	// Instruments only charts the trades of these instruments (optional)
+	Instruments []string `json:"Instruments,omitempty"`
+
+	// Directions only charts the trades in these directions, LONG or SHORT (optional)
+	Directions []string `json:"Directions,omitempty"`
+
+	// ExitReasons only charts the trades closed for these reasons, such as STOP or TARGET (optional)
+	ExitReasons []string `json:"ExitReasons,omitempty"`
+}
+
+// Includes returns true if the trade matches every filter of the configuration.
+func (c *TradeChartConfiguration) Includes(instrument, direction, exitReason string) bool {
+	matches := func(filter []string, value string) bool {
+		return len(filter) == 0 || slices.Contains(filter, value)
+	}
+
+	return matches(c.Instruments, instrument) && matches(c.Directions, direction) && matches(c.ExitReasons, exitReason)
+}
+
+// MonteCarloConfiguration is a struct representing how the trades are resampled into alternative trade sequences.
+type MonteCarloConfiguration struct {
+	// Mode is how each sequence is resampled, one of SHUFFLE, BOOTSTRAP or BLOCK_BOOTSTRAP
+	// (optional defaults to SHUFFLE)
+	Mode string `json:"Mode,omitempty"`
+
+	// Simulations is the amount of sequences to resample (optional defaults to 1000)
+	Simulations int `json:"Simulations,omitempty"`
+
+	// BlockSize is the amount of consecutive trades resampled together with BLOCK_BOOTSTRAP (optional defaults to 5)
+	BlockSize int `json:"BlockSize,omitempty"`
+
+	// Seed is the seed of the random number generator, the same seed always resamples the same sequences
+	// (optional defaults to 1)
+	Seed int64 `json:"Seed,omitempty"`
+
+	// RuinDrawdownPercent is the drawdown from the peak equity, as a percentage, at which trading would be stopped.
+	// The risk of ruin is the percentage of sequences that reach it (optional defaults to 50)
+	RuinDrawdownPercent float64 `json:"RuinDrawdownPercent,omitempty"`
+
+	// Percentiles is the percentiles of every distribution to report (optional defaults to 5, 25, 50, 75 and 95)
+	Percentiles []float64 `json:"Percentiles,omitempty"`
+}
+
+// SignificanceConfiguration is a struct representing how the significance of the edge of the trades is tested.
+type SignificanceConfiguration struct {
+	// Resamples is the amount of times the trades are resampled for the confidence intervals (optional defaults to 1000)
+	Resamples int `json:"Resamples,omitempty"`
+
+	// ConfidenceLevel is the percentage of resamples every confidence interval contains (optional defaults to 95)
+	ConfidenceLevel float64 `json:"ConfidenceLevel,omitempty"`
+
+	// BaselineSimulations is the amount of times every trade is replaced with a random entry for the random entry
+	// baseline (optional defaults to 200)
+	BaselineSimulations int `json:"BaselineSimulations,omitempty"`
+
+	// SkipBaseline skips the random entry baseline (optional defaults to false)
+	SkipBaseline bool `json:"SkipBaseline,omitempty"`
+
+	// Seed is the seed of the random number generator, the same seed always gives the same results
+	// (optional defaults to 1)
+	Seed int64 `json:"Seed,omitempty"`
+}
+
+// ParameterRange is a struct representing the values the optimiser tries for one InstrumentConfiguration field,
+// either a list of Values or every Step from From to To inclusive. The RANDOM and GENETIC methods also take a range
+// without a Step, which is any value from From to To, or any whole value for an integer field.
+type ParameterRange struct {
+	// Values is the list of values to try, From, To and Step are ignored when it is set (optional)
+	Values []float64 `json:"Values,omitempty"`
+
+	// From is the first value of the range.
+	From float64 `json:"From,omitempty"`
+
+	// To is the last value of the range.
+	To float64 `json:"To,omitempty"`
+
+	// Step is the difference between each value of the range (optional with the RANDOM and GENETIC methods)
+	Step float64 `json:"Step,omitempty"`
+}
+
+// GeneticConfiguration is a struct representing how the GENETIC method breeds parameter combinations.
+type GeneticConfiguration struct {
+	// PopulationSize is the amount of combinations in each generation (optional defaults to 20)
+	PopulationSize int `json:"PopulationSize,omitempty"`
+
+	// EliteCount is the amount of the best combinations kept unchanged in the next generation (optional defaults to 2)
+	EliteCount int `json:"EliteCount,omitempty"`
+
+	// TournamentSize is the amount of combinations drawn at random to pick each parent, the best of which is the
+	// parent (optional defaults to 3)
+	TournamentSize int `json:"TournamentSize,omitempty"`
+
+	// CrossoverRate is the chance a child mixes the parameters of two parents rather than copying one
+	// (optional defaults to 0.8)
+	CrossoverRate float64 `json:"CrossoverRate,omitempty"`
+
+	// MutationRate is the chance each parameter of a child is changed (optional defaults to 0.1)
+	MutationRate float64 `json:"MutationRate,omitempty"`
+}
+
+// WalkForwardConfiguration is a struct representing how the walk-forward command splits the backtest into
+// in-sample windows to optimise on and out-of-sample windows to trade the best parameters on.
+type WalkForwardConfiguration struct {
+	// Mode is ROLLING to move the start of the in-sample window forward with each segment, or ANCHORED to always
+	// start it at BacktestStartDate (optional defaults to ROLLING)
+	Mode string `json:"Mode,omitempty"`
+
+	// InSampleDays is the length of each in-sample window in days, with ANCHORED it is the length of the first.
+	InSampleDays int `json:"InSampleDays"`
+
+	// OutOfSampleDays is the length of each out-of-sample window in days, and how far each segment moves forward.
+	OutOfSampleDays int `json:"OutOfSampleDays"`
+}
+
+// OptimizationConfiguration is a struct representing the parameters the optimize command searches.
+type OptimizationConfiguration struct {
+	// Parameters is the values to try for each InstrumentConfiguration field, keyed by the field name.
+	Parameters map[string]*ParameterRange `json:"Parameters"`
+
+	// Instruments is the instruments the parameters are applied to (optional defaults to every instrument)
+	Instruments []string `json:"Instruments,omitempty"`
+
+	// Objective is the statistic combinations are ranked by, one of NET_R, PROFIT_FACTOR, SHARPE or
+	// RETURN_OVER_DRAWDOWN (optional defaults to NET_R)
+	Objective string `json:"Objective,omitempty"`
+
+	// MinimumTrades is the amount of trades a combination needs to be ranked above the combinations with fewer
+	// (optional defaults to 0)
+	MinimumTrades int `json:"MinimumTrades,omitempty"`
+
+	// Workers is the amount of combinations evaluated in parallel (optional defaults to the amount of CPUs)
+	Workers int `json:"Workers,omitempty"`
+
+	// Method is how combinations are picked, one of GRID, RANDOM or GENETIC (optional defaults to GRID)
+	Method string `json:"Method,omitempty"`
+
+	// MaxEvaluations is the amount of combinations to evaluate before stopping, a RANDOM or GENETIC search needs it
+	// or MaxMinutes (optional defaults to no limit)
+	MaxEvaluations int `json:"MaxEvaluations,omitempty"`
+
+	// MaxMinutes is the minutes to search for before stopping (optional defaults to no limit)
+	MaxMinutes float64 `json:"MaxMinutes,omitempty"`
+
+	// Seed is the seed of the random number generator, the same seed always picks the same combinations
+	// (optional defaults to 1)
+	Seed int64 `json:"Seed,omitempty"`
+
+	// Genetic is how the GENETIC method breeds combinations (optional)
+	Genetic *GeneticConfiguration `json:"Genetic,omitempty"`
+
+	// RankByRobustness ranks combinations by the average score of themselves and their neighbours one step away on
+	// each parameter, rather than their own score, to favour plateaus over spikes. Combinations without any evaluated
+	// neighbours rank below those with some (optional defaults to false)
+	RankByRobustness bool `json:"RankByRobustness,omitempty"`
+
+	// Heatmaps is the pairs of parameters to write score heatmaps of (optional defaults to every pair)
+	Heatmaps [][]string `json:"Heatmaps,omitempty"`
+
+	// WalkForward is the in-sample and out-of-sample windows of the walk-forward command (optional)
+	WalkForward *WalkForwardConfiguration `json:"WalkForward,omitempty"`
+}
+
+// RiskGovernorConfiguration is a struct representing the daily guardrails the live bot trades with.
+// Every governor is optional, a value of 0 disables it.
+type RiskGovernorConfiguration struct {
+	// MaxTradesPerSession is the maximum amount of trades that can be entered in one session of a region, a trade
+	// held overnight counts towards the session it was entered in.
+	MaxTradesPerSession int `json:"MaxTradesPerSession,omitempty"`
+
+	// MaxDailyLossR is the loss in R at which we stop trading for the rest of the day.
+	MaxDailyLossR float64 `json:"MaxDailyLossR,omitempty"`
+
+	// MaxDailyLossValue is the loss in currency at which we stop trading for the rest of the day.
+	MaxDailyLossValue float64 `json:"MaxDailyLossValue,omitempty"`
+
+	// MaxConsecutiveLosses is the amount of losing trades in a row after which we stop trading for the day.
+	MaxConsecutiveLosses int `json:"MaxConsecutiveLosses,omitempty"`
+
+	// DailyProfitTargetR is the profit in R at which we stop trading for the rest of the day.
+	DailyProfitTargetR float64 `json:"DailyProfitTargetR,omitempty"`
+
+	// DailyProfitTargetValue is the profit in currency at which we stop trading for the rest of the day.
+	DailyProfitTargetValue float64 `json:"DailyProfitTargetValue,omitempty"`
 }
 
 // Configuration is a struct representing a read in config.json object
@@ -74,11 +401,57 @@ type Configuration struct {
 	// StartingBalance is a number to use as a balance to applied simulated profit to. (optional defaults to 10k)
 	StartingBalance float64 `json:"StartingBalance,omitempty"`
 
-	// Mapping of asset names to tick values (optional)
-	AssetTicks map[string]float64 `json:"AssetTicks,omitempty"`
+	// ContractSpecsFile is a path to a JSON file of additional contract specifications (optional)
+	ContractSpecsFile string `json:"ContractSpecsFile,omitempty"`
+
+	// ContractSpecPrecedence decides which specification is used when an instrument in ContractSpecsFile
+	// is also built-in, one of BUILTIN, FILE or STRICT (optional defaults to BUILTIN)
+	ContractSpecPrecedence string `json:"ContractSpecPrecedence,omitempty"`
 
 	// WriteProcessedDataToFile is a boolean to write the processed data to a file (optional defaults to false)
 	WriteProcessedDataToFile bool `json:"WriteProcessedDataToFile,omitempty"`
+
+	// WriteSignalJournal is a boolean to write every candle the entry rules were applied to,
+	// and why it was rejected, to a file (optional defaults to false)
+	WriteSignalJournal bool `json:"WriteSignalJournal,omitempty"`
+
+	// Breakdowns is the names of extra trade log columns to group the breakdown report by, on top of
+	// instrument, direction, weekday, hour, month, year and region (optional)
+	Breakdowns []string `json:"Breakdowns,omitempty"`
+
+	// TradeCharts writes a candlestick chart of every trade that matches its filters when set (optional)
+	TradeCharts *TradeChartConfiguration `json:"TradeCharts,omitempty"`
+
+	// MonteCarlo resamples the trades into alternative trade sequences when set (optional)
+	MonteCarlo *MonteCarloConfiguration `json:"MonteCarlo,omitempty"`
+
+	// Significance tests whether the edge of the trades is real rather than noise when set (optional)
+	Significance *SignificanceConfiguration `json:"Significance,omitempty"`
+
+	// Optimization is the parameters the optimize command searches (optional)
+	Optimization *OptimizationConfiguration `json:"Optimization,omitempty"`
+
+	// RiskGovernor is the set of daily guardrails applied across the whole account (optional)
+	RiskGovernor *RiskGovernorConfiguration `json:"RiskGovernor,omitempty"`
+
+	// BaseCurrency is the ISO currency code the account and every statistic is in (optional defaults to USD)
+	BaseCurrency string `json:"BaseCurrency,omitempty"`
+
+	// FxRates is a mapping of currency to the amount of BaseCurrency one unit is worth (optional)
+	FxRates map[string]float64 `json:"FxRates,omitempty"`
+
+	// FxRatesFile is a path to a CSV time series of rates with the columns Time,Currency,Rate,
+	// which are used before FxRates (optional)
+	FxRatesFile string `json:"FxRatesFile,omitempty"`
+
+	// AccountPositionSizing is how many contracts to trade on each entry (optional defaults to risking 1% of equity)
+	AccountPositionSizing *PositionSizingConfiguration `json:"PositionSizing,omitempty"`
+
+	// Portfolio is the set of constraints applied across every open position (optional)
+	Portfolio *PortfolioConfiguration `json:"Portfolio,omitempty"`
+
+	// Margin is how the initial and maintenance margin of each contract is applied to the account (optional)
+	Margin *MarginConfiguration `json:"Margin,omitempty"`
 }
 
 // newConfiguration This constructor is just an idiomatic wrapper to create default values for fields.
@@ -109,7 +482,13 @@ func newConfiguration() *Configuration {
 				time.UTC,
 			),
 		},
-		StartingBalance: 10000.0,
+		StartingBalance:        10000.0,
+		BaseCurrency:           "USD",
+		ContractSpecPrecedence: ContractSpecPrecedence.BUILTIN,
+		AccountPositionSizing: &PositionSizingConfiguration{
+			Model:       PositionSizingModel.PERCENT_RISK,
+			RiskPercent: 1,
+		},
 	}
 }
 
@@ -137,6 +516,17 @@ func (j *JsonDate) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// MarshalJSON Implements Marshal interface for JsonDate
+// This writes the same format UnmarshalJSON reads, so a configuration can be written and read back
+func (j JsonDate) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + j.Time.Format("2006-01-02") + `"`), nil
+}
+
+// AssetTicksRemoved is an error for when a configuration still sets AssetTicks, which contract specifications replaced.
+var AssetTicksRemoved = errors.New(
+	"AssetTicks has been replaced by contract specifications, move the tick sizes to a file set as ContractSpecsFile",
+)
+
 // LoadConfiguration loads the config.json file from the file path
 // There are some default variables for this if they are not present in config.json
 func LoadConfiguration(filePath string) (*Configuration, error) {
@@ -155,5 +545,100 @@ func LoadConfiguration(filePath string) (*Configuration, error) {
 		return cfg, err
 	}
 
+	// Tick sizes set in the removed AssetTicks field would otherwise be dropped without a word
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(configurationFile, &keys); err == nil {
+		if _, ok := keys["AssetTicks"]; ok {
+			return nil, AssetTicksRemoved
+		}
+	}
+
+	// Default the amount of candles either side of a charted trade
+	if cfg.TradeCharts != nil {
+		if cfg.TradeCharts.BarsBefore == 0 {
+			cfg.TradeCharts.BarsBefore = 30
+		}
+		if cfg.TradeCharts.BarsAfter == 0 {
+			cfg.TradeCharts.BarsAfter = 10
+		}
+	}
+
+	// Default the Monte Carlo simulation
+	if cfg.MonteCarlo != nil {
+		if cfg.MonteCarlo.Mode == "" {
+			cfg.MonteCarlo.Mode = ResamplingMode.SHUFFLE
+		}
+		if cfg.MonteCarlo.Simulations == 0 {
+			cfg.MonteCarlo.Simulations = 1000
+		}
+		if cfg.MonteCarlo.BlockSize == 0 {
+			cfg.MonteCarlo.BlockSize = 5
+		}
+		if cfg.MonteCarlo.Seed == 0 {
+			cfg.MonteCarlo.Seed = 1
+		}
+		if cfg.MonteCarlo.RuinDrawdownPercent == 0 {
+			cfg.MonteCarlo.RuinDrawdownPercent = 50
+		}
+		if len(cfg.MonteCarlo.Percentiles) == 0 {
+			cfg.MonteCarlo.Percentiles = []float64{5, 25, 50, 75, 95}
+		}
+	}
+
+	// Default the optimiser
+	if cfg.Optimization != nil {
+		if cfg.Optimization.Objective == "" {
+			cfg.Optimization.Objective = Objective.NET_R
+		}
+		if cfg.Optimization.Workers == 0 {
+			cfg.Optimization.Workers = runtime.NumCPU()
+		}
+		if cfg.Optimization.Method == "" {
+			cfg.Optimization.Method = SearchMethod.GRID
+		}
+		if cfg.Optimization.Seed == 0 {
+			cfg.Optimization.Seed = 1
+		}
+		if cfg.Optimization.Method == SearchMethod.GENETIC && cfg.Optimization.Genetic == nil {
+			cfg.Optimization.Genetic = new(GeneticConfiguration)
+		}
+		if genetic := cfg.Optimization.Genetic; genetic != nil {
+			if genetic.PopulationSize == 0 {
+				genetic.PopulationSize = 20
+			}
+			if genetic.EliteCount == 0 {
+				genetic.EliteCount = 2
+			}
+			if genetic.TournamentSize == 0 {
+				genetic.TournamentSize = 3
+			}
+			if genetic.CrossoverRate == 0 {
+				genetic.CrossoverRate = 0.8
+			}
+			if genetic.MutationRate == 0 {
+				genetic.MutationRate = 0.1
+			}
+		}
+		if cfg.Optimization.WalkForward != nil && cfg.Optimization.WalkForward.Mode == "" {
+			cfg.Optimization.WalkForward.Mode = WalkForwardMode.ROLLING
+		}
+	}
+
+	// Default the significance tests
+	if cfg.Significance != nil {
+		if cfg.Significance.Resamples == 0 {
+			cfg.Significance.Resamples = 1000
+		}
+		if cfg.Significance.ConfidenceLevel == 0 {
+			cfg.Significance.ConfidenceLevel = 95
+		}
+		if cfg.Significance.BaselineSimulations == 0 {
+			cfg.Significance.BaselineSimulations = 200
+		}
+		if cfg.Significance.Seed == 0 {
+			cfg.Significance.Seed = 1
+		}
+	}
+
 	return cfg, nil
 }
@@ -1,8 +1,13 @@
 package utils
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
+
+	"github.com/stretchr/testify/require"
 )
 
 func TestJsonDate_UnmarshalJSON(t *testing.T) {
@@ -43,6 +48,45 @@ func TestJsonDate_UnmarshalJSON(t *testing.T) {
 	}
 }
 
+// TestConfigurationRoundTrip tests an effective configuration can be written as JSON and read back unchanged
+func TestConfigurationRoundTrip(t *testing.T) {
+	cfg := newConfiguration()
+	cfg.Instruments = map[string]*InstrumentConfiguration{
+		"ES": {
+			MinimumRR: 2,
+			EntrySchedule: &EntrySchedule{
+				TimeRanges: []TimeRange{{
+					Start: JsonClock{time.Date(0, 1, 1, 9, 30, 0, 0, time.UTC)},
+					End:   JsonClock{time.Date(0, 1, 1, 16, 0, 0, 0, time.UTC)},
+				}},
+				Weekdays:      []JsonWeekday{{time.Monday}, {time.Friday}},
+				ExcludedDates: []JsonDate{{time.Date(2023, 12, 25, 0, 0, 0, 0, time.UTC)}},
+			},
+		},
+	}
+
+	data, err := json.Marshal(cfg)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"BacktestStartDate":"2020-01-01"`)
+
+	read := new(Configuration)
+	require.NoError(t, json.Unmarshal(data, read))
+	require.Equal(t, cfg, read)
+}
+
+// TestLoadConfigurationAssetTicks tests a configuration that still sets the removed AssetTicks fails to load
+func TestLoadConfigurationAssetTicks(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(filePath, []byte(`{"AssetTicks": {"ES": 0.25}}`), 0644))
+	_, err := LoadConfiguration(filePath)
+	require.ErrorIs(t, err, AssetTicksRemoved)
+
+	require.NoError(t, os.WriteFile(filePath, []byte(`{"StartingBalance": 5000}`), 0644))
+	cfg, err := LoadConfiguration(filePath)
+	require.NoError(t, err)
+	require.Equal(t, 5000.0, cfg.StartingBalance)
+}
+
 func TestNewConfiguration(t *testing.T) {
 	cfg := newConfiguration()
 
@@ -83,3 +127,21 @@ func TestNewConfiguration(t *testing.T) {
 		)
 	}
 }
+
+func TestTradeChartConfigurationIncludes(t *testing.T) {
+	// An empty filter includes every trade
+	if !(&TradeChartConfiguration{}).Includes("ES", TradeDirection.LONG, "STOP") {
+		t.Errorf("expected a configuration without filters to include every trade")
+	}
+
+	cfg := &TradeChartConfiguration{Instruments: []string{"ES", "NQ"}, ExitReasons: []string{"TARGET"}}
+	if !cfg.Includes("NQ", TradeDirection.SHORT, "TARGET") {
+		t.Errorf("expected a NQ trade closed at the target to be included")
+	}
+	if cfg.Includes("NQ", TradeDirection.SHORT, "STOP") {
+		t.Errorf("expected a trade closed at the stop to be excluded")
+	}
+	if cfg.Includes("CL", TradeDirection.SHORT, "TARGET") {
+		t.Errorf("expected a CL trade to be excluded")
+	}
+}
@@ -0,0 +1,201 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ContractSpecInvalid is an error for when a contract specification is missing a value or has an impossible one.
+	ContractSpecInvalid = errors.New("contract specification is invalid")
+	// ContractSpecConflict is an error for when a loaded contract specification differs from a built-in one
+	// and the precedence does not allow it to be overridden.
+	ContractSpecConflict = errors.New("contract specification conflicts with the built-in specification")
+	// ContractSpecPrecedenceInvalid is an error for when the precedence is not one of ContractSpecPrecedence.
+	ContractSpecPrecedenceInvalid = errors.New("contract specification precedence is invalid")
+
+	// ContractSpecPrecedence is an equivalent to an enum for how loaded contract specifications are merged
+	// into the built-in ones when an instrument is defined in both.
+	// BUILTIN keeps the built-in specification, FILE overrides it with the loaded one and STRICT returns an error.
+	ContractSpecPrecedence = contractSpecPrecedence{BUILTIN: "BUILTIN", FILE: "FILE", STRICT: "STRICT"}
+)
+
+type contractSpecPrecedence struct {
+	BUILTIN string
+	FILE    string
+	STRICT  string
+}
+
+// ContractSpec is a struct representing the specification of a futures contract.
+type ContractSpec struct {
+	// TickSize is the minimum price movement of the contract, measured in index points.
+	TickSize float64 `json:"TickSize"`
+
+	// TickValue is the value of one TickSize price movement for one contract, in Currency.
+	TickValue float64 `json:"TickValue"`
+
+	// Currency is the ISO currency code that the contract's profit and loss is settled in.
+	Currency string `json:"Currency"`
+
+	// Exchange is the exchange the contract is listed on.
+	Exchange string `json:"Exchange,omitempty"`
+
+	// SessionTemplate is the name of the trading session the contract follows.
+	SessionTemplate string `json:"SessionTemplate,omitempty"`
+
+	// Commission is the typical round turn commission and fees for one contract, in Currency.
+	Commission float64 `json:"Commission,omitempty"`
+
+	// InitialMargin is the typical margin required to open one contract, in Currency.
+	InitialMargin float64 `json:"InitialMargin,omitempty"`
+
+	// MaintenanceMargin is the typical margin required to hold one contract, in Currency.
+	MaintenanceMargin float64 `json:"MaintenanceMargin,omitempty"`
+}
+
+// PointValue returns the value of a one point price movement for one contract, in Currency.
+func (s ContractSpec) PointValue() float64 {
+	if s.TickSize == 0 {
+		return 0
+	}
+	return s.TickValue / s.TickSize
+}
+
+// Validate returns a ContractSpecInvalid error if any value of the specification is missing or impossible.
+func (s ContractSpec) Validate() error {
+	switch {
+	case s.TickSize <= 0:
+		return fmt.Errorf("TickSize must be greater than 0: %w", ContractSpecInvalid)
+	case s.TickValue <= 0:
+		return fmt.Errorf("TickValue must be greater than 0: %w", ContractSpecInvalid)
+	case len(s.Currency) != 3:
+		return fmt.Errorf("Currency must be a three letter code, got %q: %w", s.Currency, ContractSpecInvalid)
+	case s.Commission < 0 || s.InitialMargin < 0 || s.MaintenanceMargin < 0:
+		return fmt.Errorf("Commission and margins cannot be negative: %w", ContractSpecInvalid)
+	case s.MaintenanceMargin > s.InitialMargin:
+		return fmt.Errorf("MaintenanceMargin cannot be greater than InitialMargin: %w", ContractSpecInvalid)
+	}
+	return nil
+}
+
+// ContractSpecRegistry is a mapping of Instrument -> ContractSpec
+type ContractSpecRegistry map[string]ContractSpec
+
+// ContractSpecs is the registry of every contract the backtester knows about.
+// This is seeded with the built-in specifications, which are the primary trusted source as agreed with the OMITTED team.
+// Margins and commissions are typical values and should be overridden with a contract specification file if needed.
+var ContractSpecs = ContractSpecRegistry{
+	// S&P 500
+	"ES":  {TickSize: 0.25, TickValue: 12.5, Currency: "USD", Exchange: "CME", SessionTemplate: "CME Equity", Commission: 4.5, InitialMargin: 13200, MaintenanceMargin: 12000},
+	"MES": {TickSize: 0.25, TickValue: 1.25, Currency: "USD", Exchange: "CME", SessionTemplate: "CME Equity", Commission: 1.24, InitialMargin: 1320, MaintenanceMargin: 1200},
+	// Nasdaq
+	"NQ":  {TickSize: 0.25, TickValue: 5, Currency: "USD", Exchange: "CME", SessionTemplate: "CME Equity", Commission: 4.5, InitialMargin: 19250, MaintenanceMargin: 17500},
+	"MNQ": {TickSize: 0.25, TickValue: 0.5, Currency: "USD", Exchange: "CME", SessionTemplate: "CME Equity", Commission: 1.24, InitialMargin: 1925, MaintenanceMargin: 1750},
+	// Euro
+	"EC":  {TickSize: 0.00005, TickValue: 6.25, Currency: "USD", Exchange: "CME", SessionTemplate: "CME FX", Commission: 4.5, InitialMargin: 2530, MaintenanceMargin: 2300},
+	"M6E": {TickSize: 0.0001, TickValue: 1.25, Currency: "USD", Exchange: "CME", SessionTemplate: "CME FX", Commission: 1.24, InitialMargin: 253, MaintenanceMargin: 230},
+	// Crude oil
+	"CL":  {TickSize: 0.01, TickValue: 10, Currency: "USD", Exchange: "NYMEX", SessionTemplate: "CME Energy", Commission: 4.5, InitialMargin: 6600, MaintenanceMargin: 6000},
+	"MCL": {TickSize: 0.01, TickValue: 1, Currency: "USD", Exchange: "NYMEX", SessionTemplate: "CME Energy", Commission: 1.24, InitialMargin: 660, MaintenanceMargin: 600},
+	// Gold
+	"GC":  {TickSize: 0.1, TickValue: 10, Currency: "USD", Exchange: "COMEX", SessionTemplate: "CME Metals", Commission: 4.5, InitialMargin: 9900, MaintenanceMargin: 9000},
+	"MGC": {TickSize: 0.1, TickValue: 1, Currency: "USD", Exchange: "COMEX", SessionTemplate: "CME Metals", Commission: 1.24, InitialMargin: 990, MaintenanceMargin: 900},
+	// Yen, 6J is quoted in US dollars per yen and the micro M6J is USD/JPY quoted in yen per dollar and settled in yen
+	"6J":  {TickSize: 0.0000005, TickValue: 6.25, Currency: "USD", Exchange: "CME", SessionTemplate: "CME FX", Commission: 4.5, InitialMargin: 3300, MaintenanceMargin: 3000},
+	"M6J": {TickSize: 0.01, TickValue: 100, Currency: "JPY", Exchange: "CME", SessionTemplate: "CME FX", Commission: 186, InitialMargin: 49500, MaintenanceMargin: 45000},
+	// Pound
+	"BP":  {TickSize: 0.0001, TickValue: 6.25, Currency: "USD", Exchange: "CME", SessionTemplate: "CME FX", Commission: 4.5, InitialMargin: 2860, MaintenanceMargin: 2600},
+	"M6B": {TickSize: 0.0001, TickValue: 0.625, Currency: "USD", Exchange: "CME", SessionTemplate: "CME FX", Commission: 1.24, InitialMargin: 286, MaintenanceMargin: 260},
+	// Australian Dollar
+	"AD":  {TickSize: 0.00005, TickValue: 5, Currency: "USD", Exchange: "CME", SessionTemplate: "CME FX", Commission: 4.5, InitialMargin: 1650, MaintenanceMargin: 1500},
+	"M6A": {TickSize: 0.0001, TickValue: 1, Currency: "USD", Exchange: "CME", SessionTemplate: "CME FX", Commission: 1.24, InitialMargin: 165, MaintenanceMargin: 150},
+}
+
+// LoadContractSpecs loads a JSON file of Instrument -> ContractSpec from the file path
+// and validates every specification in it.
+func LoadContractSpecs(filePath string) (ContractSpecRegistry, error) {
+	specsFile, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var registry ContractSpecRegistry
+	if err := json.Unmarshal(specsFile, &registry); err != nil {
+		return nil, err
+	}
+
+	for instrument, spec := range registry {
+		if err := spec.Validate(); err != nil {
+			return nil, fmt.Errorf("%s in %s: %w", instrument, filePath, err)
+		}
+	}
+
+	return registry, nil
+}
+
+// Merge adds every specification from other into the registry.
+// When an instrument already exists with a different specification the precedence decides the outcome,
+// see ContractSpecPrecedence. Every override or ignored specification is logged so nothing is merged silently.
+func (r ContractSpecRegistry) Merge(other ContractSpecRegistry, precedence string) error {
+	if precedence != ContractSpecPrecedence.BUILTIN &&
+		precedence != ContractSpecPrecedence.FILE &&
+		precedence != ContractSpecPrecedence.STRICT {
+		return fmt.Errorf("got %q: %w", precedence, ContractSpecPrecedenceInvalid)
+	}
+
+	for instrument, spec := range other {
+		if err := spec.Validate(); err != nil {
+			return fmt.Errorf("%s: %w", instrument, err)
+		}
+
+		existing, exists := r[instrument]
+		switch {
+		// New instruments are always added
+		case !exists:
+			log.Info().Str("instrument", instrument).Msg("Adding contract specification")
+			r[instrument] = spec
+		// Identical specifications have nothing to merge
+		case existing == spec:
+			continue
+		case precedence == ContractSpecPrecedence.STRICT:
+			return fmt.Errorf(
+				"%s differs on %v: %w",
+				instrument,
+				differingFields(existing, spec),
+				ContractSpecConflict,
+			)
+		case precedence == ContractSpecPrecedence.FILE:
+			log.Warn().Str("instrument", instrument).Msgf(
+				"Overriding built-in contract specification fields %v",
+				differingFields(existing, spec),
+			)
+			r[instrument] = spec
+		default:
+			log.Warn().Str("instrument", instrument).Msgf(
+				"Ignoring contract specification as it differs from the built-in on %v",
+				differingFields(existing, spec),
+			)
+		}
+	}
+
+	return nil
+}
+
+// differingFields returns the names of every field that is not equal between two specifications.
+func differingFields(a, b ContractSpec) []string {
+	var fields []string
+
+	valueA, valueB := reflect.ValueOf(a), reflect.ValueOf(b)
+	for i := 0; i < valueA.NumField(); i++ {
+		if valueA.Field(i).Interface() != valueB.Field(i).Interface() {
+			fields = append(fields, valueA.Type().Field(i).Name)
+		}
+	}
+
+	return fields
+}
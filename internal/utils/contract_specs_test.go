@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestBuiltInContractSpecsAreValid tests that every built-in specification passes validation
+func TestBuiltInContractSpecsAreValid(t *testing.T) {
+	for instrument, spec := range ContractSpecs {
+		require.NoError(t, spec.Validate(), instrument)
+	}
+}
+
+// TestContractSpec_PointValue tests the value of a full point move
+func TestContractSpec_PointValue(t *testing.T) {
+	require.Equal(t, 50.0, ContractSpecs["ES"].PointValue())
+	require.Equal(t, 20.0, ContractSpecs["NQ"].PointValue())
+	require.Equal(t, 0.0, ContractSpec{}.PointValue())
+	require.Equal(t, 10000.0, ContractSpecs["M6J"].PointValue())
+
+	// Every micro quoted like its full size contract is worth a tenth of it
+	for micro, full := range map[string]string{"MES": "ES", "MNQ": "NQ", "MCL": "CL", "MGC": "GC"} {
+		require.InDelta(t, ContractSpecs[full].PointValue()/10, ContractSpecs[micro].PointValue(), 1e-6, micro)
+	}
+}
+
+// TestContractSpec_Validate tests each validation rule
+func TestContractSpec_Validate(t *testing.T) {
+	valid := ContractSpec{TickSize: 0.25, TickValue: 12.5, Currency: "USD", InitialMargin: 100, MaintenanceMargin: 90}
+
+	tests := []struct {
+		name   string
+		modify func(s *ContractSpec)
+		valid  bool
+	}{
+		{"valid", func(s *ContractSpec) {}, true},
+		{"no tick size", func(s *ContractSpec) { s.TickSize = 0 }, false},
+		{"no tick value", func(s *ContractSpec) { s.TickValue = 0 }, false},
+		{"bad currency", func(s *ContractSpec) { s.Currency = "US" }, false},
+		{"negative commission", func(s *ContractSpec) { s.Commission = -1 }, false},
+		{"maintenance above initial", func(s *ContractSpec) { s.MaintenanceMargin = 200 }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := valid
+			tt.modify(&spec)
+			err := spec.Validate()
+			if tt.valid {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, ContractSpecInvalid)
+			}
+		})
+	}
+}
+
+// TestContractSpecRegistry_Merge tests every precedence when merging a conflicting and a new specification
+func TestContractSpecRegistry_Merge(t *testing.T) {
+	builtIn := ContractSpec{TickSize: 0.25, TickValue: 12.5, Currency: "USD"}
+	override := ContractSpec{TickSize: 0.25, TickValue: 12.5, Currency: "USD", Commission: 2}
+	added := ContractSpec{TickSize: 1, TickValue: 10, Currency: "EUR"}
+
+	tests := []struct {
+		name       string
+		precedence string
+		wantErr    error
+		wantES     ContractSpec
+	}{
+		{"builtin wins", ContractSpecPrecedence.BUILTIN, nil, builtIn},
+		{"file wins", ContractSpecPrecedence.FILE, nil, override},
+		{"strict", ContractSpecPrecedence.STRICT, ContractSpecConflict, builtIn},
+		{"invalid precedence", "OURS", ContractSpecPrecedenceInvalid, builtIn},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := ContractSpecRegistry{"ES": builtIn}
+			err := registry.Merge(ContractSpecRegistry{"ES": override, "FDAX": added}, tt.precedence)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantES, registry["ES"])
+			require.Equal(t, added, registry["FDAX"])
+		})
+	}
+}
+
+// TestLoadContractSpecs tests loading a valid and an invalid specification file
+func TestLoadContractSpecs(t *testing.T) {
+	directory := t.TempDir()
+
+	validPath := filepath.Join(directory, "valid.json")
+	require.NoError(t, os.WriteFile(validPath, []byte(`{
+		"FDAX": {"TickSize": 1, "TickValue": 25, "Currency": "EUR", "Exchange": "EUREX"}
+	}`), 0600))
+	registry, err := LoadContractSpecs(validPath)
+	require.NoError(t, err)
+	require.Equal(t, 25.0, registry["FDAX"].TickValue)
+
+	invalidPath := filepath.Join(directory, "invalid.json")
+	require.NoError(t, os.WriteFile(invalidPath, []byte(`{"FDAX": {"TickSize": 1}}`), 0600))
+	_, err = LoadContractSpecs(invalidPath)
+	require.ErrorIs(t, err, ContractSpecInvalid)
+
+	_, err = LoadContractSpecs(filepath.Join(directory, "missing.json"))
+	require.Error(t, err)
+}
+
+// TestDifferingFields tests the names of the fields that differ are returned
+func TestDifferingFields(t *testing.T) {
+	a := ContractSpec{TickSize: 0.25, TickValue: 12.5, Currency: "USD"}
+	b := ContractSpec{TickSize: 0.5, TickValue: 12.5, Currency: "EUR"}
+	require.Equal(t, []string{"TickSize", "Currency"}, differingFields(a, b))
+}
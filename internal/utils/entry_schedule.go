@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// JsonClock is a struct specifically to implement custom Unmarshalling on read for a time of day.
+// Only the hour and minute of the Time are used.
+type JsonClock struct {
+	time.Time
+}
+
+// UnmarshalJSON Implements Unmarshal interface for JsonClock
+// This is just so that we can use the same 15:04 format as the regions
+func (j *JsonClock) UnmarshalJSON(b []byte) error {
+	var err error
+	s := strings.Trim(string(b), "\"")
+	j.Time, err = time.Parse("15:04", s)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// MarshalJSON Implements Marshal interface for JsonClock
+// This writes the same 15:04 format UnmarshalJSON reads
+func (j JsonClock) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + j.Time.Format("15:04") + `"`), nil
+}
+
+// minuteOfDay returns the amount of minutes since midnight for the clock.
+func (j JsonClock) minuteOfDay() int {
+	return j.Hour()*60 + j.Minute()
+}
+
+// JsonWeekday is a struct specifically to implement custom Unmarshalling on read for a weekday name.
+type JsonWeekday struct {
+	time.Weekday
+}
+
+// UnmarshalJSON Implements Unmarshal interface for JsonWeekday
+// This accepts the full or three letter name of the day in any case, for example "Monday", "mon" or "MON".
+func (j *JsonWeekday) UnmarshalJSON(b []byte) error {
+	s := strings.ToLower(strings.Trim(string(b), "\""))
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		name := strings.ToLower(weekday.String())
+		if s == name || s == name[:3] {
+			j.Weekday = weekday
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid weekday %q", s)
+}
+
+// MarshalJSON Implements Marshal interface for JsonWeekday
+// This writes the full name of the day, for example "Monday"
+func (j JsonWeekday) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + j.Weekday.String() + `"`), nil
+}
+
+// TimeRange is a struct representing a range of times in the day, both Start and End are inclusive.
+// If the Start is after the End then the range spans midnight.
+type TimeRange struct {
+	// Start is the first time of day in the range.
+	Start JsonClock `json:"Start"`
+
+	// End is the last time of day in the range.
+	End JsonClock `json:"End"`
+}
+
+// Contains returns a boolean for if the time of day of t is within the range.
+func (r TimeRange) Contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	start, end := r.Start.minuteOfDay(), r.End.minuteOfDay()
+
+	// If the range spans midnight then it is either after the start or before the end
+	if start > end {
+		return minute >= start || minute <= end
+	}
+	return minute >= start && minute <= end
+}
+
+// EntrySchedule is a struct representing when new trades are allowed to be opened for an instrument.
+// This is separate to the region window, trades that are already open are still managed until the window closes.
+// Any list left empty does not restrict entries.
+type EntrySchedule struct {
+	// TimeRanges is a list of times of day that trades can be entered in.
+	TimeRanges []TimeRange `json:"TimeRanges,omitempty"`
+
+	// Weekdays is a list of days of the week that trades can be entered on.
+	Weekdays []JsonWeekday `json:"Weekdays,omitempty"`
+
+	// ExcludedDates is a list of dates in the format 2006-01-02 that trades can never be entered on.
+	ExcludedDates []JsonDate `json:"ExcludedDates,omitempty"`
+}
+
+// AllowsEntry returns a boolean for if a trade can be entered at the given time.
+// A nil EntrySchedule allows every entry.
+func (e *EntrySchedule) AllowsEntry(t time.Time) bool {
+	if e == nil {
+		return true
+	}
+
+	// Check the date has not been excluded
+	for _, excludedDate := range e.ExcludedDates {
+		if excludedDate.Year() == t.Year() && excludedDate.YearDay() == t.YearDay() {
+			return false
+		}
+	}
+
+	// Check the day of the week is allowed
+	if len(e.Weekdays) > 0 {
+		allowedDay := false
+		for _, weekday := range e.Weekdays {
+			if weekday.Weekday == t.Weekday() {
+				allowedDay = true
+				break
+			}
+		}
+		if !allowedDay {
+			return false
+		}
+	}
+
+	// Check the time of day is in one of the ranges
+	if len(e.TimeRanges) == 0 {
+		return true
+	}
+	for _, timeRange := range e.TimeRanges {
+		if timeRange.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
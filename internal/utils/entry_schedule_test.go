@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestJsonWeekday_UnmarshalJSON tests the weekday names that can be parsed
+func TestJsonWeekday_UnmarshalJSON(t *testing.T) {
+	testCases := []struct {
+		input       string
+		want        time.Weekday
+		expectError bool
+	}{
+		{"\"Monday\"", time.Monday, false},
+		{"\"fri\"", time.Friday, false},
+		{"\"SUNDAY\"", time.Sunday, false},
+		{"\"Someday\"", time.Sunday, true},
+	}
+
+	for _, tc := range testCases {
+		var weekday JsonWeekday
+		err := weekday.UnmarshalJSON([]byte(tc.input))
+		if tc.expectError {
+			require.Error(t, err, tc.input)
+			continue
+		}
+		require.NoError(t, err, tc.input)
+		require.Equal(t, tc.want, weekday.Weekday, tc.input)
+	}
+}
+
+// TestTimeRange_Contains tests normal ranges and ranges that span midnight
+func TestTimeRange_Contains(t *testing.T) {
+	var normal, overnight TimeRange
+	require.NoError(t, json.Unmarshal([]byte(`{"Start": "09:35", "End": "10:30"}`), &normal))
+	require.NoError(t, json.Unmarshal([]byte(`{"Start": "23:00", "End": "01:00"}`), &overnight))
+
+	day := time.Date(2023, 10, 20, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name      string
+		timeRange TimeRange
+		at        time.Time
+		want      bool
+	}{
+		{"before start", normal, day.Add(9*time.Hour + 30*time.Minute), false},
+		{"equal to start", normal, day.Add(9*time.Hour + 35*time.Minute), true},
+		{"equal to end", normal, day.Add(10*time.Hour + 30*time.Minute), true},
+		{"after end", normal, day.Add(10*time.Hour + 35*time.Minute), false},
+		{"overnight before midnight", overnight, day.Add(23*time.Hour + 30*time.Minute), true},
+		{"overnight after midnight", overnight, day.Add(30 * time.Minute), true},
+		{"overnight outside", overnight, day.Add(12 * time.Hour), false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, tc.timeRange.Contains(tc.at))
+		})
+	}
+}
+
+// TestEntrySchedule_AllowsEntry tests each of the schedule restrictions
+func TestEntrySchedule_AllowsEntry(t *testing.T) {
+	var schedule *EntrySchedule
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"TimeRanges": [{"Start": "09:35", "End": "10:30"}, {"Start": "14:00", "End": "15:00"}],
+		"Weekdays": ["Monday", "Tuesday", "Friday"],
+		"ExcludedDates": ["2023-10-23"]
+	}`), &schedule))
+
+	testCases := []struct {
+		name     string
+		schedule *EntrySchedule
+		at       time.Time
+		want     bool
+	}{
+		{"nil schedule", nil, time.Date(2023, 10, 21, 3, 0, 0, 0, time.UTC), true},
+		{"empty schedule", &EntrySchedule{}, time.Date(2023, 10, 21, 3, 0, 0, 0, time.UTC), true},
+		{"allowed first range", schedule, time.Date(2023, 10, 20, 9, 40, 0, 0, time.UTC), true},
+		{"allowed second range", schedule, time.Date(2023, 10, 20, 14, 30, 0, 0, time.UTC), true},
+		{"between ranges", schedule, time.Date(2023, 10, 20, 12, 0, 0, 0, time.UTC), false},
+		{"weekday not allowed", schedule, time.Date(2023, 10, 19, 9, 40, 0, 0, time.UTC), false},
+		{"excluded date", schedule, time.Date(2023, 10, 23, 9, 40, 0, 0, time.UTC), false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, tc.schedule.AllowsEntry(tc.at))
+		})
+	}
+}
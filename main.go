@@ -3,8 +3,13 @@ package main
 import (
 	"bufio"
 	"errors"
+	"flag"
 	"fmt"
 	"github.com/BenHiramTaylor/strongbow-backtester/internal/backtestData"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/fx"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/manifest"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/portfolio"
+	"github.com/BenHiramTaylor/strongbow-backtester/internal/report"
 	"github.com/BenHiramTaylor/strongbow-backtester/internal/tradeConfig"
 	"github.com/BenHiramTaylor/strongbow-backtester/internal/tradeLog"
 	"github.com/BenHiramTaylor/strongbow-backtester/internal/utils"
@@ -12,11 +17,16 @@ import (
 	"github.com/rs/zerolog/log"
 	"io"
 	"os"
+	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 )
 
 func main() {
+	// Store the time of the run, this is used to name every output file
+	runTime := time.Now().UTC()
+
 	// Initialise Logger values
 	logFile, _ := os.OpenFile("back-tester.log", os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
 	writers := []io.Writer{
@@ -32,6 +42,16 @@ func main() {
 	writer := zerolog.MultiLevelWriter(writers...)
 	log.Logger = zerolog.New(writer).Level(zerolog.DebugLevel).With().Logger()
 
+	// Run the subcommand if one is given instead of a backtest. Subcommands are run from a shell or script, so an
+	// error exits straight away rather than waiting for a keypress, and asking for help is not an error.
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1], os.Args[2:], runTime); err != nil && !errors.Is(err, flag.ErrHelp) {
+			log.Error().Msg(err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Read in the config.json
 	userConfiguration, err := utils.LoadConfiguration("config.json")
 	if err != nil {
@@ -39,19 +59,102 @@ func main() {
 		handleErrorAndExit(err)
 	}
 
-	// If any additional tick data is defined in config.json that is NOT in our tick definitions
-	// we add it to ours, we do not overwrite what we have as ours is the primary trusted source
-	// as agreed with the OMITTED team
-	for instrument, tickSize := range userConfiguration.AssetTicks {
-		_, exists := utils.AssetTicks[instrument]
+	if err := loadContractSpecs(userConfiguration); err != nil {
+		handleErrorAndExit(err)
+	}
+
+	if err := runBacktest(userConfiguration, runTime); err != nil {
+		handleErrorAndExit(err)
+	}
+
+	// waitForKeyPress waits for the user to press any key before continuing.
+	waitForKeyPress()
+}
 
-		if !exists {
-			utils.AssetTicks[instrument] = tickSize
+// loadContractSpecs merges the contract specification file of the configuration, if one is defined, into the
+// built-in specifications, the precedence decides what happens when an instrument is in both.
+func loadContractSpecs(cfg *utils.Configuration) error {
+	if cfg.ContractSpecsFile == "" {
+		return nil
+	}
+
+	fileSpecs, err := utils.LoadContractSpecs(cfg.ContractSpecsFile)
+	if err != nil {
+		return err
+	}
+
+	return utils.ContractSpecs.Merge(fileSpecs, cfg.ContractSpecPrecedence)
+}
+
+// newConverter builds the currency converter of the configuration from the fixed rates, and the rate time series
+// if one is defined.
+func newConverter(cfg *utils.Configuration) (*fx.Converter, error) {
+	converter, err := fx.NewConverter(cfg.BaseCurrency, cfg.FxRates)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.FxRatesFile != "" {
+		if err = converter.LoadRatesCSV(cfg.FxRatesFile); err != nil {
+			return nil, err
 		}
 	}
 
-	// Build the log file
-	var logOfTrades = tradeLog.NewLog()
+	return converter, nil
+}
+
+// dataFiles returns the path of every file a backtest with the configuration reads its data from.
+func dataFiles(cfg *utils.Configuration) []string {
+	instruments := cfg.Keys()
+	sort.Strings(instruments)
+
+	var filePaths []string
+	for _, instrument := range instruments {
+		filePaths = append(filePaths, instrumentDataPath(instrument))
+	}
+	for _, filePath := range []string{cfg.ContractSpecsFile, cfg.FxRatesFile} {
+		if filePath != "" {
+			filePaths = append(filePaths, filePath)
+		}
+	}
+
+	return filePaths
+}
+
+// instrumentDataPath returns the path of the candles of an instrument.
+func instrumentDataPath(instrument string) string {
+	return fmt.Sprintf("data/%s.csv", instrument)
+}
+
+// runBacktest backtests every instrument of the configuration with the contract specifications in
+// utils.ContractSpecs, then writes every output and the manifest of the run named by the run time. It returns an
+// error if the backtest cannot run, errors writing an output are logged so the rest are still written.
+func runBacktest(userConfiguration *utils.Configuration, runTime time.Time) error {
+	// Hash the data before it is read so the manifest records exactly what the run used
+	runManifest, err := manifest.New(userConfiguration, utils.ContractSpecs, dataFiles(userConfiguration), runTime)
+	if err != nil {
+		return err
+	}
+
+	// Build the currency converter from the fixed rates, and the rate time series if one is defined
+	converter, err := newConverter(userConfiguration)
+	if err != nil {
+		return err
+	}
+
+	// Validate the analytics before the backtest runs, as they only run once the backtest is finished
+	dimensions, err := analysisDimensions(userConfiguration)
+	if err != nil {
+		return err
+	}
+
+	// Build the log files and the slice of every session window the portfolio engine will trade
+	var (
+		logOfTrades      = tradeLog.NewLog()
+		suppressedTrades = new(tradeLog.SuppressedLog)
+		marginLog        = new(tradeLog.MarginLog)
+		journalLog       = new(tradeLog.JournalLog)
+		sessions         []*portfolio.Session
+	)
 
 	log.Info().Msgf("Backtesting: %+v", userConfiguration.Keys())
 
@@ -60,6 +163,13 @@ func main() {
 	// Create a mutex and wait group to use for the concurrent reading of data
 	var mutex = new(sync.Mutex)
 	var wg = new(sync.WaitGroup)
+	// Collect the error of every instrument that could not be loaded, so they are returned once every file is read
+	var loadErrors []error
+	failed := func(err error) {
+		mutex.Lock()
+		loadErrors = append(loadErrors, err)
+		mutex.Unlock()
+	}
 
 	// Begin iteration of data files
 	for instrumentName, instrumentConfig := range userConfiguration.Instruments {
@@ -72,22 +182,22 @@ func main() {
 		go func() {
 			// Defer the wait group being decremented
 			defer wg.Done()
-			instrumentData, err := backtestData.Load(fmt.Sprintf("data/%s.csv", localInstrumentName))
+			instrumentData, err := backtestData.Load(instrumentDataPath(localInstrumentName))
 			if err != nil {
-				// Was return, needs adding back at later date
-				// TODO ROB THIS NO LONGER WORKS AS ITS INSIDE OF A GOROUTINE
-				handleErrorAndExit(err)
+				failed(err)
+				return
 			}
 
-			// Get the tick size from the mapping
-			tickSize, ok := utils.AssetTicks[localInstrumentName]
+			// Get the contract specification from the registry
+			contractSpec, ok := utils.ContractSpecs[localInstrumentName]
 			if !ok {
 				log.Error().Str(
 					"instrument",
 					localInstrumentName,
-				).Msg("Instrument not found in AssetTicks, add it to the JSON.")
+				).Msg("Instrument not found in ContractSpecs, add it to the contract specifications file.")
 				return
 			}
+			tickSize := contractSpec.TickSize
 
 			// Log message so user knows the time filtering
 			log.Info().Str(
@@ -107,7 +217,8 @@ func main() {
 			log.Info().Str("instrument", localInstrumentName).Msg("Calculating SMA values")
 			err = instrumentData.CalculateSMA(instrumentConfig, tickSize)
 			if err != nil {
-				handleErrorAndExit(err)
+				failed(err)
+				return
 			}
 			log.Info().Str("instrument", localInstrumentName).Msg("Calculated SMA values")
 
@@ -152,8 +263,8 @@ func main() {
 						err.Error(),
 					)
 				}
-				// TODO ROB THIS NO LONGER WORKS AS ITS INSIDE OF A GOROUTINE
-				handleErrorAndExit(err)
+				failed(err)
+				return
 			}
 
 			log.Info().Str("instrument", localInstrumentName).Msg("Filtered by times.")
@@ -161,7 +272,8 @@ func main() {
 			if userConfiguration.WriteProcessedDataToFile {
 				err = instrumentData.WriteToCSV(fmt.Sprintf("./%s.csv", localInstrumentName))
 				if err != nil {
-					handleErrorAndExit(err)
+					failed(err)
+					return
 				}
 			}
 
@@ -173,12 +285,12 @@ func main() {
 	}
 	// Wait for all files to finish loading
 	wg.Wait()
+	if len(loadErrors) > 0 {
+		return errors.Join(loadErrors...)
+	}
 
 	if len(data) == 0 {
-		// len(data) *is* zero.
-		// Duped error message for now
-		log.Error().Msgf("no backtester data was loaded. Please ensure that the data/ directory contains valid data files.")
-		handleErrorAndExit(err)
+		return errors.New("no backtester data was loaded. Please ensure that the data/ directory contains valid data files")
 	}
 
 	for instrument, item := range data {
@@ -206,104 +318,176 @@ func main() {
 		log.Info().Str(
 			"instrument",
 			instrument,
-		).Msg("Starting back testing")
+		).Msg("Building sessions")
 
-		// Get the tick size from the mapping
-		tickSize, ok := utils.AssetTicks[instrument]
-		if !ok {
+		// Only instruments with a contract specification can be traded
+		if _, ok := utils.ContractSpecs[instrument]; !ok {
 			log.Error().Str(
 				"instrument",
 				instrument,
-			).Msg("Instrument not found in AssetTicks, add it to the JSON.")
+			).Msg("Instrument not found in ContractSpecs, add it to the contract specifications file.")
 			continue
 		}
 
-		// Get the instrument-specific configuration from userConfiguration
-		instrumentConfig, configExists := userConfiguration.Instruments[instrument]
-		if !configExists {
-			log.Error().Str(
-				"instrument",
-				instrument,
-			).Msg("Instrument configuration not found in userConfiguration.")
-			continue
-		}
+		// Build the sessions for each region, windows defined in ./internal/utils/region.go
+		instrumentSessions := portfolio.BuildSessions(instrument, historicalData)
+		sessions = append(sessions, instrumentSessions...)
+		log.Info().Str("instrument", instrument).Msgf("Generated %d sessions", len(instrumentSessions))
+	}
 
-		// Begin testing for each region/session
-		for _, region := range utils.StandardConfiguration.Regions {
-			log.Info().Str(
-				"region",
-				region.RegionName,
-			).Msg("Starting back testing for region")
-
-			// Subset the data into a slice of data's for each trade window.
-			// Windows defined in ./internal/utils/region.go
-			subsets, err := historicalData.SubsetDataForMultipleDays(
-				region.MarketOpen,
-				region.MarketClose,
-			)
-			if err != nil {
-				log.Error().Str("error", err.Error()).Msg("Got error on generating subsets")
-				continue
-			}
+	// Simulate the account across every instrument bar by bar, applying the daily risk governors,
+	// portfolio constraints and sizing each trade with the running equity
+	engine, err := portfolio.NewEngine(userConfiguration, utils.ContractSpecs, converter)
+	if err != nil {
+		return err
+	}
+	log.Info().Msgf("Starting back testing across %d sessions", len(sessions))
+	simulation, err := engine.Run(sessions)
+	if err != nil {
+		return err
+	}
+	for _, trade := range simulation.Trades {
+		logOfTrades = tradeLog.AddRow(logOfTrades, trade)
+	}
+	for _, suppressed := range simulation.Suppressed {
+		suppressedTrades = tradeLog.AddSuppressedRow(suppressedTrades, suppressed.Trade, suppressed.Scope, suppressed.Reason)
+	}
+	for _, point := range simulation.MarginUtilisation {
+		marginLog = tradeLog.AddMarginRow(marginLog, point)
+	}
+	for _, entry := range simulation.Journal {
+		journalLog = tradeLog.AddJournalRow(journalLog, entry)
+	}
 
-			log.Info().Str(
-				"instrument",
-				instrument,
-			).Msgf("Generated subsets")
-
-			// Begin iteration of each subset
-			for _, subset := range *subsets {
-				tradeData := tradeConfig.GenerateTradesInWindow(
-					subset,
-					instrumentConfig,
-					instrument,
-					tickSize,
-				)
-
-				log.Debug().Msgf("%+v", tradeData)
-				for _, trade := range tradeData {
-					logOfTrades = tradeLog.AddRow(logOfTrades, trade)
-				}
-			}
+	// Calculate, log and write every statistic of the trades
+	(&analysis{
+		title:             "Strongbow backtest",
+		trades:            logOfTrades,
+		configuration:     userConfiguration,
+		dimensions:        dimensions,
+		baseCurrency:      simulation.BaseCurrency,
+		runTime:           runTime,
+		sessions:          sessions,
+		marginUtilisation: simulation.MarginUtilisation,
+	}).run()
+
+	// Report the trades held overnight separately to the intraday trades as their exposure is different
+	intradayTrades, overnightTrades := logOfTrades.SplitOvernight()
+	for _, split := range []struct {
+		name   string
+		trades *tradeLog.Log
+	}{{"Intraday", intradayTrades}, {"Overnight", overnightTrades}} {
+		log.Info().Msgf(
+			"%s trades: %d with %d wins, RR value %.2f, net profit %.2f %s and %d gap exits",
+			split.name,
+			len(*split.trades),
+			split.trades.TotalWins(),
+			split.trades.SumTotalProfit(),
+			split.trades.SumProfitValue(),
+			simulation.BaseCurrency,
+			split.trades.TotalGapExits(),
+		)
+	}
+
+	log.Info().Msgf("Trades suppressed by risk governors, position sizing or portfolio constraints: %d", len(*suppressedTrades))
+	// Summarise why candles were not traded so the effect of a parameter change can be seen
+	signalCounts := journalLog.CountByReason()
+	for _, outcome := range []string{portfolio.Outcome.TAKEN, portfolio.Outcome.REJECTED, portfolio.Outcome.SUPPRESSED} {
+		for reason, count := range signalCounts[outcome] {
+			log.Info().Str("outcome", outcome).Str("reason", reason).Msgf("Signals: %d", count)
 		}
 	}
 
-	// Log outputs
-	// Calculate ROI and Profit
-	returnOnInvestment := logOfTrades.CalculateCumulativeProfit()
-	totalProfit := (returnOnInvestment - 1) * 100
-
-	// Calculate total wins and win percentage.
-	totalTrades := len(*logOfTrades)
-	totalWins := logOfTrades.TotalWins()
-	winRate := 0.0
-	profitValue := 0.0
-	if totalWins > 0 {
-		winRate = (float64(totalWins) / float64(totalTrades)) * 100
-		profitValue = logOfTrades.CalculateProfitValue(userConfiguration.StartingBalance)
-	}
-	log.Info().Msgf("Cumulative profit percentage: %.2d%%", int64(totalProfit))
-	log.Info().Msgf("Total RR value: %.2f", logOfTrades.SumTotalProfit())
-	log.Info().Msgf("Cumulative profit value with a starting balance of %.2f:  %.2f",
-		userConfiguration.StartingBalance,
-		profitValue,
-	)
 	log.Info().Msgf(
-		"Trades taken: %d with %d wins for a winrate of %.2f%%",
-		totalTrades,
-		totalWins,
-		winRate,
+		"Max margin utilisation: %.2f%% with %d margin calls",
+		marginLog.MaxUtilisation(),
+		simulation.MarginCalls,
 	)
 
-	// Write log to disk
-	err = tradeLog.Write(logOfTrades)
+	// Write the suppressed trades to their own file so they can be reviewed separately
+	if len(*suppressedTrades) > 0 {
+		suppressedPath, err := tradeLog.ResultsFilePath("suppressed", runTime, "csv")
+		if err == nil {
+			err = tradeLog.WriteSuppressed(suppressedTrades, suppressedPath)
+		}
+		if err != nil {
+			log.Error().Msg(err.Error())
+		}
+	}
+
+	// Draw a candlestick chart of every trade that matches the filters, from the processed data already loaded
+	if userConfiguration.TradeCharts != nil && len(simulation.Trades) > 0 {
+		chartsDirectory, err := tradeLog.ResultsSubdirectory("charts", runTime)
+		if err != nil {
+			log.Error().Msg(err.Error())
+		} else {
+			charted := writeTradeCharts(simulation.Trades, data, userConfiguration.TradeCharts, chartsDirectory)
+			log.Info().Msgf("Charted %d trades in %s", charted, chartsDirectory)
+		}
+	}
+
+	// Write the signal journal if the user requested it, this has a row for most candles so can be large
+	if userConfiguration.WriteSignalJournal {
+		journalPath, err := tradeLog.ResultsFilePath("journal", runTime, "csv")
+		if err == nil {
+			err = tradeLog.WriteJournal(journalLog, journalPath)
+		}
+		if err != nil {
+			log.Error().Msg(err.Error())
+		}
+	}
+
+	// Write the margin held over time so utilisation can be charted
+	if len(*marginLog) > 0 {
+		marginPath, err := tradeLog.ResultsFilePath("margin", runTime, "csv")
+		if err == nil {
+			err = tradeLog.WriteMargin(marginLog, marginPath)
+		}
+		if err != nil {
+			log.Error().Msg(err.Error())
+		}
+	}
+
+	// Write the manifest last so it records how long the run took
+	runManifest.Finish(time.Now().UTC())
+	manifestPath, err := tradeLog.ResultsFilePath("manifest", runTime, "json")
+	if err == nil {
+		err = runManifest.WriteJSON(manifestPath)
+	}
 	if err != nil {
 		log.Error().Msg(err.Error())
 	}
 	log.Info().Msg("Computering finito.")
 
-	// waitForKeyPress waits for the user to press any key before continuing.
-	waitForKeyPress()
+	return nil
+}
+
+// writeTradeCharts writes a candlestick chart of every trade that matches the configuration to the directory,
+// and returns the amount of trades charted.
+func writeTradeCharts(
+	trades tradeConfig.Trades,
+	data map[string]backtestData.Data,
+	cfg *utils.TradeChartConfiguration,
+	directory string,
+) int {
+	charted := 0
+	for _, trade := range trades {
+		if !cfg.Includes(trade.Instrument, trade.Direction, trade.ExitReason) {
+			continue
+		}
+
+		chart, err := report.NewTradeChart(trade, data[trade.Instrument], cfg.BarsBefore, cfg.BarsAfter)
+		if err == nil {
+			err = chart.WriteSVG(filepath.Join(directory, report.TradeChartFileName(trade)))
+		}
+		if err != nil {
+			log.Error().Msg(err.Error())
+			continue
+		}
+		charted++
+	}
+
+	return charted
 }
 
 // handleErrorAndExit logs the error and waits for a keypress before exiting.